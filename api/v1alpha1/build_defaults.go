@@ -1,31 +1,35 @@
 package v1alpha1
 
 func (b *Build) Default() {
-	if b.Spec.Context == "" {
-		b.Spec.Context = "."
+	b.Spec.Default()
+}
+
+func (s *BuildSpec) Default() {
+	if s.Context == "" {
+		s.Context = "."
 	}
 
-	if b.Spec.Args != nil {
-		for i := range b.Spec.Args.Items {
-			kp := &b.Spec.Args.Items[i]
+	if s.Args != nil {
+		for i := range s.Args.Items {
+			kp := &s.Args.Items[i]
 			kp.Default()
 		}
 	}
 
-	if b.Spec.Secrets != nil {
-		for i := range b.Spec.Secrets.Items {
-			kp := &b.Spec.Secrets.Items[i]
+	if s.Secrets != nil {
+		for i := range s.Secrets.Items {
+			kp := &s.Secrets.Items[i]
 			kp.Default()
 		}
 	}
 
-	for i := range b.Spec.ContainerRegistries {
-		cr := &b.Spec.ContainerRegistries[i]
+	for i := range s.ContainerRegistries {
+		cr := &s.ContainerRegistries[i]
 		cr.Default()
 	}
 
-	for i := range b.Spec.ImportContent {
-		ic := &b.Spec.ImportContent[i]
+	for i := range s.ImportContent {
+		ic := &s.ImportContent[i]
 		ic.Default()
 	}
 }
@@ -42,11 +42,11 @@ type BuildSpec struct {
 	// this is a valid value here too. This value needs to be a relative path as the path will be created under a random
 	// temporary folder created for this build, inside the builder pod.
 	// If the value is not provided, it will be set to "."
-	// TODO: Create Validation Pattern to exclude absolute path and path starting with ..
+	// Absolute paths and paths that point outside of the build's folder are rejected by the webhook.
 	Context string `json:"context,omitempty"`
 
 	// Dockerfile is the name of the Dockerfile to run for this build. It can include a
-	// TODO: Create Validation Pattern to exclude absolute path and path starting with ..
+	// relative path, which is validated the same way Context is.
 	Dockerfile string `json:"dockerfile"`
 
 	// Target is an optional field that can be set if a build needs to use a Docker target.
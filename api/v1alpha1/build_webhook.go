@@ -43,29 +43,45 @@ var ErrContainerRegistryEmpty = field.Error{
 }
 
 func (b *Build) ValidateCreate() (admission.Warnings, error) {
+	errors := b.Spec.Validate(field.NewPath("spec"))
+
+	if len(errors) > 0 {
+		return nil, apierrors.NewInvalid(
+			schema.GroupKind{Group: "se.quencer.io", Kind: "Build"},
+			b.Name, errors)
+	}
+	return nil, nil
+}
+
+// Validate the spec of a build. This is used for Build as well as for the build
+// of a Component so errors can be caught before the Build is created.
+func (s *BuildSpec) Validate(path *field.Path) field.ErrorList {
 	var errors field.ErrorList
 
-	if len(b.Spec.ContainerRegistries) == 0 {
+	if len(s.ContainerRegistries) == 0 {
 		errors = append(errors, &ErrContainerRegistryEmpty)
 	}
 
-	for _, id := range b.Spec.ImportContent {
+	if err := validators.ValidateRelativePath(path.Child("context"), s.Context); err != nil {
+		errors = append(errors, err)
+	}
+
+	if err := validators.ValidateRelativePath(path.Child("dockerfile"), s.Dockerfile); err != nil {
+		errors = append(errors, err)
+	}
+
+	for i, id := range s.ImportContent {
 		if id.ContentFrom.Git == nil {
 			errors = append(errors, &ErrContentFromMissing)
 			continue
 		}
 
-		if err := validators.ValidateGit(id.ContentFrom.Git); err != nil {
+		if err := validators.ValidateGit(path.Child("importContent").Index(i).Child("contentFrom", "git"), id.ContentFrom.Git); err != nil {
 			errors = append(errors, err)
 		}
 	}
 
-	if len(errors) > 0 {
-		return nil, apierrors.NewInvalid(
-			schema.GroupKind{Group: "se.quencer.io", Kind: "Build"},
-			b.Name, errors)
-	}
-	return nil, nil
+	return errors
 }
 
 func (b *Build) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
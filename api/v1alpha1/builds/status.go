@@ -15,6 +15,9 @@ type Status struct {
 	Conditions []conditions.Condition `json:"conditions"`
 	PodRef     *utils.Reference       `json:"pod,omitempty"`
 	Images     []*Image               `json:"images,omitempty"`
+
+	// GitSHA is the commit that was checked out for the first repository imported by the build.
+	GitSHA string `json:"gitSha,omitempty"`
 }
 
 func (r *Status) Default() {
@@ -44,6 +47,10 @@ func (r *Status) Default() {
 // +kubebuilder:object:generate=true
 type Image struct {
 	URL string `json:"url"`
+
+	// Tags that were pushed to the registry along with the image.
+	Tags []string `json:"tags,omitempty"`
+
 	// Unfortunately, gcr.IndexManifest includes a Hash type
 	// that does custom marshalling which isn't supported by kubebuilder.
 	// Until it is supported, a serialized string will have to do.
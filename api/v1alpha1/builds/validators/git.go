@@ -1,10 +1,89 @@
 package validators
 
 import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/builds"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
-func ValidateGit(git *builds.GitSource) *field.Error {
+// SCP-like syntax supported by git for SSH, ie. git@github.com:pier-oliviert/sequencer.git
+var scpLikeURL = regexp.MustCompile(`^[A-Za-z0-9._-]+@[A-Za-z0-9.-]+:[^\s/][^\s]*$`)
+
+var supportedSchemes = []string{"https", "http", "ssh", "git"}
+
+// ValidateGit makes sure the source can be cloned. The errors are reported under the path of the source.
+func ValidateGit(p *field.Path, git *builds.GitSource) *field.Error {
+	if err := validateGitURL(git.URL); err != nil {
+		return field.Invalid(p.Child("url"), git.URL, fmt.Sprintf("E#1018: %s", err))
+	}
+
+	if err := validateGitRef(git.Ref); err != nil {
+		return field.Invalid(p.Child("ref"), git.Ref, fmt.Sprintf("E#1019: %s", err))
+	}
+
+	return nil
+}
+
+func validateGitURL(value string) error {
+	if value == "" {
+		return fmt.Errorf("URL can't be empty")
+	}
+
+	if scpLikeURL.MatchString(value) {
+		return nil
+	}
+
+	u, err := url.Parse(value)
+	if err != nil {
+		return fmt.Errorf("URL is not valid -- %w", err)
+	}
+
+	if !slices.Contains(supportedSchemes, u.Scheme) {
+		return fmt.Errorf("URL needs to use one of the supported schemes (%s) or be in the form of user@host:path", strings.Join(supportedSchemes, ", "))
+	}
+
+	if u.Host == "" || strings.Trim(u.Path, "/") == "" {
+		return fmt.Errorf("URL needs to include a host and the path of the repository")
+	}
+
+	return nil
+}
+
+// Follows the rules of `git check-ref-format` so that the ref can be checked out. A commit
+// SHA is also a valid ref.
+func validateGitRef(ref string) error {
+	if ref == "" {
+		return fmt.Errorf("ref can't be empty")
+	}
+
+	if ref == "@" {
+		return fmt.Errorf("ref can't be '@'")
+	}
+
+	if strings.HasPrefix(ref, "/") || strings.HasSuffix(ref, "/") || strings.HasSuffix(ref, ".") || strings.HasSuffix(ref, ".lock") {
+		return fmt.Errorf("ref can't start or end with '/', or end with '.' or '.lock'")
+	}
+
+	for _, sequence := range []string{"..", "//", "@{", "/."} {
+		if strings.Contains(ref, sequence) {
+			return fmt.Errorf("ref can't contain '%s'", sequence)
+		}
+	}
+
+	if strings.HasPrefix(ref, ".") {
+		return fmt.Errorf("ref can't start with '.'")
+	}
+
+	for _, r := range ref {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(" ~^:?*[\\", r) {
+			return fmt.Errorf("ref can't contain control characters, spaces, or any of ~^:?*[\\")
+		}
+	}
+
 	return nil
 }
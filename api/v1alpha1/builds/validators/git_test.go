@@ -0,0 +1,72 @@
+package validators
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/builds"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var _ = Describe("Git", func() {
+	DescribeTable("valid sources",
+		func(url, ref string) {
+			Expect(ValidateGit(field.NewPath("git"), &builds.GitSource{URL: url, Ref: ref})).To(BeNil())
+		},
+		Entry("ssh scp-like", "git@github.com:pier-oliviert/sequencer.git", "main"),
+		Entry("https", "https://github.com/pier-oliviert/sequencer.git", "refs/heads/feature/foo"),
+		Entry("ssh scheme", "ssh://git@github.com/pier-oliviert/sequencer.git", "v1.0.0"),
+		Entry("commit sha", "https://github.com/pier-oliviert/sequencer", "4f3a0c1b9e8d7c6b5a4f3e2d1c0b9a8f7e6d5c4b"),
+	)
+
+	DescribeTable("invalid urls",
+		func(url string) {
+			err := ValidateGit(field.NewPath("git"), &builds.GitSource{URL: url, Ref: "main"})
+			Expect(err).NotTo(BeNil())
+			Expect(err.Field).To(Equal("git.url"))
+			Expect(err.Detail).To(ContainSubstring("E#1018"))
+		},
+		Entry("empty", ""),
+		Entry("unsupported scheme", "ftp://github.com/pier-oliviert/sequencer.git"),
+		Entry("missing path", "https://github.com"),
+		Entry("not a url", "github.com pier-oliviert"),
+	)
+
+	DescribeTable("invalid refs",
+		func(ref string) {
+			err := ValidateGit(field.NewPath("git"), &builds.GitSource{URL: "https://github.com/pier-oliviert/sequencer.git", Ref: ref})
+			Expect(err).NotTo(BeNil())
+			Expect(err.Field).To(Equal("git.ref"))
+			Expect(err.Detail).To(ContainSubstring("E#1019"))
+		},
+		Entry("empty", ""),
+		Entry("double dots", "feature..foo"),
+		Entry("space", "my branch"),
+		Entry("lock suffix", "main.lock"),
+		Entry("trailing slash", "feature/"),
+		Entry("reflog syntax", "main@{1}"),
+	)
+})
+
+var _ = Describe("Relative paths", func() {
+	DescribeTable("valid paths",
+		func(path string) {
+			Expect(ValidateRelativePath(nil, path)).To(BeNil())
+		},
+		Entry("empty", ""),
+		Entry("current folder", "."),
+		Entry("sub folder", "app/Dockerfile"),
+		Entry("stays inside", "app/../Dockerfile"),
+	)
+
+	DescribeTable("invalid paths",
+		func(path string) {
+			err := ValidateRelativePath(nil, path)
+			Expect(err).NotTo(BeNil())
+			Expect(err.Detail).To(ContainSubstring("E#1017"))
+		},
+		Entry("absolute", "/etc/passwd"),
+		Entry("parent", ".."),
+		Entry("outside", "app/../../Dockerfile"),
+	)
+})
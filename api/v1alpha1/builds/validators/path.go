@@ -0,0 +1,27 @@
+package validators
+
+import (
+	"path"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateRelativePath makes sure the value is a path relative to the build's folder. The
+// content of a build is imported under a temporary folder and a path can't point outside of it.
+func ValidateRelativePath(p *field.Path, value string) *field.Error {
+	if value == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(value, "/") {
+		return field.Invalid(p, value, "E#1017: Path needs to be relative, absolute paths are not supported")
+	}
+
+	cleaned := path.Clean(value)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return field.Invalid(p, value, "E#1017: Path needs to be relative, it can't point outside of the build's folder")
+	}
+
+	return nil
+}
@@ -55,6 +55,11 @@ func (in *GitSource) DeepCopy() *GitSource {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Image) DeepCopyInto(out *Image) {
 	*out = *in
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Image.
@@ -160,7 +165,7 @@ func (in *Status) DeepCopyInto(out *Status) {
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(Image)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
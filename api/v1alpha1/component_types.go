@@ -22,6 +22,7 @@ import (
 	core "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
 type ComponentSpec struct {
@@ -35,10 +36,72 @@ type ComponentSpec struct {
 	// Pod Template that will be used for the pod
 	Pod core.PodSpec `json:"template"`
 
+	// Workload used to run the pod template. By default, a single pod is created.
+	// +optional
+	Workload components.WorkloadSpec `json:"workload,omitempty"`
+
+	// How long the pods can take to become ready after they are deployed. If they aren't
+	// ready by then, the component errors. There's no timeout by default.
+	// +optional
+	StartupTimeout *meta.Duration `json:"startupTimeout,omitempty"`
+
 	// Block a component to deploy a pod until each of the dependencies are
 	// running and healthy.
 	// +optional
 	DependsOn []Dependency `json:"dependsOn,omitempty"`
+
+	// Jobs that run, in order, once the dependencies are met and before the pod is deployed. This
+	// is where database migrations and seeds usually go.
+	// +optional
+	Jobs []JobSpec `json:"jobs,omitempty"`
+
+	// ConfigMaps created for the component once its variables are resolved. Variables can be used
+	// in the data of a ConfigMap, and the template can reference a ConfigMap by its name here.
+	// +optional
+	ConfigMaps []ConfigMapSpec `json:"configMaps,omitempty"`
+}
+
+type ConfigMapSpec struct {
+	// Name used to reference the ConfigMap in the volumes and environment of the template.
+	Name string            `json:"name"`
+	Data map[string]string `json:"data"`
+}
+
+// WalkVariables calls fn for each of the fields of the spec that support variables, in the
+// template, the jobs and the ConfigMaps. It stops at the first error.
+func (s *ComponentSpec) WalkVariables(path *field.Path, fn components.StringFieldFunc) error {
+	if err := components.WalkPodSpec(&s.Pod, path.Child("template"), fn); err != nil {
+		return err
+	}
+
+	for i := range s.Jobs {
+		if err := components.WalkPodSpec(&s.Jobs[i].Pod, path.Child("jobs").Index(i).Child("template"), fn); err != nil {
+			return err
+		}
+	}
+
+	for i, cm := range s.ConfigMaps {
+		for key, value := range cm.Data {
+			if err := fn(path.Child("configMaps").Index(i).Child("data").Key(key), &value); err != nil {
+				return err
+			}
+			cm.Data[key] = value
+		}
+	}
+
+	return nil
+}
+
+type JobSpec struct {
+	Name string `json:"name"`
+
+	// Pod Template used by the job. Variables are interpolated the same way they are for
+	// the component's pod.
+	Pod core.PodSpec `json:"template"`
+
+	// Number of retries before the job is considered failed, defaults to the Job's default (6).
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -66,6 +129,12 @@ type Component struct {
 	Status components.Status `json:"status,omitempty"`
 }
 
+// A sleeping component has its pod removed, but keeps everything else (services, variables, etc.)
+// so it can be woken up without going through the whole deployment again.
+func (c Component) IsSleeping() bool {
+	return c.Annotations[components.SleepAnnotation] == "true"
+}
+
 //+kubebuilder:object:root=true
 
 // ComponentList contains a list of Component
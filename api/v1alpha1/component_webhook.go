@@ -0,0 +1,111 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (c *Component) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(c).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-se-quencer-io-v1alpha1-component,mutating=true,failurePolicy=fail,sideEffects=None,groups=se.quencer.io,resources=components,verbs=create;update,versions=v1alpha1,name=mcomponent.se.quencer.io,admissionReviewVersions=v1
+var _ webhook.Defaulter = &Component{}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-se-quencer-io-v1alpha1-component,mutating=false,failurePolicy=fail,groups=se.quencer.io,resources=components,versions=v1alpha1,name=vcomponent.se.quencer.io,sideEffects=None,admissionReviewVersions=v1
+var _ webhook.Validator = &Component{}
+
+func (c *Component) Default() {
+	c.Spec.Default()
+}
+
+func (c *Component) ValidateCreate() (admission.Warnings, error) {
+	return nil, c.validate()
+}
+
+func (c *Component) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	// Only validate the spec when it changes so that components created before the
+	// validation existed can still be updated.
+	if previous, ok := old.(*Component); ok && equality.Semantic.DeepEqual(previous.Spec, c.Spec) {
+		return nil, nil
+	}
+
+	return nil, c.validate()
+}
+
+func (c *Component) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (c *Component) validate() error {
+	errors := c.Spec.Validate(field.NewPath("spec"))
+
+	if len(errors) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: "se.quencer.io", Kind: "Component"},
+			c.Name, errors)
+	}
+	return nil
+}
+
+func (s *ComponentSpec) Default() {
+	if s.Workload.Kind == "" {
+		s.Workload.Kind = components.WorkloadPod
+	}
+
+	if s.Build != nil {
+		s.Build.Default()
+	}
+}
+
+// Validate the spec of a component. This is used by the Component's webhook as well as the
+// Workspace's webhook so errors are caught before the components are created.
+func (s *ComponentSpec) Validate(path *field.Path) field.ErrorList {
+	var errors field.ErrorList
+
+	// The walk is done on a copy as the callback receives pointers to the fields.
+	spec := s.DeepCopy()
+	_ = spec.WalkVariables(path, func(p *field.Path, value *string) error {
+		for _, expression := range components.MalformedVariables(*value) {
+			errors = append(errors, field.Invalid(p, *value, fmt.Sprintf("%s (%s)", components.ErrNotAResolvableValue, expression)))
+		}
+
+		for _, variable := range components.Variables(*value) {
+			if err := components.ValidateVariable(variable); err != nil {
+				errors = append(errors, field.Invalid(p, *value, err.Error()))
+				continue
+			}
+
+			// Secrets and ConfigMaps are rendered as environment variables, which Kubernetes doesn't expand
+			// in images nor in the data of a ConfigMap.
+			if components.IsReference(variable) && !supportsReferences(p) {
+				errors = append(errors, field.Invalid(p, *value, "E#2020: Secrets and ConfigMaps can only be used in the command, args and environment of a container"))
+			}
+		}
+		return nil
+	})
+
+	if s.Build != nil {
+		errors = append(errors, s.Build.Validate(path.Child("build"))...)
+	}
+
+	return errors
+}
+
+func supportsReferences(path *field.Path) bool {
+	p := path.String()
+	return !strings.HasSuffix(p, ".image") && !strings.Contains(p, ".configMaps[")
+}
@@ -8,6 +8,10 @@ const (
 	NameLabel     string = "components.sequencer.io/name"
 	InstanceLabel string = "components.sequencer.io/instance"
 	NetworkLabel  string = "components.sequencer.io/network"
+	JobLabel      string = "components.sequencer.io/job"
+
+	// Set by the workspace on each of its components when the workspace is sleeping.
+	SleepAnnotation string = "components.sequencer.io/sleep"
 
 	InterpolationDelimStart string = "${"
 )
@@ -18,6 +22,7 @@ const (
 	PodCondition          conditions.ConditionType = "Pod"
 	DependenciesCondition conditions.ConditionType = "Dependency"
 	VariablesCondition    conditions.ConditionType = "Variables"
+	JobsCondition         conditions.ConditionType = "Jobs"
 
 	ConditionReasonInitialized   string = "Initialized"
 	ConditionReasonProcessing    string = "Processing"
@@ -27,4 +32,9 @@ const (
 	ConditionReasonSkipped       string = "Build Skipped"
 	ConditionReasonPodTerminated string = "Pod Terminated"
 	ConditionReasonDependsOn     string = "Depends on other components"
+	ConditionReasonSleeping      string = "Sleeping"
+	ConditionReasonRollingOut    string = "Rolling out"
+	ConditionReasonStarting      string = "Waiting for pod to be ready"
+	ConditionReasonReady         string = "Ready"
+	ConditionReasonNotReady      string = "Not ready"
 )
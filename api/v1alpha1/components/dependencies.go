@@ -0,0 +1,15 @@
+package components
+
+import "github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+
+// DependableConditions lists, for each condition of a component, the statuses that another
+// component can wait on with DependsOn. A dependency that uses a pair that isn't listed here would wait forever
+// as the component never sets its condition to that status. Jobs completes even when the component has no jobs.
+var DependableConditions = map[conditions.ConditionType][]conditions.ConditionStatus{
+	BuildCondition:        {conditions.ConditionCompleted},
+	NetworkCondition:      {conditions.ConditionCompleted},
+	DependenciesCondition: {conditions.ConditionCompleted},
+	VariablesCondition:    {conditions.ConditionCompleted},
+	JobsCondition:         {conditions.ConditionCompleted},
+	PodCondition:          {conditions.ConditionHealthy},
+}
@@ -0,0 +1,170 @@
+package components
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// References are variables that point to a key in a Secret or a ConfigMap. Their values are never
+// copied in the status of a component, instead they are rendered as environment variables that use
+// `valueFrom` and Kubernetes retrieves the value when the container starts.
+
+var envNameSanitizer = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// IsReference returns true if the variable points to a key in a Secret or a ConfigMap.
+func IsReference(variable string) bool {
+	parsed := VariableParser.FindStringSubmatch(variable)
+	return parsed != nil && (parsed[1] == SecretsResolver || parsed[1] == ConfigMapsResolver)
+}
+
+// ParseReference returns the resolver, the name of the resource and the key that the reference points to.
+// The key can include dots, as the keys of Secrets and ConfigMaps usually do (eg. `tls.crt`).
+func ParseReference(variable string) (resolver, name, key string, err error) {
+	if err := ValidateVariable(variable); err != nil {
+		return "", "", "", err
+	}
+
+	if !IsReference(variable) {
+		return "", "", "", fmt.Errorf("E#2006: Variable (%s) doesn't reference a Secret or a ConfigMap", variable)
+	}
+
+	parsed := VariableParser.FindStringSubmatch(variable)
+	name, key, _ = strings.Cut(parsed[2], ".")
+	return parsed[1], name, key, nil
+}
+
+// ReferenceSource returns the source Kubernetes uses to retrieve the value of the reference.
+func ReferenceSource(variable string) (*core.EnvVarSource, error) {
+	resolver, name, key, err := ParseReference(variable)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolver == SecretsResolver {
+		return &core.EnvVarSource{
+			SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: name},
+				Key:                  key,
+			},
+		}, nil
+	}
+
+	return &core.EnvVarSource{
+		ConfigMapKeyRef: &core.ConfigMapKeySelector{
+			LocalObjectReference: core.LocalObjectReference{Name: name},
+			Key:                  key,
+		},
+	}, nil
+}
+
+// ReferenceEnvName returns the name of the environment variable that holds the value of the reference
+// when the reference is used within a string. Different references can look the same once sanitized, eg.
+// `${secrets::db-creds.pw}` and `${secrets::db_creds.pw}`, so the name ends with a hash of the reference.
+func ReferenceEnvName(variable string) string {
+	parsed := VariableParser.FindStringSubmatch(variable)
+	if parsed == nil {
+		return ""
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(parsed[0]))
+
+	name := strings.ToUpper(fmt.Sprintf("SEQUENCER_%s_%s_%x", parsed[1], parsed[2], hash.Sum32()))
+	return envNameSanitizer.ReplaceAllString(name, "_")
+}
+
+// AssignReferences renders the references found in the containers of the pod spec. An environment variable
+// that only holds a reference gets its value from the Secret or ConfigMap directly. References used elsewhere,
+// like within a string or in the command and args, are replaced with `$(NAME)` and an environment
+// variable with that name is added to the container, which Kubernetes expands when the container starts.
+func AssignReferences(spec *core.PodSpec, path *field.Path) error {
+	if err := assignContainerReferences(spec.InitContainers, path.Child("initContainers")); err != nil {
+		return err
+	}
+
+	return assignContainerReferences(spec.Containers, path.Child("containers"))
+}
+
+func assignContainerReferences(containers []core.Container, path *field.Path) error {
+	for i := range containers {
+		container := &containers[i]
+		p := path.Index(i)
+
+		for _, variable := range Variables(container.Image) {
+			if IsReference(variable) {
+				return fmt.Errorf("%s: E#2020: Secrets and ConfigMaps can't be used in an image", p.Child("image"))
+			}
+		}
+
+		for j := range container.Env {
+			env := &container.Env[j]
+			if !IsReference(env.Value) || env.Value != VariableParser.FindString(env.Value) {
+				continue
+			}
+
+			source, err := ReferenceSource(env.Value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", p.Child("env").Index(j).Child("value"), err)
+			}
+
+			env.Value = ""
+			env.ValueFrom = source
+		}
+
+		var envs []core.EnvVar
+		added := map[string]bool{}
+		replace := func(path *field.Path, value *string) error {
+			var err error
+			*value = VariableParser.ReplaceAllStringFunc(*value, func(variable string) string {
+				if !IsReference(variable) {
+					return variable
+				}
+
+				name := ReferenceEnvName(variable)
+				if !added[name] {
+					source, sourceErr := ReferenceSource(variable)
+					if sourceErr != nil && err == nil {
+						err = fmt.Errorf("%s: %w", path, sourceErr)
+					}
+
+					envs = append(envs, core.EnvVar{Name: name, ValueFrom: source})
+					added[name] = true
+				}
+
+				return fmt.Sprintf("$(%s)", name)
+			})
+
+			return err
+		}
+
+		for j := range container.Command {
+			if err := replace(p.Child("command").Index(j), &container.Command[j]); err != nil {
+				return err
+			}
+		}
+
+		for j := range container.Args {
+			if err := replace(p.Child("args").Index(j), &container.Args[j]); err != nil {
+				return err
+			}
+		}
+
+		for j := range container.Env {
+			if err := replace(p.Child("env").Index(j).Child("value"), &container.Env[j].Value); err != nil {
+				return err
+			}
+		}
+
+		// Kubernetes only expands variables that are defined before the one using them.
+		if len(envs) > 0 {
+			container.Env = append(envs, container.Env...)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package components
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var _ = Describe("References", func() {
+	path := field.NewPath("spec", "template")
+
+	It("keeps the dots of the key", func() {
+		resolver, name, key, err := ParseReference("${secrets::tls.tls.crt}")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resolver).To(Equal(SecretsResolver))
+		Expect(name).To(Equal("tls"))
+		Expect(key).To(Equal("tls.crt"))
+	})
+
+	It("renders an environment variable that only holds a reference as valueFrom", func() {
+		spec := &core.PodSpec{Containers: []core.Container{{
+			Env: []core.EnvVar{{Name: "PASSWORD", Value: "${secrets::mysql.password}"}},
+		}}}
+
+		Expect(AssignReferences(spec, path)).To(Succeed())
+		Expect(spec.Containers[0].Env).To(Equal([]core.EnvVar{{
+			Name: "PASSWORD",
+			ValueFrom: &core.EnvVarSource{SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: "mysql"},
+				Key:                  "password",
+			}},
+		}}))
+	})
+
+	It("expands references used within a string through an environment variable", func() {
+		spec := &core.PodSpec{Containers: []core.Container{{
+			Args: []string{"--url", "mysql://root:${secrets::mysql.password}@mysql/${configmaps::app.database}"},
+			Env:  []core.EnvVar{{Name: "DATABASE", Value: "${configmaps::app.database}_test"}},
+		}}}
+
+		Expect(AssignReferences(spec, path)).To(Succeed())
+
+		password := ReferenceEnvName("${secrets::mysql.password}")
+		database := ReferenceEnvName("${configmaps::app.database}")
+		Expect(password).To(MatchRegexp(`^SEQUENCER_SECRETS_MYSQL_PASSWORD_[0-9A-F]{8}$`))
+		Expect(database).To(MatchRegexp(`^SEQUENCER_CONFIGMAPS_APP_DATABASE_[0-9A-F]{8}$`))
+
+		container := spec.Containers[0]
+		Expect(container.Args[1]).To(Equal("mysql://root:$(" + password + ")@mysql/$(" + database + ")"))
+		Expect(container.Env).To(HaveLen(3))
+		Expect(container.Env[0].Name).To(Equal(password))
+		Expect(container.Env[1].Name).To(Equal(database))
+		Expect(container.Env[1].ValueFrom.ConfigMapKeyRef.Key).To(Equal("database"))
+		Expect(container.Env[2].Value).To(Equal("$(" + database + ")_test"))
+	})
+
+	It("keeps references that look the same once sanitized apart", func() {
+		spec := &core.PodSpec{Containers: []core.Container{{
+			Args: []string{"${secrets::db.api-key}", "${secrets::db.api_key}"},
+		}}}
+
+		Expect(AssignReferences(spec, path)).To(Succeed())
+
+		container := spec.Containers[0]
+		Expect(container.Args[0]).NotTo(Equal(container.Args[1]))
+		Expect(container.Env).To(HaveLen(2))
+		Expect(container.Env[0].ValueFrom.SecretKeyRef.Key).To(Equal("api-key"))
+		Expect(container.Env[1].ValueFrom.SecretKeyRef.Key).To(Equal("api_key"))
+		Expect(container.Args[1]).To(Equal("$(" + container.Env[1].Name + ")"))
+	})
+
+	It("doesn't support references in an image", func() {
+		spec := &core.PodSpec{Containers: []core.Container{{Image: "${secrets::registry.image}"}}}
+		Expect(AssignReferences(spec, path)).To(MatchError(ContainSubstring("spec.template.containers[0].image: E#2020")))
+	})
+})
@@ -11,13 +11,14 @@ const (
 	PhaseInitializing Phase = "Initializing"
 	PhaseDeploying    Phase = "Deploying"
 	PhaseHealthy      Phase = "Healthy"
+	PhaseSleeping     Phase = "Sleeping"
 	PhaseError        Phase = "Error"
 	PhaseTerminating  Phase = "Terminating"
 )
 
 // +kubebuilder:object:generate=true
 type Status struct {
-	// +kubebuilder:validation:Enum=Initializing;Deploying;Healthy;Error;Terminating
+	// +kubebuilder:validation:Enum=Initializing;Deploying;Healthy;Sleeping;Error;Terminating
 	Phase Phase `json:"phase"`
 
 	Conditions  []conditions.Condition `json:"conditions,omitempty"`
@@ -25,6 +26,11 @@ type Status struct {
 	IngressRefs []utils.Reference      `json:"ingressRefs,omitempty"`
 
 	Variables []GeneratedVariable `json:"variables,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation of the spec that the
+	// current status was built from. When the spec changes, the component
+	// is redeployed and this value is updated.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 type GeneratedVariable struct {
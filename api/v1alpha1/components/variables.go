@@ -0,0 +1,209 @@
+package components
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// VariableParser matches a variable in the form of `${resolver::params}`. A string can include
+// many variables, anywhere in the string.
+var VariableParser = regexp.MustCompile(`\$\{([a-zA-Z]+)::([a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)*)\}`)
+
+const (
+	BuildResolver      string = "build"
+	ComponentsResolver string = "components"
+	IngressResolver    string = "ingress"
+	WorkspaceResolver  string = "workspace"
+	SecretsResolver    string = "secrets"
+	ConfigMapsResolver string = "configmaps"
+)
+
+// Fields of a build that can be retrieved with `${build::buildName.field}`. Without a field, the variable
+// resolves to the image with its digest.
+const (
+	BuildFieldDigest string = "digest"
+	BuildFieldTag    string = "tag"
+	BuildFieldGitSHA string = "gitSha"
+)
+
+// Fields of a workspace that can be retrieved with `${workspace::field}`.
+const (
+	WorkspaceFieldHost      string = "host"
+	WorkspaceFieldName      string = "name"
+	WorkspaceFieldNamespace string = "namespace"
+)
+
+// Appended to a components variable to retrieve the port of the network instead of its host.
+const NetworkFieldPort string = "port"
+
+var ErrNotAResolvableValue = errors.New("E#2006: Variable couldn't be parsed")
+
+// Variables returns each of the variables found in the content, in the order they appear.
+func Variables(content string) []string {
+	return VariableParser.FindAllString(content, -1)
+}
+
+// MalformedVariables returns the expressions that start like a variable, `${resolver::`, but aren't
+// one, eg. a variable without its closing brace. Those would be left as is in the pod as they
+// can't be interpolated. Shell variables, like `${HOME}`, aren't expressions for a resolver and are ignored.
+func MalformedVariables(content string) []string {
+	starts := map[int]bool{}
+	for _, match := range VariableParser.FindAllStringIndex(content, -1) {
+		starts[match[0]] = true
+	}
+
+	var malformed []string
+	for offset := 0; ; {
+		i := strings.Index(content[offset:], "${")
+		if i < 0 {
+			return malformed
+		}
+
+		start := offset + i
+		offset = start + len("${")
+		if starts[start] {
+			continue
+		}
+
+		expression := content[start:]
+		if end := strings.Index(expression, "}"); end >= 0 {
+			expression = expression[:end+1]
+		}
+
+		if strings.Contains(expression, "::") {
+			malformed = append(malformed, expression)
+		}
+	}
+}
+
+// Interpolate replaces each of the variables found in the content with their value. Returns an
+// error if a variable doesn't have a value.
+func Interpolate(content string, values map[string]string) (string, error) {
+	var err error
+
+	interpolated := VariableParser.ReplaceAllStringFunc(content, func(variable string) string {
+		value, ok := values[variable]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("E#2001: Could not find a variable to interpolate for %s", variable)
+			}
+			return variable
+		}
+
+		return value
+	})
+
+	return interpolated, err
+}
+
+// ValidateVariable makes sure the variable is one that a resolver can interpolate. It
+// doesn't check if the resources referenced by the variable exist.
+func ValidateVariable(variable string) error {
+	parsed := VariableParser.FindStringSubmatch(variable)
+	if parsed == nil {
+		return ErrNotAResolvableValue
+	}
+
+	params := strings.Split(parsed[2], ".")
+
+	switch parsed[1] {
+	case BuildResolver:
+		if len(params) > 2 {
+			return fmt.Errorf("E#2012: Unexpected value. Expected format `${build::buildName.field?}`, got %s", variable)
+		}
+
+		if len(params) == 2 && params[1] != BuildFieldDigest && params[1] != BuildFieldTag && params[1] != BuildFieldGitSHA {
+			return fmt.Errorf("E#2013: Invalid field (%s), only supported fields are: %s, %s, %s", params[1], BuildFieldDigest, BuildFieldTag, BuildFieldGitSHA)
+		}
+
+		return nil
+	case ComponentsResolver:
+		if len(params) != 3 && len(params) != 4 {
+			return fmt.Errorf("E#2012: Unexpected value. Expected format `${components::componentName.section.serviceName}`, got %s", variable)
+		}
+
+		if params[1] != "networks" {
+			return errors.New("E#2013: Invalid section, only supported sections are: networks")
+		}
+
+		if len(params) == 4 && params[3] != NetworkFieldPort {
+			return fmt.Errorf("E#2013: Invalid field (%s), only supported fields are: %s", params[3], NetworkFieldPort)
+		}
+
+		return nil
+	case IngressResolver:
+		if len(params) > 2 {
+			return fmt.Errorf("E#2012: Unexpected value. Expected format `${ingress::subdomain?.ingressName}`, got `%s`", variable)
+		}
+
+		return nil
+	case WorkspaceResolver:
+		if len(params) != 1 {
+			return fmt.Errorf("E#2012: Unexpected value. Expected format `${workspace::field}`, got `%s`", variable)
+		}
+
+		if params[0] != WorkspaceFieldHost && params[0] != WorkspaceFieldName && params[0] != WorkspaceFieldNamespace {
+			return fmt.Errorf("E#2013: Invalid field (%s), only supported fields are: %s, %s, %s", params[0], WorkspaceFieldHost, WorkspaceFieldName, WorkspaceFieldNamespace)
+		}
+
+		return nil
+	case SecretsResolver, ConfigMapsResolver:
+		if len(params) < 2 {
+			return fmt.Errorf("E#2012: Unexpected value. Expected format `${%s::name.key}`, got `%s`", parsed[1], variable)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("E#2007: no resolver exists for (%s)", parsed[1])
+}
+
+// StringFieldFunc is called for each of the fields of a spec that can include variables. The
+// path points to the field in the spec, and the value can be modified in place.
+type StringFieldFunc func(path *field.Path, value *string) error
+
+// WalkPodSpec calls fn for each of the fields of the pod spec that support variables: the image,
+// command, args and environment variables of the containers and init containers. It stops at the first error.
+func WalkPodSpec(spec *core.PodSpec, path *field.Path, fn StringFieldFunc) error {
+	if err := walkContainers(spec.InitContainers, path.Child("initContainers"), fn); err != nil {
+		return err
+	}
+
+	return walkContainers(spec.Containers, path.Child("containers"), fn)
+}
+
+func walkContainers(containers []core.Container, path *field.Path, fn StringFieldFunc) error {
+	for i := range containers {
+		container := &containers[i]
+		p := path.Index(i)
+
+		if err := fn(p.Child("image"), &container.Image); err != nil {
+			return err
+		}
+
+		for j := range container.Command {
+			if err := fn(p.Child("command").Index(j), &container.Command[j]); err != nil {
+				return err
+			}
+		}
+
+		for j := range container.Args {
+			if err := fn(p.Child("args").Index(j), &container.Args[j]); err != nil {
+				return err
+			}
+		}
+
+		for j := range container.Env {
+			if err := fn(p.Child("env").Index(j).Child("value"), &container.Env[j].Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
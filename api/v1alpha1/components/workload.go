@@ -0,0 +1,43 @@
+package components
+
+type WorkloadKind string
+
+const (
+	// A single pod that isn't restarted when it fails. This is the default.
+	WorkloadPod WorkloadKind = "Pod"
+
+	// A Deployment that keeps the replicas running and rolls out changes to the spec.
+	WorkloadDeployment WorkloadKind = "Deployment"
+
+	// A StatefulSet, for components that need a stable identity for their pods.
+	WorkloadStatefulSet WorkloadKind = "StatefulSet"
+)
+
+// WorkloadSpec describes the Kubernetes resource used to run the pod template of a component.
+//
+// +kubebuilder:object:generate=true
+type WorkloadSpec struct {
+	// +kubebuilder:validation:Enum=Pod;Deployment;StatefulSet
+	// +kubebuilder:default=Pod
+	// +optional
+	Kind WorkloadKind `json:"kind,omitempty"`
+
+	// Number of pods to run. Only used by Deployment and StatefulSet, defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// Managed returns true if the pods are managed by a workload controller (Deployment, StatefulSet)
+// instead of being created directly by the component.
+func (w WorkloadSpec) Managed() bool {
+	return w.Kind == WorkloadDeployment || w.Kind == WorkloadStatefulSet
+}
+
+func (w WorkloadSpec) ReplicaCount() int32 {
+	if w.Replicas == nil {
+		return 1
+	}
+
+	return *w.Replicas
+}
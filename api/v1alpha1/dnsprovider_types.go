@@ -0,0 +1,79 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strings"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DNSProviderSpec describes a DNS provider and the zones it serves. DNSRecords are
+// routed to the provider that serves their zone, or to the one they reference explicitly.
+// +kubebuilder:validation:XValidation:rule="self.provider == 'local' || has(self.secretRef)",message="secretRef is required for this provider"
+type DNSProviderSpec struct {
+	// Name of the provider's implementation.
+	// +kubebuilder:validation:Enum=cloudflare;aws;google;azure;rfc2136;local
+	Provider string `json:"provider"`
+
+	// Zones served by this provider, eg. `example.com`.
+	// +optional
+	Zones []string `json:"zones,omitempty"`
+
+	// Secret holding the configuration values of the provider. Each key is the name of a value
+	// the provider reads, eg. `CF_API_TOKEN` and `CF_ZONE_ID` for Cloudflare. The local provider doesn't need one.
+	// +optional
+	SecretRef *utils.Reference `json:"secretRef,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name != 'default'",message="default is reserved for the provider configured on the DNS controller"
+
+// DNSProvider is the Schema for the dnsproviders API
+type DNSProvider struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DNSProviderSpec `json:"spec,omitempty"`
+}
+
+// Returns true if the provider serves the zone.
+func (p DNSProvider) Serves(zone string) bool {
+	zone = strings.TrimSuffix(zone, ".")
+	for _, z := range p.Spec.Zones {
+		if strings.EqualFold(strings.TrimSuffix(z, "."), zone) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// +kubebuilder:object:root=true
+
+// DNSProviderList contains a list of DNSProvider
+type DNSProviderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DNSProvider `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DNSProvider{}, &DNSProviderList{})
+}
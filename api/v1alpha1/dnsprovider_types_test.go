@@ -0,0 +1,24 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DNSProvider", func() {
+	provider := DNSProvider{Spec: DNSProviderSpec{
+		Provider: "cloudflare",
+		Zones:    []string{"previews.example.com", "Internal.example.com."},
+	}}
+
+	It("serves the zones it lists", func() {
+		Expect(provider.Serves("previews.example.com")).To(BeTrue())
+		Expect(provider.Serves("previews.example.com.")).To(BeTrue())
+		Expect(provider.Serves("internal.example.com")).To(BeTrue())
+	})
+
+	It("doesn't serve other zones", func() {
+		Expect(provider.Serves("example.com")).To(BeFalse())
+		Expect(provider.Serves("other.previews.example.com")).To(BeFalse())
+	})
+})
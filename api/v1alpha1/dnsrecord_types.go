@@ -12,11 +12,23 @@ import (
 // Represent a single DNS Record
 // The record will be created and metadata about the record will
 // be stored in the Status.
+//
+// The zone, the type and the name identify the record upstream and can't be changed. The target
+// and the properties can be updated and the changes are applied to the provider.
 type DNSRecordSpec struct {
-	Zone       string `json:"zone"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="zone is immutable"
+	Zone string `json:"zone"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="recordType is immutable"
 	RecordType string `json:"recordType"`
-	Name       string `json:"name"`
-	Target     string `json:"target"`
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="name is immutable"
+	Name   string `json:"name"`
+	Target string `json:"target"`
+
+	// Name of the DNSProvider that manages this record. When it isn't set, the record is managed by the
+	// DNSProvider that serves its zone, or by the provider configured on the DNS controller.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="providerRef is immutable"
+	// +optional
+	ProviderRef string `json:"providerRef,omitempty"`
 
 	// Provider specific configuration settings that can be used
 	// to configure a DNS Record in accordance to the provider used.
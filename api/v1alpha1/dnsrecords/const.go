@@ -13,4 +13,8 @@ const (
 
 const (
 	ProviderCondition conditions.ConditionType = "Provider"
+
+	// Set when the DNS controller verifies that the record answers with its target. The condition
+	// is absent when propagation checks are disabled.
+	PropagatedCondition conditions.ConditionType = "Propagated"
 )
@@ -1,6 +1,9 @@
 package dnsrecords
 
-import "github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+import (
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 // Defines the observed state of a DNSRecord
 // +kubebuilder:object:generate=true
@@ -8,8 +11,19 @@ type Status struct {
 	// Conditions representing the current state of the DNSRecord
 	Conditions []conditions.Condition `json:"conditions,omitempty"`
 
-	// Name of the provider that was used to create this record.
+	// Name of the DNSProvider that was used to create this record, or `default` when the record
+	// was created by the provider configured on the DNS controller. The record is updated and deleted with the same provider.
 	Provider string `json:"provider,omitempty"`
 	// RemoteID is the ID, if available for the record that was created
 	RemoteID *string `json:"remoteID,omitempty"`
+
+	// ID of a change the provider accepted but hasn't applied yet. The DNS controller polls the
+	// provider until the change is applied.
+	PendingChange *string `json:"pendingChange,omitempty"`
+
+	// Generation of the spec that was last applied to the provider.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Last time the record was verified to match the spec upstream.
+	LastVerifiedAt *meta.Time `json:"lastVerifiedAt,omitempty"`
 }
@@ -23,6 +23,15 @@ func (in *Status) DeepCopyInto(out *Status) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.PendingChange != nil {
+		in, out := &in.PendingChange, &out.PendingChange
+		*out = new(string)
+		**out = **in
+	}
+	if in.LastVerifiedAt != nil {
+		in, out := &in.LastVerifiedAt, &out.LastVerifiedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Status.
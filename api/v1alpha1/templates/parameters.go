@@ -0,0 +1,98 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Name of the resolver used to reference a parameter in a template, ie. `${parameters::gitRef}`.
+const ParametersResolver string = "parameters"
+
+var ParameterParser = regexp.MustCompile(`\$\{parameters::([a-zA-Z][a-zA-Z0-9_-]*)}`)
+
+// Parameter is a value that a Workspace needs to provide when it uses a template. The
+// parameter can be referenced anywhere in the template's components and networking
+// with `${parameters::name}`.
+//
+// +kubebuilder:object:generate=true
+type Parameter struct {
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z][a-zA-Z0-9_-]*$`
+	Name string `json:"name"`
+
+	// Description of the parameter, only used as documentation for the users of the template.
+	// +optional
+	Description string `json:"description,omitempty"`
+
+	// Default is the value used when the workspace doesn't provide one. A parameter
+	// without a default value is required.
+	// +optional
+	Default *string `json:"default,omitempty"`
+}
+
+// Values returns the value of each of the parameters declared. The value given by the user
+// takes precedence over the default value of the parameter. Returns an error if a parameter has no value, or if
+// a value is given for a parameter that isn't declared.
+func Values(parameters []Parameter, values map[string]string) (map[string]string, error) {
+	resolved := map[string]string{}
+	var missing []string
+
+	for _, p := range parameters {
+		if value, ok := values[p.Name]; ok {
+			resolved[p.Name] = value
+			continue
+		}
+
+		if p.Default != nil {
+			resolved[p.Name] = *p.Default
+			continue
+		}
+
+		missing = append(missing, p.Name)
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("E#3017: Missing values for the parameters: %s", strings.Join(missing, ", "))
+	}
+
+	var unknown []string
+	for name := range values {
+		if _, ok := resolved[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("E#3017: Parameters are not declared by the template: %s", strings.Join(unknown, ", "))
+	}
+
+	return resolved, nil
+}
+
+// Render replaces each of the parameters referenced in the JSON document with their value. The values are
+// escaped so the document stays valid JSON.
+func Render(data []byte, values map[string]string) ([]byte, error) {
+	var err error
+
+	rendered := ParameterParser.ReplaceAllFunc(data, func(match []byte) []byte {
+		name := string(ParameterParser.FindSubmatch(match)[1])
+		value, ok := values[name]
+		if !ok {
+			err = fmt.Errorf("E#3017: Parameter (%s) is referenced but not declared", name)
+			return match
+		}
+
+		// Marshalling the value as a JSON string escapes it, only the surrounding quotes need to be removed.
+		escaped, _ := json.Marshal(value)
+		return escaped[1 : len(escaped)-1]
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rendered, nil
+}
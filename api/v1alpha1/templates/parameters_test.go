@@ -0,0 +1,57 @@
+package templates
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Parameters", func() {
+	defaultValue := "main"
+	parameters := []Parameter{
+		{Name: "ref", Default: &defaultValue},
+		{Name: "zone"},
+	}
+
+	Context("Values", func() {
+		It("uses the default value when none is given", func() {
+			values, err := Values(parameters, map[string]string{"zone": "example.com"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values).To(Equal(map[string]string{"ref": "main", "zone": "example.com"}))
+		})
+
+		It("uses the value given over the default value", func() {
+			values, err := Values(parameters, map[string]string{"ref": "feature", "zone": "example.com"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(values["ref"]).To(Equal("feature"))
+		})
+
+		It("returns an error when a required parameter is missing", func() {
+			_, err := Values(parameters, map[string]string{})
+			Expect(err).To(MatchError(ContainSubstring("zone")))
+		})
+
+		It("returns an error when a parameter is not declared", func() {
+			_, err := Values(parameters, map[string]string{"zone": "example.com", "branch": "main"})
+			Expect(err).To(MatchError(ContainSubstring("branch")))
+		})
+	})
+
+	Context("Render", func() {
+		It("replaces each of the parameters", func() {
+			data, err := Render([]byte(`{"image":"app:${parameters::ref}","zone":"${parameters::zone}"}`), map[string]string{"ref": "v1", "zone": "example.com"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"image":"app:v1","zone":"example.com"}`))
+		})
+
+		It("escapes the values", func() {
+			data, err := Render([]byte(`{"value":"${parameters::ref}"}`), map[string]string{"ref": `a"b`})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`{"value":"a\"b"}`))
+		})
+
+		It("returns an error when a parameter is referenced but not declared", func() {
+			_, err := Render([]byte(`{"value":"${parameters::ref}"}`), map[string]string{})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -1,6 +1,10 @@
 package tunneling
 
-import "github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+import (
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 type CloudflareConnector string
 
@@ -9,17 +13,105 @@ var (
 )
 
 // +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="has(self.route) || (has(self.routes) && size(self.routes) > 0)",message="the tunnel needs at least one route"
 type CloudflareTunnelSpec struct {
 	SecretKeyRef utils.SecretKeyRef  `json:"secretKeyRef"`
 	Connector    CloudflareConnector `json:"connector"`
-	Route        CloudflareRouteSpec `json:"route"`
-	AccountID    string              `json:"accountId"`
+
+	// Single route of the tunnel, prefer Routes. When both are set, this route comes first.
+	// +optional
+	Route CloudflareRouteSpec `json:"route,omitempty"`
+
+	// Routes of the tunnel, in the order they're matched. Routes with a path are matched
+	// before the routes without one for the same host.
+	// +optional
+	Routes []CloudflareRouteSpec `json:"routes,omitempty"`
+
+	AccountID string `json:"accountId"`
+
+	// Deployment running the connector of the tunnel.
+	// +optional
+	Cloudflared CloudflaredSpec `json:"cloudflared,omitempty"`
+
+	// Protects the hosts of the tunnel with Cloudflare Access, only the requests
+	// allowed by the policy reach the workspace.
+	// +optional
+	Access *CloudflareAccessSpec `json:"access,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+// +kubebuilder:validation:XValidation:rule="(has(self.emailDomains) && size(self.emailDomains) > 0) || (has(self.groups) && size(self.groups) > 0) || (has(self.serviceTokens) && size(self.serviceTokens) > 0)",message="the access policy needs to allow at least one email domain, group or service token"
+type CloudflareAccessSpec struct {
+	// Email domains of the users allowed through, eg. `example.com`.
+	// +optional
+	EmailDomains []string `json:"emailDomains,omitempty"`
+
+	// IDs of the Access groups allowed through.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+
+	// IDs of the service tokens allowed through, for automated clients.
+	// +optional
+	ServiceTokens []string `json:"serviceTokens,omitempty"`
+
+	// How long a user stays authenticated, eg. `24h`. Defaults to the session duration of the account.
+	// +optional
+	SessionDuration *meta.Duration `json:"sessionDuration,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type CloudflaredSpec struct {
+	// Number of connectors, Cloudflare balances the traffic between them. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Image of cloudflared. Defaults to `cloudflare/cloudflared:latest`.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// +optional
+	Resources core.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// Returns every route of the tunnel.
+func (c *CloudflareTunnelSpec) AllRoutes() []CloudflareRouteSpec {
+	var routes []CloudflareRouteSpec
+	if c.Route.ComponentName != "" || c.Route.NetworkName != "" {
+		routes = append(routes, c.Route)
+	}
+
+	return append(routes, c.Routes...)
 }
 
 // +kubebuilder:object:generate=true
 type CloudflareRouteSpec struct {
+	// Subdomain of the workspace's host the route answers to, eg. `api` for `api.<host>`. The
+	// route answers to the host itself when empty.
+	// +optional
+	Subdomain string `json:"subdomain,omitempty"`
+
 	Path          string `json:"path,omitempty"`
 	Protocol      string `json:"protocol,omitempty"`
 	ComponentName string `json:"component"`
 	NetworkName   string `json:"network"`
+
+	// +optional
+	OriginRequest *CloudflareOriginRequestSpec `json:"originRequest,omitempty"`
+}
+
+// Options used by cloudflared when it sends requests to the service.
+// +kubebuilder:object:generate=true
+type CloudflareOriginRequestSpec struct {
+	// Accept any certificate from a service that uses the https protocol.
+	// +optional
+	NoTLSVerify *bool `json:"noTLSVerify,omitempty"`
+
+	// Host header sent to the service.
+	// +optional
+	HTTPHostHeader string `json:"httpHostHeader,omitempty"`
+
+	// Timeout to establish a connection with the service, eg. `10s`.
+	// +optional
+	ConnectTimeout *meta.Duration `json:"connectTimeout,omitempty"`
 }
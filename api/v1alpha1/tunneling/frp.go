@@ -0,0 +1,31 @@
+package tunneling
+
+import "github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+
+// FrpTunnelSpec connects the workspace to a self-hosted frp server (frps). The server needs to
+// have `vhostHTTPPort` configured, the host of the workspace is routed through it.
+// +kubebuilder:object:generate=true
+type FrpTunnelSpec struct {
+	// Address the agent uses to connect to the server.
+	ServerAddress string `json:"serverAddress"`
+
+	// Port the server listens to for agents. Defaults to 7000.
+	// +kubebuilder:default=7000
+	// +optional
+	ServerPort int32 `json:"serverPort,omitempty"`
+
+	// Address, IP or hostname, where clients reach the server's HTTP virtual hosts. A DNS record for the
+	// host of the workspace points to it. Defaults to the ServerAddress.
+	// +optional
+	PublicAddress string `json:"publicAddress,omitempty"`
+
+	// Secret holding the token used to authenticate with the server, if the server requires one.
+	// +optional
+	SecretKeyRef *utils.SecretKeyRef `json:"secretKeyRef,omitempty"`
+
+	Route RouteSpec `json:"route"`
+
+	// Image of the agent. Defaults to `fatedier/frpc:v0.61.0`.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
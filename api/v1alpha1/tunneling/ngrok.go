@@ -0,0 +1,21 @@
+package tunneling
+
+import "github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+
+// NgrokTunnelSpec runs an ngrok agent in the workspace's namespace. The host of the workspace,
+// `<workspace>.<zone>`, needs to be covered by a domain reserved on ngrok, eg. `*.previews.example.com`.
+// +kubebuilder:object:generate=true
+type NgrokTunnelSpec struct {
+	// Secret holding the authtoken of the ngrok agent.
+	SecretKeyRef utils.SecretKeyRef `json:"secretKeyRef"`
+	Route        RouteSpec          `json:"route"`
+
+	// Target of the CNAME record created for the host of the workspace, as given by ngrok when the domain
+	// was reserved. No record is created when it's empty, eg. when a wildcard record already exists.
+	// +optional
+	CNAMETarget string `json:"cnameTarget,omitempty"`
+
+	// Image of the agent. Defaults to `ngrok/ngrok:3`.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
@@ -0,0 +1,13 @@
+package tunneling
+
+// RouteSpec connects a tunnel to the network of one of the workspace's components.
+// +kubebuilder:object:generate=true
+type RouteSpec struct {
+	ComponentName string `json:"component"`
+	NetworkName   string `json:"network"`
+
+	// Protocol used by the agent to reach the service, `http` or `https`. Defaults to `http`.
+	// +kubebuilder:validation:Enum=http;https
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+}
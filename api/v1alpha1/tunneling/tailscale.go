@@ -0,0 +1,17 @@
+package tunneling
+
+import "github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+
+// TailscaleTunnelSpec exposes the workspace with Tailscale Funnel. The agent joins the tailnet as a node named
+// after the workspace, so the DNS zone of the workspace needs to be the tailnet's domain, eg. `tail1234.ts.net`.
+// +kubebuilder:object:generate=true
+type TailscaleTunnelSpec struct {
+	// Secret holding an auth key for the tailnet. The key should be ephemeral so the node is removed
+	// from the tailnet when the workspace is deleted.
+	SecretKeyRef utils.SecretKeyRef `json:"secretKeyRef"`
+	Route        RouteSpec          `json:"route"`
+
+	// Image of the agent. Defaults to `tailscale/tailscale:stable`.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
@@ -4,11 +4,79 @@
 
 package tunneling
 
-import ()
+import (
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudflareAccessSpec) DeepCopyInto(out *CloudflareAccessSpec) {
+	*out = *in
+	if in.EmailDomains != nil {
+		in, out := &in.EmailDomains, &out.EmailDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceTokens != nil {
+		in, out := &in.ServiceTokens, &out.ServiceTokens
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SessionDuration != nil {
+		in, out := &in.SessionDuration, &out.SessionDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflareAccessSpec.
+func (in *CloudflareAccessSpec) DeepCopy() *CloudflareAccessSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudflareAccessSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudflareOriginRequestSpec) DeepCopyInto(out *CloudflareOriginRequestSpec) {
+	*out = *in
+	if in.NoTLSVerify != nil {
+		in, out := &in.NoTLSVerify, &out.NoTLSVerify
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ConnectTimeout != nil {
+		in, out := &in.ConnectTimeout, &out.ConnectTimeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflareOriginRequestSpec.
+func (in *CloudflareOriginRequestSpec) DeepCopy() *CloudflareOriginRequestSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudflareOriginRequestSpec)
+	in.DeepCopyInto(out)
+	return out
+}
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloudflareRouteSpec) DeepCopyInto(out *CloudflareRouteSpec) {
 	*out = *in
+	if in.OriginRequest != nil {
+		in, out := &in.OriginRequest, &out.OriginRequest
+		*out = new(CloudflareOriginRequestSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflareRouteSpec.
@@ -25,7 +93,20 @@ func (in *CloudflareRouteSpec) DeepCopy() *CloudflareRouteSpec {
 func (in *CloudflareTunnelSpec) DeepCopyInto(out *CloudflareTunnelSpec) {
 	*out = *in
 	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
-	out.Route = in.Route
+	in.Route.DeepCopyInto(&out.Route)
+	if in.Routes != nil {
+		in, out := &in.Routes, &out.Routes
+		*out = make([]CloudflareRouteSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Cloudflared.DeepCopyInto(&out.Cloudflared)
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(CloudflareAccessSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflareTunnelSpec.
@@ -37,3 +118,94 @@ func (in *CloudflareTunnelSpec) DeepCopy() *CloudflareTunnelSpec {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloudflaredSpec) DeepCopyInto(out *CloudflaredSpec) {
+	*out = *in
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloudflaredSpec.
+func (in *CloudflaredSpec) DeepCopy() *CloudflaredSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CloudflaredSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FrpTunnelSpec) DeepCopyInto(out *FrpTunnelSpec) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(utils.SecretKeyRef)
+		(*in).DeepCopyInto(*out)
+	}
+	out.Route = in.Route
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FrpTunnelSpec.
+func (in *FrpTunnelSpec) DeepCopy() *FrpTunnelSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(FrpTunnelSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NgrokTunnelSpec) DeepCopyInto(out *NgrokTunnelSpec) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+	out.Route = in.Route
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NgrokTunnelSpec.
+func (in *NgrokTunnelSpec) DeepCopy() *NgrokTunnelSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NgrokTunnelSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteSpec) DeepCopyInto(out *RouteSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteSpec.
+func (in *RouteSpec) DeepCopy() *RouteSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TailscaleTunnelSpec) DeepCopyInto(out *TailscaleTunnelSpec) {
+	*out = *in
+	in.SecretKeyRef.DeepCopyInto(&out.SecretKeyRef)
+	out.Route = in.Route
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TailscaleTunnelSpec.
+func (in *TailscaleTunnelSpec) DeepCopy() *TailscaleTunnelSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TailscaleTunnelSpec)
+	in.DeepCopyInto(out)
+	return out
+}
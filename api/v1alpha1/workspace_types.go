@@ -23,14 +23,34 @@ import (
 
 // WorkspaceSpec defines the desired state of Workspace
 type WorkspaceSpec struct {
-	Components []ComponentSpec           `json:"components"`
-	Networking workspaces.NetworkingSpec `json:"networking"`
+	// Template used to render the components and networking of this workspace. When set,
+	// the components and networking are generated by Sequencer and don't need to be specified.
+	// +optional
+	Template *workspaces.TemplateSpec `json:"template,omitempty"`
+
+	// +optional
+	Components []ComponentSpec `json:"components,omitempty"`
+
+	// +optional
+	Networking workspaces.NetworkingSpec `json:"networking,omitempty"`
+
+	// Expiry configures when the workspace is automatically deleted. Workspaces
+	// without an expiry live until they are deleted.
+	// +optional
+	Expiry *workspaces.ExpirySpec `json:"expiry,omitempty"`
+
+	// Sleep puts the workspace to sleep when set to true. The pods of every component are
+	// removed while the services, DNS records, ingress and tunnel stay in place. Setting it back
+	// to false wakes the workspace up and recreates the pods.
+	// +optional
+	Sleep bool `json:"sleep,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
 // +kubebuilder:printcolumn:name="Hostname",type=string,JSONPath=`.status.host`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expiresAt`
 type Workspace struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
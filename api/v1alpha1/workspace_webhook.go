@@ -0,0 +1,293 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// SetupWebhookWithManager will setup the manager to manage the webhooks
+func (w *Workspace) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(w).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-se-quencer-io-v1alpha1-workspace,mutating=true,failurePolicy=fail,sideEffects=None,groups=se.quencer.io,resources=workspaces,verbs=create;update,versions=v1alpha1,name=mworkspace.se.quencer.io,admissionReviewVersions=v1
+var _ webhook.Defaulter = &Workspace{}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-se-quencer-io-v1alpha1-workspace,mutating=false,failurePolicy=fail,groups=se.quencer.io,resources=workspaces,versions=v1alpha1,name=vworkspace.se.quencer.io,sideEffects=None,admissionReviewVersions=v1
+var _ webhook.Validator = &Workspace{}
+
+func (w *Workspace) Default() {
+	for i := range w.Spec.Components {
+		w.Spec.Components[i].Default()
+	}
+}
+
+func (w *Workspace) ValidateCreate() (admission.Warnings, error) {
+	return nil, w.validate()
+}
+
+func (w *Workspace) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+	// Only validate the spec when it changes so that workspaces created before the
+	// validation existed can still be updated, ie. to remove a finalizer.
+	if previous, ok := old.(*Workspace); ok && equality.Semantic.DeepEqual(previous.Spec, w.Spec) {
+		return nil, nil
+	}
+
+	return nil, w.validate()
+}
+
+func (w *Workspace) ValidateDelete() (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (w *Workspace) validate() error {
+	var errors field.ErrorList
+	path := field.NewPath("spec")
+
+	// The components and networking of a workspace that uses a template are only available
+	// once the template is rendered, they are validated at that point.
+	if w.Spec.Template == nil || len(w.Spec.Components) > 0 {
+		errors = append(errors, validateComponentNames(w.Spec.Components, path.Child("components"))...)
+		for i := range w.Spec.Components {
+			errors = append(errors, w.Spec.Components[i].Validate(path.Child("components").Index(i))...)
+		}
+
+		errors = append(errors, ValidateDependencies(w.Spec.Components, path.Child("components"))...)
+		errors = append(errors, ValidateNetworking(&w.Spec.Networking, w.Spec.Components, path.Child("networking"))...)
+	}
+
+	if len(errors) > 0 {
+		return apierrors.NewInvalid(
+			schema.GroupKind{Group: "se.quencer.io", Kind: "Workspace"},
+			w.Name, errors)
+	}
+	return nil
+}
+
+func validateComponentNames(specs []ComponentSpec, path *field.Path) field.ErrorList {
+	var errors field.ErrorList
+
+	names := map[string]bool{}
+	for i, spec := range specs {
+		if names[spec.Name] {
+			errors = append(errors, field.Invalid(path.Index(i).Child("name"), spec.Name,
+				fmt.Sprintf("E#3022: Component names need to be unique within a workspace, (%s) is used more than once", spec.Name)))
+		}
+		names[spec.Name] = true
+	}
+
+	return errors
+}
+
+// ValidateNetworking makes sure exactly one networking provider is configured and that the
+// rules and routes of the provider point to networks that exist in the components.
+func ValidateNetworking(networking *workspaces.NetworkingSpec, specs []ComponentSpec, path *field.Path) field.ErrorList {
+	var errors field.ErrorList
+
+	var providers []string
+	if networking.Ingress != nil {
+		providers = append(providers, "ingress")
+	}
+
+	if networking.Gateway != nil {
+		providers = append(providers, "gateway")
+	}
+
+	if networking.Tunnel != nil {
+		providers = append(providers, "tunnel")
+		if len(networking.Tunnel.Providers()) != 1 {
+			errors = append(errors, field.Invalid(path.Child("tunnel"), strings.Join(networking.Tunnel.Providers(), ", "),
+				"E#3012: The Tunnel Spec needs to include exactly one valid provider (cloudflare, ngrok, tailscale, frp)"))
+		}
+	}
+
+	if len(providers) != 1 {
+		errors = append(errors, field.Invalid(path, strings.Join(providers, ", "),
+			"E#3023: Exactly one networking provider needs to be configured (ingress, gateway, tunnel)"))
+	}
+
+	networkExists := func(componentName, networkName string) bool {
+		for _, spec := range specs {
+			if spec.Name != componentName {
+				continue
+			}
+
+			for _, network := range spec.Networks {
+				if network.Name == networkName {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	if networking.Ingress != nil {
+		for i, rule := range networking.Ingress.Rules {
+			if !networkExists(rule.ComponentName, rule.NetworkName) {
+				errors = append(errors, field.Invalid(path.Child("ingress", "rules").Index(i), fmt.Sprintf("%s.%s", rule.ComponentName, rule.NetworkName),
+					fmt.Sprintf("E#3024: Rule (%s) references a network that doesn't exist", rule.Name)))
+			}
+		}
+	}
+
+	if networking.Gateway != nil {
+		for i, rule := range networking.Gateway.Rules {
+			if !networkExists(rule.ComponentName, rule.NetworkName) {
+				errors = append(errors, field.Invalid(path.Child("gateway", "rules").Index(i), fmt.Sprintf("%s.%s", rule.ComponentName, rule.NetworkName),
+					fmt.Sprintf("E#3024: Rule (%s) references a network that doesn't exist", rule.Name)))
+			}
+		}
+	}
+
+	if networking.Tunnel != nil {
+		type tunnelRoute struct {
+			path  *field.Path
+			route tunneling.RouteSpec
+		}
+
+		var routes []tunnelRoute
+		if cloudflare := networking.Tunnel.Cloudflare; cloudflare != nil {
+			if len(cloudflare.AllRoutes()) == 0 {
+				errors = append(errors, field.Required(path.Child("tunnel", "cloudflare", "routes"), "E#3028: The tunnel needs at least one route"))
+			}
+
+			if cloudflare.Route.ComponentName != "" || cloudflare.Route.NetworkName != "" {
+				routes = append(routes, tunnelRoute{path.Child("tunnel", "cloudflare", "route"), tunneling.RouteSpec{ComponentName: cloudflare.Route.ComponentName, NetworkName: cloudflare.Route.NetworkName}})
+			}
+
+			for i, route := range cloudflare.Routes {
+				routes = append(routes, tunnelRoute{path.Child("tunnel", "cloudflare", "routes").Index(i), tunneling.RouteSpec{ComponentName: route.ComponentName, NetworkName: route.NetworkName}})
+			}
+		}
+		if networking.Tunnel.Ngrok != nil {
+			routes = append(routes, tunnelRoute{path.Child("tunnel", "ngrok", "route"), networking.Tunnel.Ngrok.Route})
+		}
+		if networking.Tunnel.Tailscale != nil {
+			routes = append(routes, tunnelRoute{path.Child("tunnel", "tailscale", "route"), networking.Tunnel.Tailscale.Route})
+		}
+		if networking.Tunnel.Frp != nil {
+			routes = append(routes, tunnelRoute{path.Child("tunnel", "frp", "route"), networking.Tunnel.Frp.Route})
+		}
+
+		for _, r := range routes {
+			if !networkExists(r.route.ComponentName, r.route.NetworkName) {
+				errors = append(errors, field.Invalid(r.path, fmt.Sprintf("%s.%s", r.route.ComponentName, r.route.NetworkName),
+					"E#3024: Tunnel route references a network that doesn't exist"))
+			}
+		}
+	}
+
+	return errors
+}
+
+// ValidateDependencies makes sure the DependsOn of each component can be met. A dependency needs
+// to reference a component that exists, use a condition the component will reach, and components can't depend on each
+// other in a cycle. Each of these would otherwise have the component wait forever.
+func ValidateDependencies(specs []ComponentSpec, path *field.Path) field.ErrorList {
+	var errors field.ErrorList
+
+	names := map[string]bool{}
+	for _, spec := range specs {
+		names[spec.Name] = true
+	}
+
+	for i, spec := range specs {
+		for j, dep := range spec.DependsOn {
+			depPath := path.Index(i).Child("dependsOn").Index(j)
+
+			if !names[dep.ComponentName] {
+				errors = append(errors, field.Invalid(depPath.Child("componentName"), dep.ComponentName,
+					fmt.Sprintf("E#3019: Component (%s) depends on a component that doesn't exist (%s)", spec.Name, dep.ComponentName)))
+			}
+
+			statuses, ok := components.DependableConditions[dep.ConditionType]
+			if !ok || !slices.Contains(statuses, dep.ConditionStatus) {
+				errors = append(errors, field.Invalid(depPath, fmt.Sprintf("%s:%s", dep.ConditionType, dep.ConditionStatus),
+					fmt.Sprintf("E#3020: Component (%s) depends on a condition that is never reached, supported conditions are: %s", spec.Name, dependableConditions())))
+			}
+		}
+	}
+
+	for _, cycle := range dependencyCycles(specs) {
+		errors = append(errors, field.Invalid(path, strings.Join(cycle, " -> "),
+			fmt.Sprintf("E#3021: Components depend on each other in a cycle (%s)", strings.Join(cycle, " -> "))))
+	}
+
+	return errors
+}
+
+// Returns each of the cycles found in the dependency graph of the components. A cycle is
+// represented by the names of the components in it, with the first one repeated at the end.
+func dependencyCycles(specs []ComponentSpec) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	graph := map[string][]string{}
+	for _, spec := range specs {
+		for _, dep := range spec.DependsOn {
+			graph[spec.Name] = append(graph[spec.Name], dep.ComponentName)
+		}
+	}
+
+	var cycles [][]string
+	state := map[string]int{}
+	var stack []string
+
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		stack = append(stack, name)
+
+		for _, next := range graph[name] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				start := slices.Index(stack, next)
+				cycle := append(slices.Clone(stack[start:]), next)
+				cycles = append(cycles, cycle)
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[name] = visited
+	}
+
+	for _, spec := range specs {
+		if state[spec.Name] == unvisited {
+			visit(spec.Name)
+		}
+	}
+
+	return cycles
+}
+
+func dependableConditions() string {
+	var pairs []string
+	for t, statuses := range components.DependableConditions {
+		for _, s := range statuses {
+			pairs = append(pairs, fmt.Sprintf("%s:%s", t, s))
+		}
+	}
+
+	slices.Sort(pairs)
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,267 @@
+package v1alpha1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/builds"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Workspace Webhook", func() {
+	dependsOn := func(name string) Dependency {
+		return Dependency{
+			ComponentName:   name,
+			ConditionType:   components.PodCondition,
+			ConditionStatus: conditions.ConditionHealthy,
+		}
+	}
+
+	workspace := func(specs ...ComponentSpec) *Workspace {
+		return &Workspace{Spec: WorkspaceSpec{
+			Components: specs,
+			Networking: workspaces.NetworkingSpec{
+				Tunnel: &workspaces.TunnelSpec{
+					Cloudflare: &tunneling.CloudflareTunnelSpec{
+						Route: tunneling.CloudflareRouteSpec{ComponentName: "app", NetworkName: "http"},
+					},
+				},
+			},
+		}}
+	}
+
+	http := []NetworkSpec{{ServicePort: core.ServicePort{Name: "http", Port: 80}}}
+
+	Context("When creating Workspace under Validating Webhook", func() {
+		It("Should admit components with valid dependencies", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "mysql"},
+				ComponentSpec{Name: "app", Networks: http, DependsOn: []Dependency{dependsOn("mysql")}},
+			).ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny a dependency on a component that doesn't exist", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", DependsOn: []Dependency{dependsOn("mysql")}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3019")))
+		})
+
+		It("Should admit a dependency on the jobs of a component that has none", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "mysql"},
+				ComponentSpec{Name: "app", Networks: http, DependsOn: []Dependency{{
+					ComponentName:   "mysql",
+					ConditionType:   components.JobsCondition,
+					ConditionStatus: conditions.ConditionCompleted,
+				}}},
+			).ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny a dependency on a condition that is never reached", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "mysql"},
+				ComponentSpec{Name: "app", DependsOn: []Dependency{{
+					ComponentName:   "mysql",
+					ConditionType:   components.PodCondition,
+					ConditionStatus: conditions.ConditionCompleted,
+				}}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3020")))
+		})
+
+		It("Should deny components that depend on each other", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "a", DependsOn: []Dependency{dependsOn("b")}},
+				ComponentSpec{Name: "b", DependsOn: []Dependency{dependsOn("c")}},
+				ComponentSpec{Name: "c", DependsOn: []Dependency{dependsOn("a")}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3021")))
+			Expect(err).To(MatchError(ContainSubstring("a -> b -> c -> a")))
+		})
+
+		It("Should deny a component that depends on itself", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "a", DependsOn: []Dependency{dependsOn("a")}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3021")))
+		})
+	})
+
+	Context("When creating Workspace with invalid components", func() {
+		It("Should deny components that share a name", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", Networks: http},
+				ComponentSpec{Name: "app"},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3022")))
+		})
+
+		It("Should deny variables that can't be parsed", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", Networks: http, Pod: core.PodSpec{
+					Containers: []core.Container{{
+						Name:  "app",
+						Image: "nginx",
+						Env:   []core.EnvVar{{Name: "DB_HOST", Value: "${components::mysql.tcp}"}},
+					}},
+				}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#2012")))
+		})
+
+		It("Should point at the field holding a variable that can't be parsed", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", Networks: http, Pod: core.PodSpec{
+					Containers: []core.Container{{
+						Name:  "app",
+						Image: "nginx",
+						Args:  []string{"--db", "mysql://${unknown::mysql}:3306"},
+					}},
+				}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.components[0].template.containers[0].args[1]")))
+			Expect(err).To(MatchError(ContainSubstring("E#2007")))
+		})
+
+		It("Should deny malformed variables", func() {
+			for _, arg := range []string{"${components::mysql.networks.tcp", "${components::mysql networks}"} {
+				_, err := workspace(
+					ComponentSpec{Name: "app", Networks: http, Pod: core.PodSpec{
+						Containers: []core.Container{{Name: "app", Image: "nginx", Args: []string{"--db", arg}}},
+					}},
+				).ValidateCreate()
+				Expect(err).To(MatchError(ContainSubstring("spec.components[0].template.containers[0].args[1]")), arg)
+				Expect(err).To(MatchError(ContainSubstring("E#2006")), arg)
+			}
+		})
+
+		It("Should deny a secret used as an image", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", Networks: http, Pod: core.PodSpec{
+					Containers: []core.Container{{Name: "app", Image: "${secrets::registry.image}"}},
+				}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#2020")))
+		})
+
+		It("Should deny a build with an absolute dockerfile", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", Networks: http, Build: &BuildSpec{
+					Name:                "app",
+					Dockerfile:          "/Dockerfile",
+					ContainerRegistries: []builds.ContainerRegistry{{URL: "example.com/app"}},
+				}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#1017")))
+		})
+
+		It("Should deny a build with an invalid git url", func() {
+			_, err := workspace(
+				ComponentSpec{Name: "app", Networks: http, Build: &BuildSpec{
+					Name:                "app",
+					ContainerRegistries: []builds.ContainerRegistry{{URL: "example.com/app"}},
+					ImportContent: []builds.ImportContent{{
+						ContentFrom: builds.ImportSource{Git: &builds.GitSource{URL: "ftp://github.com/app.git", Ref: "main"}},
+					}},
+				}},
+			).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.components[0].build.importContent[0].contentFrom.git.url")))
+			Expect(err).To(MatchError(ContainSubstring("E#1018")))
+		})
+	})
+
+	Context("When creating Workspace with invalid networking", func() {
+		It("Should deny a route to a network that doesn't exist", func() {
+			_, err := workspace(ComponentSpec{Name: "app"}).ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3024")))
+		})
+
+		It("Should deny a gateway rule to a network that doesn't exist", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Tunnel = nil
+			ws.Spec.Networking.Gateway = &workspaces.GatewaySpec{
+				Rules: []workspaces.RuleSpec{{Name: "web", ComponentName: "app", NetworkName: "grpc"}},
+			}
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.networking.gateway.rules[0]")))
+			Expect(err).To(MatchError(ContainSubstring("E#3024")))
+		})
+
+		It("Should deny a workspace with more than one networking provider", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Gateway = &workspaces.GatewaySpec{
+				Rules: []workspaces.RuleSpec{{Name: "web", ComponentName: "app", NetworkName: "http"}},
+			}
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3023")))
+		})
+
+		It("Should deny a workspace without a networking provider", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Tunnel = nil
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3023")))
+		})
+
+		It("Should deny a Cloudflare route to a network that doesn't exist", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Tunnel.Cloudflare.Routes = []tunneling.CloudflareRouteSpec{
+				{Subdomain: "api", ComponentName: "app", NetworkName: "grpc"},
+			}
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.networking.tunnel.cloudflare.routes[0]")))
+			Expect(err).To(MatchError(ContainSubstring("E#3024")))
+		})
+
+		It("Should deny a Cloudflare tunnel without routes", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Tunnel.Cloudflare.Route = tunneling.CloudflareRouteSpec{}
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3028")))
+		})
+
+		It("Should deny a tunnel with more than one provider", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Tunnel.Ngrok = &tunneling.NgrokTunnelSpec{
+				Route: tunneling.RouteSpec{ComponentName: "app", NetworkName: "http"},
+			}
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("E#3012")))
+		})
+
+		It("Should deny an agent tunnel route to a network that doesn't exist", func() {
+			ws := workspace(ComponentSpec{Name: "app", Networks: http})
+			ws.Spec.Networking.Tunnel = &workspaces.TunnelSpec{
+				Frp: &tunneling.FrpTunnelSpec{
+					ServerAddress: "frps.example.com",
+					Route:         tunneling.RouteSpec{ComponentName: "app", NetworkName: "grpc"},
+				},
+			}
+			_, err := ws.ValidateCreate()
+			Expect(err).To(MatchError(ContainSubstring("spec.networking.tunnel.frp.route")))
+			Expect(err).To(MatchError(ContainSubstring("E#3024")))
+		})
+
+		It("Should admit a workspace that is rendered from a template", func() {
+			_, err := (&Workspace{Spec: WorkspaceSpec{
+				Template: &workspaces.TemplateSpec{Name: "preview"},
+			}}).ValidateCreate()
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Context("When updating Workspace under Validating Webhook", func() {
+		It("Should admit updates that don't change the components", func() {
+			invalid := workspace(ComponentSpec{Name: "app", DependsOn: []Dependency{dependsOn("mysql")}})
+			_, err := invalid.ValidateUpdate(invalid.DeepCopy())
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+})
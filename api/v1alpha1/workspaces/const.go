@@ -6,14 +6,23 @@ import (
 
 const (
 	InstanceLabel string = "workspaces.sequencer.io/instance"
-	IngressLabel  string = "workspaces.sequencer.io/ingress"
+
+	// Set on the HTTPRoutes created for a workspace, the value is the name of the rule.
+	RuleLabel string = "workspaces.sequencer.io/rule"
+
+	// Set on the Ingress of a workspace for each of its rules, the name of the rule follows the
+	// prefix and the value is the public URL of the rule.
+	RuleAnnotationPrefix string = "rules.workspaces.sequencer.io/"
 )
 
 const (
 	DNSCondition       conditions.ConditionType = "DNS"
 	IngressCondition   conditions.ConditionType = "Ingress"
+	GatewayCondition   conditions.ConditionType = "Gateway"
 	TunnelingCondition conditions.ConditionType = "Tunneling"
 	ComponentCondition conditions.ConditionType = "Components"
+	ExpiryCondition    conditions.ConditionType = "Expiry"
+	TemplateCondition  conditions.ConditionType = "Template"
 )
 
 const (
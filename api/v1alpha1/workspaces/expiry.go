@@ -0,0 +1,74 @@
+package workspaces
+
+import (
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Annotation that can be set on a workspace to signal that it's still in use. The value
+// needs to be a RFC3339 timestamp, eg. `kubectl annotate workspace/my-workspace workspaces.sequencer.io/last-activity=2024-09-01T15:04:05Z --overwrite`.
+// It's only used when an idle timeout is configured.
+const LastActivityAnnotation string = "workspaces.sequencer.io/last-activity"
+
+// Default duration, before a workspace expires, where a warning is sent.
+const DefaultExpiryWarning = time.Hour
+
+// ExpirySpec describes when a workspace should be deleted. All the fields are optional and
+// when more than one is set, the workspace expires at the earliest of the times computed.
+//
+// +kubebuilder:object:generate=true
+type ExpirySpec struct {
+	// TTL is the time a workspace can live after it was created, eg. `72h`.
+	TTL *meta.Duration `json:"ttl,omitempty"`
+
+	// ExpiresAt is the absolute time at which the workspace needs to be deleted.
+	ExpiresAt *meta.Time `json:"expiresAt,omitempty"`
+
+	// IdleTimeout is the time a workspace can live without any activity. The last activity is read from
+	// the `workspaces.sequencer.io/last-activity` annotation and defaults to the time the workspace was created.
+	IdleTimeout *meta.Duration `json:"idleTimeout,omitempty"`
+
+	// WarnBefore is how long before the expiry a warning event is sent to the workspace.
+	// Defaults to 1 hour.
+	WarnBefore *meta.Duration `json:"warnBefore,omitempty"`
+}
+
+// ExpiryTime returns the earliest time at which the workspace expires, given
+// the time it was created and the time of its last activity. Returns nil if no
+// expiry is configured.
+func (e ExpirySpec) ExpiryTime(created, lastActivity time.Time) *time.Time {
+	var expiry *time.Time
+	earliest := func(t time.Time) {
+		if expiry == nil || t.Before(*expiry) {
+			expiry = &t
+		}
+	}
+
+	if e.ExpiresAt != nil {
+		earliest(e.ExpiresAt.Time)
+	}
+
+	if e.TTL != nil {
+		earliest(created.Add(e.TTL.Duration))
+	}
+
+	if e.IdleTimeout != nil {
+		if lastActivity.Before(created) {
+			lastActivity = created
+		}
+		earliest(lastActivity.Add(e.IdleTimeout.Duration))
+	}
+
+	return expiry
+}
+
+// Warning returns how long before the expiry the workspace should
+// receive a warning.
+func (e ExpirySpec) Warning() time.Duration {
+	if e.WarnBefore == nil {
+		return DefaultExpiryWarning
+	}
+
+	return e.WarnBefore.Duration
+}
@@ -0,0 +1,55 @@
+package workspaces
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("ExpirySpec", func() {
+	created := time.Date(2024, 9, 1, 12, 0, 0, 0, time.UTC)
+
+	Context("ExpiryTime", func() {
+		It("returns nil when nothing is configured", func() {
+			Expect(ExpirySpec{}.ExpiryTime(created, time.Time{})).To(BeNil())
+		})
+
+		It("adds the TTL to the creation time", func() {
+			spec := ExpirySpec{TTL: &meta.Duration{Duration: 48 * time.Hour}}
+
+			Expect(*spec.ExpiryTime(created, time.Time{})).To(Equal(created.Add(48 * time.Hour)))
+		})
+
+		It("uses the creation time when there was no activity", func() {
+			spec := ExpirySpec{IdleTimeout: &meta.Duration{Duration: time.Hour}}
+
+			Expect(*spec.ExpiryTime(created, time.Time{})).To(Equal(created.Add(time.Hour)))
+		})
+
+		It("pushes the idle expiry with the last activity", func() {
+			spec := ExpirySpec{IdleTimeout: &meta.Duration{Duration: time.Hour}}
+			activity := created.Add(5 * time.Hour)
+
+			Expect(*spec.ExpiryTime(created, activity)).To(Equal(activity.Add(time.Hour)))
+		})
+
+		It("returns the earliest of all the configured values", func() {
+			expiresAt := meta.NewTime(created.Add(10 * time.Hour))
+			spec := ExpirySpec{
+				TTL:         &meta.Duration{Duration: 48 * time.Hour},
+				ExpiresAt:   &expiresAt,
+				IdleTimeout: &meta.Duration{Duration: 24 * time.Hour},
+			}
+
+			Expect(*spec.ExpiryTime(created, time.Time{})).To(Equal(expiresAt.Time))
+		})
+	})
+
+	Context("Warning", func() {
+		It("defaults to an hour", func() {
+			Expect(ExpirySpec{}.Warning()).To(Equal(time.Hour))
+		})
+	})
+})
@@ -0,0 +1,23 @@
+package workspaces
+
+import "github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+
+// +kubebuilder:object:generate=true
+type GatewaySpec struct {
+	// Rules represent each endpoint that you want to make accessible through the Gateway. An HTTPRoute
+	// is created for each of the rules.
+	//+kubebuilder:validation:MinItems:1
+	Rules []RuleSpec `json:"rules"`
+
+	// Reference to the Gateway the routes are attached to. The Gateway is not managed by Sequencer,
+	// it needs to exist and have an address assigned. The addresses of the Gateway are used to
+	// generate the DNS records of the workspace.
+	//
+	// TLS is expected to be terminated by the Gateway, the listener should use a wildcard certificate for the
+	// DNS zone configured in the workspace.
+	GatewayRef utils.Reference `json:"gatewayRef"`
+
+	// Name of the listener, within the Gateway, the routes attach to. When omitted, the routes
+	// attach to every listener that allows them.
+	SectionName *string `json:"sectionName,omitempty"`
+}
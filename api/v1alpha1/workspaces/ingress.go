@@ -1,6 +1,10 @@
 package workspaces
 
-import "github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+import (
+	"fmt"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+)
 
 // +kubebuilder:object:generate=true
 type IngressSpec struct {
@@ -42,3 +46,28 @@ type RuleSpec struct {
 	ComponentName string  `json:"component"`
 	NetworkName   string  `json:"network"`
 }
+
+// Host returns the host of the rule for a workspace that uses the hostname provided.
+func (r RuleSpec) Host(hostname string) string {
+	if r.Subdomain != nil {
+		return fmt.Sprintf("%s.%s", *r.Subdomain, hostname)
+	}
+
+	return hostname
+}
+
+// Status returns the public endpoint of the rule for a workspace that uses the hostname provided. The ingress
+// always terminates TLS, so the URL uses https.
+func (r RuleSpec) Status(hostname string) RuleStatus {
+	host := r.Host(hostname)
+	url := fmt.Sprintf("https://%s", host)
+	if r.Path != nil && *r.Path != "/" {
+		url += *r.Path
+	}
+
+	return RuleStatus{
+		Name: r.Name,
+		Host: host,
+		URL:  url,
+	}
+}
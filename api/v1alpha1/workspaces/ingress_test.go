@@ -0,0 +1,31 @@
+package workspaces
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RuleSpec", func() {
+	hostname := "my-workspace.example.com"
+
+	It("uses the workspace's hostname", func() {
+		rule := RuleSpec{Name: "app"}
+
+		Expect(rule.Status(hostname)).To(Equal(RuleStatus{
+			Name: "app",
+			Host: "my-workspace.example.com",
+			URL:  "https://my-workspace.example.com",
+		}))
+	})
+
+	It("includes the subdomain and the path", func() {
+		subdomain, path := "api", "/v1"
+		rule := RuleSpec{Name: "api", Subdomain: &subdomain, Path: &path}
+
+		Expect(rule.Status(hostname)).To(Equal(RuleStatus{
+			Name: "api",
+			Host: "api.my-workspace.example.com",
+			URL:  "https://api.my-workspace.example.com/v1",
+		}))
+	})
+})
@@ -20,18 +20,45 @@ type NetworkingSpec struct {
 
 	// IngressSpec includes all the configuration to customize the networking section
 	// of a workspace to work with an ingress controller
-	//
-	// At the moment, this ingress spec is required for networking to work.
-	// It will become an optional field when the Gateway API is added to the NetworkingSpec
 	Ingress *IngressSpec `json:"ingress,omitempty"`
+
+	// GatewaySpec configures the workspace to use the Gateway API. Routes are attached to a Gateway
+	// that exists in the cluster instead of creating an Ingress.
+	Gateway *GatewaySpec `json:"gateway,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
 type TunnelSpec struct {
 	Cloudflare *tunneling.CloudflareTunnelSpec `json:"cloudflare,omitempty"`
+	Ngrok      *tunneling.NgrokTunnelSpec      `json:"ngrok,omitempty"`
+	Tailscale  *tunneling.TailscaleTunnelSpec  `json:"tailscale,omitempty"`
+	Frp        *tunneling.FrpTunnelSpec        `json:"frp,omitempty"`
+}
+
+// Returns the names of the providers that are configured. A valid spec has exactly one.
+func (t *TunnelSpec) Providers() []string {
+	var providers []string
+	if t.Cloudflare != nil {
+		providers = append(providers, "cloudflare")
+	}
+	if t.Ngrok != nil {
+		providers = append(providers, "ngrok")
+	}
+	if t.Tailscale != nil {
+		providers = append(providers, "tailscale")
+	}
+	if t.Frp != nil {
+		providers = append(providers, "frp")
+	}
+	return providers
 }
 
 // +kubebuilder:object:generate=true
 type DNSSpec struct {
 	Zone string `json:"zone"`
+
+	// Name of the DNSProvider used for the records of the workspace. By default, the records
+	// are sent to the DNSProvider that serves the zone.
+	// +optional
+	ProviderRef string `json:"providerRef,omitempty"`
 }
@@ -2,6 +2,7 @@ package workspaces
 
 import (
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // +kubebuilder:object:generate=true
@@ -14,6 +15,9 @@ type Status struct {
 	// Host is the root URL where the application will point to
 	Host string `json:"host,omitempty"`
 
+	// Rules lists the public endpoint of each of the ingress rules of the workspace.
+	Rules []RuleStatus `json:"rules,omitempty"`
+
 	// Any components or task that needs a DNS entry to work
 	// needs to set it up here. Sequencer will take those dns records here
 	// and create a DNSRecord that represent each of those entries.
@@ -21,6 +25,16 @@ type Status struct {
 	// as it decouples the creating of the DNS with the requests from each of the subtasks
 	// that represent a workspace.
 	DNS []DNS `json:"dns,omitempty"`
+
+	// ObservedGeneration is the .metadata.generation of the workspace's spec that
+	// the components were last synced with.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// ExpiresAt is the time at which the workspace will be deleted, computed from the expiry spec.
+	ExpiresAt *meta.Time `json:"expiresAt,omitempty"`
+
+	// Template that was used to render the spec of the workspace, if any.
+	Template *TemplateStatus `json:"template,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -34,6 +48,18 @@ type Tunnel struct {
 	ProviderMeta map[string]string `json:"meta"`
 }
 
+// +kubebuilder:object:generate=true
+type RuleStatus struct {
+	// Name of the rule, as defined in the ingress spec.
+	Name string `json:"name"`
+
+	// Host the rule answers to, including its subdomain.
+	Host string `json:"host"`
+
+	// URL is the public URL of the rule, with its scheme and path.
+	URL string `json:"url"`
+}
+
 // +kubebuilder:object:generate=true
 type DNS struct {
 	RecordType string            `json:"recordType"`
@@ -42,12 +68,13 @@ type DNS struct {
 	Properties map[string]string `json:"properties,omitempty"`
 }
 
-// +kubebuilder:validation:Enum=Deploying;Healthy;Error;Terminating
+// +kubebuilder:validation:Enum=Deploying;Healthy;Sleeping;Error;Terminating
 type Phase string
 
 const (
 	PhaseDeploying   Phase = "Deploying"
 	PhaseHealthy     Phase = "Healthy"
+	PhaseSleeping    Phase = "Sleeping"
 	PhaseError       Phase = "Error"
 	PhaseTerminating Phase = "Terminating"
 )
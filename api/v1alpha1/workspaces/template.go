@@ -0,0 +1,37 @@
+package workspaces
+
+// TemplateSpec references a WorkspaceTemplate that is used to render the components and networking
+// of a workspace. When a template is used, the components and networking of the workspace are owned
+// by the template and will be overwritten each time the template is rendered.
+//
+// +kubebuilder:object:generate=true
+type TemplateSpec struct {
+	// Name of the WorkspaceTemplate
+	Name string `json:"name"`
+
+	// Namespace of the WorkspaceTemplate, defaults to the namespace of the workspace.
+	// +optional
+	Namespace *string `json:"namespace,omitempty"`
+
+	// Values for each of the parameters declared by the template.
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// AutoUpdate renders the workspace again when the template changes. Components that are
+	// affected by the change are redeployed.
+	// +optional
+	AutoUpdate bool `json:"autoUpdate,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type TemplateStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Generation of the template that was used to render the workspace.
+	Generation int64 `json:"generation"`
+
+	// Generation of the workspace once the template was rendered into its spec. If the
+	// workspace's generation differs, the spec was changed and needs to be rendered again.
+	RenderedGeneration int64 `json:"renderedGeneration"`
+}
@@ -0,0 +1,44 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/templates"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+)
+
+type renderedTemplate struct {
+	Components []ComponentSpec           `json:"components"`
+	Networking workspaces.NetworkingSpec `json:"networking"`
+}
+
+// Render the components and networking of the template with the values given. Each of the
+// `${parameters::name}` found in the template is replaced by its value, or by the default value of the
+// parameter when no value is given.
+func (t *WorkspaceTemplate) Render(values map[string]string) ([]ComponentSpec, *workspaces.NetworkingSpec, error) {
+	resolved, err := templates.Values(t.Spec.Parameters, values)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Template (%s)->%w", t.Name, err)
+	}
+
+	data, err := json.Marshal(renderedTemplate{
+		Components: t.Spec.Components,
+		Networking: t.Spec.Networking,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("E#3018: Could not render template (%s) -- %w", t.Name, err)
+	}
+
+	data, err = templates.Render(data, resolved)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Template (%s)->%w", t.Name, err)
+	}
+
+	var rendered renderedTemplate
+	if err := json.Unmarshal(data, &rendered); err != nil {
+		return nil, nil, fmt.Errorf("E#3018: Could not render template (%s) -- %w", t.Name, err)
+	}
+
+	return rendered.Components, &rendered.Networking, nil
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/templates"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkspaceTemplateSpec holds the definition of a workspace that can be reused
+// by many workspaces. Each workspace provides its own values for the parameters
+// declared here.
+type WorkspaceTemplateSpec struct {
+	// +optional
+	Parameters []templates.Parameter `json:"parameters,omitempty"`
+
+	Components []ComponentSpec           `json:"components"`
+	Networking workspaces.NetworkingSpec `json:"networking"`
+}
+
+// +kubebuilder:object:root=true
+
+// WorkspaceTemplate is the Schema for the workspacetemplates API
+type WorkspaceTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkspaceTemplateSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// WorkspaceTemplateList contains a list of WorkspaceTemplate
+type WorkspaceTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkspaceTemplate `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkspaceTemplate{}, &WorkspaceTemplateList{})
+}
@@ -96,10 +96,18 @@ func main() {
 				opts = append(opts, source.WithAuth(os.Getenv("BUILD_IMPORT_CREDENTIALS_PATH"), content.Credentials))
 			}
 
-			_, err = source.Git(ctx, opts...)
+			repo, err := source.Git(ctx, opts...)
 			if err != nil {
 				return fmt.Errorf("E#1012: Could not pull repository(URL: %s) -- %w", git.URL, err)
 			}
+
+			if build.Status.GitSHA == "" {
+				sha, err := repo.Ref()
+				if err != nil {
+					return fmt.Errorf("E#1012: Could not read the commit of repository(URL: %s) -- %w", git.URL, err)
+				}
+				build.Status.GitSHA = sha
+			}
 		}
 
 		return nil
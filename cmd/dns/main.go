@@ -20,6 +20,8 @@ import (
 	"crypto/tls"
 	"flag"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -37,6 +39,7 @@ import (
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
 	"github.com/pier-oliviert/sequencer/internal/controller"
+	"github.com/pier-oliviert/sequencer/pkg/propagation"
 	"github.com/pier-oliviert/sequencer/pkg/providers"
 	// +kubebuilder:scaffold:imports
 )
@@ -59,6 +62,13 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var resyncInterval time.Duration
+	var gcInterval time.Duration
+	var gcDryRun bool
+	var propagationCheck bool
+	var propagationTimeout time.Duration
+	var propagationNameservers string
+	var dnsServerAddr string
 	var tlsOpts []func(*tls.Config)
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
 		"Use :8443 for HTTPS or :8080 for HTTP, or leave as 0 to disable the metrics service.")
@@ -70,6 +80,21 @@ func main() {
 		"If set, the metrics endpoint is served securely via HTTPS. Use --metrics-secure=false to use HTTP instead.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.DurationVar(&resyncInterval, "resync-interval", 10*time.Minute,
+		"How often DNS records are verified against the provider and updated if they drifted.")
+	flag.DurationVar(&gcInterval, "gc-interval", time.Hour,
+		"How often records that Sequencer created upstream are checked for a matching DNSRecord. Use 0 to disable it.")
+	flag.BoolVar(&gcDryRun, "gc-dry-run", true,
+		"If set, orphaned records are only reported through logs, events and metrics instead of being deleted.")
+	flag.BoolVar(&propagationCheck, "propagation-check", false,
+		"If set, records are only reported as propagated once the nameservers answer with their target.")
+	flag.DurationVar(&propagationTimeout, "propagation-timeout", 5*time.Minute,
+		"How long a record can take to propagate before it's reported as late.")
+	flag.StringVar(&propagationNameservers, "propagation-nameservers", "",
+		"Comma-separated list of resolvers (host:port) used to verify propagation. "+
+			"The authoritative nameservers of the zone are used when empty.")
+	flag.StringVar(&dnsServerAddr, "dns-server-bind-address", "0",
+		"The address the DNS server for the records of local providers binds to, eg. :5353. Leave as 0 to disable it.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -145,15 +170,47 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&controller.DNSRecordReconciler{
-		DefaultProvider: providers.DefaultProvider(),
-		EventRecorder:   mgr.GetEventRecorderFor("dnsrecord"),
-		Client:          mgr.GetClient(),
-		Scheme:          mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	records := &controller.DNSRecordReconciler{
+		DefaultProvider:    providers.DefaultProvider(),
+		ResyncInterval:     resyncInterval,
+		PropagationTimeout: propagationTimeout,
+		EventRecorder:      mgr.GetEventRecorderFor("dnsrecord"),
+		Client:             mgr.GetClient(),
+		Scheme:             mgr.GetScheme(),
+	}
+	if propagationCheck {
+		records.Propagation = &propagation.Checker{}
+		for _, nameserver := range strings.Split(propagationNameservers, ",") {
+			if nameserver = strings.TrimSpace(nameserver); nameserver != "" {
+				records.Propagation.Nameservers = append(records.Propagation.Nameservers, nameserver)
+			}
+		}
+	}
+	if err = records.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "DNSRecord")
 		os.Exit(1)
 	}
+
+	if gcInterval > 0 {
+		if err = mgr.Add(&controller.DNSRecordSweeper{
+			Records:  records,
+			Interval: gcInterval,
+			DryRun:   gcDryRun,
+		}); err != nil {
+			setupLog.Error(err, "unable to create the sweeper", "controller", "DNSRecord")
+			os.Exit(1)
+		}
+	}
+
+	if dnsServerAddr != "0" {
+		if err = mgr.Add(&controller.DNSRecordServer{
+			Records: records,
+			Address: dnsServerAddr,
+		}); err != nil {
+			setupLog.Error(err, "unable to create the DNS server", "controller", "DNSRecord")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -38,6 +38,7 @@ import (
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
 	"github.com/pier-oliviert/sequencer/internal/controller"
+	gateway "sigs.k8s.io/gateway-api/apis/v1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -50,6 +51,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(sequencer.AddToScheme(scheme))
+	utilruntime.Must(gateway.Install(scheme))
 
 	//+kubebuilder:scaffold:scheme
 }
@@ -165,6 +167,14 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "Build")
 			os.Exit(1)
 		}
+		if err = (&sequencer.Workspace{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Workspace")
+			os.Exit(1)
+		}
+		if err = (&sequencer.Component{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Component")
+			os.Exit(1)
+		}
 	}
 
 	setupLog.Info("starting manager")
@@ -123,6 +123,7 @@ func (r *Registry) Upload(ctx context.Context, index gcr.ImageIndex) (*builds.Im
 
 	return &builds.Image{
 		URL:              r.reference.String(),
+		Tags:             r.tags,
 		IndexManifestStr: string(payload),
 	}, nil
 }
@@ -20,8 +20,11 @@ import (
 	"context"
 	"fmt"
 
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -50,7 +53,11 @@ type ComponentReconciler struct {
 //+kubebuilder:rbac:groups=se.quencer.io,resources=components/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=se.quencer.io,resources=components/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
-//+kubebuilder:rbac:groups="",resources=services;pods,verbs=get;watch;list;create;delete
+//+kubebuilder:rbac:groups="",resources=services;pods,verbs=get;watch;list;create;update;delete
+//+kubebuilder:rbac:groups=se.quencer.io,resources=builds,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups="apps",resources=deployments;statefulsets,verbs=get;watch;list;create;update;delete
+//+kubebuilder:rbac:groups="batch",resources=jobs,verbs=get;watch;list;create;delete
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;watch;list;create;update
 
 func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var component sequencer.Component
@@ -64,18 +71,52 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	if component.Status.Phase == "" {
 		component.Status.Default()
+		component.Status.ObservedGeneration = component.Generation
 		if err := r.Client.Status().Update(ctx, &component); err != nil {
 			return r.componentFailed(ctx, ctrl.Result{}, &component, err)
 		}
 		return ctrl.Result{}, nil
 	}
 
+	// Components created before the generation was tracked are adopted as-is.
+	if component.Status.ObservedGeneration == 0 {
+		component.Status.ObservedGeneration = component.Generation
+		return ctrl.Result{}, r.Client.Status().Update(ctx, &component)
+	}
+
+	// The spec changed since the last time this component was deployed, the
+	// component needs to start over. This is checked before the error phase
+	// as a new spec is the way a user can recover an errored component.
+	if component.Status.ObservedGeneration != component.Generation {
+		if err := r.redeploy(ctx, &component); err != nil {
+			return r.componentFailed(ctx, ctrl.Result{}, &component, fmt.Errorf("redeploy failed: %w", err))
+		}
+		return ctrl.Result{}, nil
+	}
+
 	// If the component already is in an errored phase, there's nothing
 	// else to do.
 	if component.Status.Phase == components.PhaseError {
 		return ctrl.Result{}, nil
 	}
 
+	if component.IsSleeping() {
+		nr := tasks.PodReconciler{Client: r.Client, EventRecorder: r.EventRecorder}
+		if result, err := nr.Sleep(ctx, &component); err != nil {
+			return r.componentFailed(ctx, ctrl.Result{}, &component, fmt.Errorf("sleep failed: %w", err))
+		} else if result != nil {
+			return *result, nil
+		}
+
+		return ctrl.Result{}, nil
+	}
+
+	if component.Status.Phase == components.PhaseSleeping {
+		r.Event(&component, core.EventTypeNormal, string(components.PhaseDeploying), "Waking up component")
+		component.Status.Phase = components.PhaseDeploying
+		return ctrl.Result{}, r.Status().Update(ctx, &component)
+	}
+
 	if result, err := (&tasks.NetworkReconciler{
 		Client:        r.Client,
 		EventRecorder: r.EventRecorder,
@@ -113,6 +154,15 @@ func (r *ComponentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	if conditions.IsStatusConditionPresentAndEqual(component.Status.Conditions, components.DependenciesCondition, conditions.ConditionCompleted) {
+		if result, err := (&tasks.JobsReconciler{
+			Client:        r.Client,
+			EventRecorder: r.EventRecorder,
+		}).Reconcile(ctx, &component); err != nil {
+			return r.componentFailed(ctx, ctrl.Result{}, &component, fmt.Errorf("jobs failed: %w", err))
+		} else if result != nil {
+			return *result, nil
+		}
+
 		nr := tasks.PodReconciler{Client: r.Client, EventRecorder: r.EventRecorder}
 		result, err := nr.Reconcile(ctx, &component)
 		if err != nil {
@@ -136,7 +186,22 @@ func (r *ComponentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		).
 		Watches(
 			&core.Pod{},
-			handler.EnqueueRequestsFromMapFunc(r.reconcileForPodFunc),
+			handler.EnqueueRequestsFromMapFunc(r.reconcileForOwnedFunc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&apps.Deployment{},
+			handler.EnqueueRequestsFromMapFunc(r.reconcileForOwnedFunc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&apps.StatefulSet{},
+			handler.EnqueueRequestsFromMapFunc(r.reconcileForOwnedFunc),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&batch.Job{},
+			handler.EnqueueRequestsFromMapFunc(r.reconcileForOwnedFunc),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
 		Complete(r)
@@ -165,14 +230,14 @@ func (r *ComponentReconciler) reconcileForBuildFunc(ctx context.Context, buildOb
 	return requests
 }
 
-func (r *ComponentReconciler) reconcileForPodFunc(ctx context.Context, pod client.Object) []reconcile.Request {
+func (r *ComponentReconciler) reconcileForOwnedFunc(ctx context.Context, obj client.Object) []reconcile.Request {
 	requests := []reconcile.Request{}
-	for _, owner := range pod.GetOwnerReferences() {
+	for _, owner := range obj.GetOwnerReferences() {
 		if owner.Kind == "Component" && owner.APIVersion == "se.quencer.io/v1alpha1" {
 			requests = append(requests, reconcile.Request{
 				NamespacedName: types.NamespacedName{
 					Name:      owner.Name,
-					Namespace: pod.GetNamespace(),
+					Namespace: obj.GetNamespace(),
 				},
 			})
 		}
@@ -180,6 +245,68 @@ func (r *ComponentReconciler) reconcileForPodFunc(ctx context.Context, pod clien
 	return requests
 }
 
+// Tear down the pod, the jobs and the builds that were generated from the previous spec and reset
+// the status so each of the tasks can run again with the new spec. Services are left in
+// place as the NetworkReconciler reuses them, which keeps the hostnames that other components
+// might have already interpolated stable.
+func (r *ComponentReconciler) redeploy(ctx context.Context, component *sequencer.Component) error {
+	// Deployments and StatefulSets are updated in place once the component is deployed again, which
+	// lets their controller roll out the new spec without downtime.
+	if !component.Spec.Workload.Managed() {
+		var pods core.PodList
+		err := r.List(ctx, &pods, client.InNamespace(component.Namespace), client.MatchingLabels{
+			components.InstanceLabel: component.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("E#5002: failure to retrieve a list of pods for component (%s) -- %w", component.Name, err)
+		}
+
+		for i := range pods.Items {
+			if err := r.Delete(ctx, &pods.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	// Jobs run again with the new spec, ie. migrations for a new commit.
+	var jobs batch.JobList
+	err := r.List(ctx, &jobs, client.InNamespace(component.Namespace), client.MatchingLabels{
+		components.InstanceLabel: component.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("E#5002: failure to retrieve a list of jobs for component (%s) -- %w", component.Name, err)
+	}
+
+	for i := range jobs.Items {
+		if err := r.Delete(ctx, &jobs.Items[i], client.PropagationPolicy(meta.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	var list sequencer.BuildList
+	err = r.List(ctx, &list, client.InNamespace(component.Namespace), client.MatchingLabels{
+		components.NameLabel: component.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("E#5002: failure to retrieve a list of builds for component (%s) -- %w", component.Name, err)
+	}
+
+	for i := range list.Items {
+		if err := r.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	r.Eventf(component, core.EventTypeNormal, string(components.PhaseDeploying), "Spec changed (generation %d), redeploying component", component.Generation)
+
+	component.Status = components.Status{
+		ObservedGeneration: component.Generation,
+	}
+	component.Status.Default()
+
+	return r.Status().Update(ctx, component)
+}
+
 func (r *ComponentReconciler) componentFailed(ctx context.Context, result ctrl.Result, component *sequencer.Component, err error) (ctrl.Result, error) {
 	// Ignore 409, log and error on everything else
 	if k8sErrors.IsConflict(err) {
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/pkg/providers"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Name stored in the status of the records that are managed by the provider configured
+// on the DNS controller.
+const kDefaultDNSProviderName = "default"
+
+var errDNSProviderNotFound = errors.New("the DNSProvider doesn't exist")
+
+// Providers are cached so the clients aren't created on every reconciliation. The version changes
+// when either the DNSProvider or its secret changes.
+type cachedDNSProvider struct {
+	version string
+	providers.Provider
+}
+
+// Returns the name of the provider that manages the record. Once a record is created, it
+// stays with the provider that created it.
+func (r *DNSRecordReconciler) providerNameFor(ctx context.Context, record *sequencer.DNSRecord) (string, error) {
+	if record.Status.Provider != "" {
+		return record.Status.Provider, nil
+	}
+
+	// Records created before DNSProviders existed were all created by the default provider.
+	if !record.IsInitializing() {
+		return kDefaultDNSProviderName, nil
+	}
+
+	if record.Spec.ProviderRef != "" {
+		return record.Spec.ProviderRef, nil
+	}
+
+	var list sequencer.DNSProviderList
+	if err := r.List(ctx, &list); err != nil {
+		return "", fmt.Errorf("E#4007: Couldn't retrieve the list of DNSProviders -- %w", err)
+	}
+
+	// Sorting makes the choice predictable when more than one provider serves the same zone.
+	sort.Slice(list.Items, func(i, j int) bool {
+		return list.Items[i].Name < list.Items[j].Name
+	})
+
+	for _, provider := range list.Items {
+		if provider.Serves(record.Spec.Zone) {
+			return provider.Name, nil
+		}
+	}
+
+	return kDefaultDNSProviderName, nil
+}
+
+func (r *DNSRecordReconciler) providerFor(ctx context.Context, name string) (providers.Provider, error) {
+	if name == kDefaultDNSProviderName {
+		if r.DefaultProvider == nil {
+			return nil, fmt.Errorf("E#4007: No DNSProvider serves the zone and the DNS controller doesn't have a default provider")
+		}
+		return r.DefaultProvider, nil
+	}
+
+	var dnsProvider sequencer.DNSProvider
+	if err := r.Get(ctx, types.NamespacedName{Name: name}, &dnsProvider); err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("E#4007: %w: %s", errDNSProviderNotFound, name)
+		}
+		return nil, fmt.Errorf("E#4007: Couldn't retrieve the DNSProvider %s -- %w", name, err)
+	}
+
+	// Providers that don't need any value, like the local one, don't have a secret.
+	var secret core.Secret
+	if dnsProvider.Spec.SecretRef != nil {
+		if err := r.Get(ctx, dnsProvider.Spec.SecretRef.NamespacedName(), &secret); err != nil {
+			return nil, fmt.Errorf("E#4008: Couldn't retrieve the secret (%s) of the DNSProvider %s -- %w", dnsProvider.Spec.SecretRef, name, err)
+		}
+	}
+
+	version := fmt.Sprintf("%s/%s", dnsProvider.ResourceVersion, secret.ResourceVersion)
+
+	r.providersMutex.Lock()
+	defer r.providersMutex.Unlock()
+
+	if cached, ok := r.providers[name]; ok && cached.version == version {
+		return cached.Provider, nil
+	}
+
+	provider, err := providers.NewProviderWithValues(dnsProvider.Spec.Provider, providers.SecretValues(&secret))
+	if err != nil {
+		return nil, err
+	}
+
+	if r.providers == nil {
+		r.providers = map[string]cachedDNSProvider{}
+	}
+	r.providers[name] = cachedDNSProvider{version: version, Provider: provider}
+
+	return provider, nil
+}
+
+// Returns every provider the controller can use, by name. A DNSProvider that can't be
+// configured is skipped so it doesn't prevent the other ones from being used.
+func (r *DNSRecordReconciler) allProviders(ctx context.Context) (map[string]providers.Provider, error) {
+	all := map[string]providers.Provider{}
+	if r.DefaultProvider != nil {
+		all[kDefaultDNSProviderName] = r.DefaultProvider
+	}
+
+	var list sequencer.DNSProviderList
+	if err := r.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("E#4007: Couldn't retrieve the list of DNSProviders -- %w", err)
+	}
+
+	for _, dnsProvider := range list.Items {
+		provider, err := r.providerFor(ctx, dnsProvider.Name)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Skipping DNSProvider", "DNSProvider", dnsProvider.Name)
+			continue
+		}
+		all[dnsProvider.Name] = provider
+	}
+
+	return all, nil
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/pkg/providers"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("DNSProvider routing", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "my-workspace-abcd", Namespace: "default"}
+
+	var dnsRecord *sequencer.DNSRecord
+	var provider *fakeDNSProvider
+	var reconciler *DNSRecordReconciler
+
+	dnsProvider := func(name, kind string, zones ...string) *sequencer.DNSProvider {
+		p := &sequencer.DNSProvider{}
+		p.Name = name
+		p.Spec = sequencer.DNSProviderSpec{Provider: kind, Zones: zones}
+		return p
+	}
+
+	BeforeEach(func() {
+		dnsRecord = &sequencer.DNSRecord{}
+		dnsRecord.Name = key.Name
+		dnsRecord.Namespace = key.Namespace
+		dnsRecord.Generation = 1
+		dnsRecord.Spec = sequencer.DNSRecordSpec{Zone: "previews.example.com", RecordType: "A", Name: "my-workspace.previews.example.com", Target: "203.0.113.10"}
+	})
+
+	// The reconciler is created once the test added the objects it needs.
+	start := func(objects ...client.Object) {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(sequencer.AddToScheme(scheme)).To(Succeed())
+
+		provider = &fakeDNSProvider{}
+		reconciler = &DNSRecordReconciler{
+			DefaultProvider: provider,
+			EventRecorder:   record.NewFakeRecorder(20),
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append(objects, dnsRecord)...).
+				WithStatusSubresource(&sequencer.DNSRecord{}).
+				Build(),
+		}
+	}
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	current := func() *sequencer.DNSRecord {
+		var record sequencer.DNSRecord
+		Expect(reconciler.Get(ctx, key, &record)).To(Succeed())
+		return &record
+	}
+
+	providerCondition := func() *conditions.Condition {
+		return conditions.FindCondition(current().Status.Conditions, dnsrecords.ProviderCondition)
+	}
+
+	It("routes the record to the DNSProvider that serves its zone", func() {
+		start(
+			dnsProvider("example", "local", "example.com"),
+			dnsProvider("previews", "local", "previews.example.com."),
+		)
+
+		reconcile()
+		Expect(current().Status.Provider).To(Equal("previews"))
+		Expect(providerCondition().Status).To(Equal(conditions.ConditionCreated))
+		Expect(provider.creates).To(Equal(0))
+	})
+
+	It("routes the record to the DNSProvider it references", func() {
+		dnsRecord.Spec.ProviderRef = "lab"
+		start(
+			dnsProvider("lab", "local"),
+			dnsProvider("previews", "local", "previews.example.com"),
+		)
+
+		reconcile()
+		Expect(current().Status.Provider).To(Equal("lab"))
+		Expect(provider.creates).To(Equal(0))
+	})
+
+	It("uses the default provider when no DNSProvider serves the zone", func() {
+		start(dnsProvider("example", "local", "example.com"))
+
+		reconcile()
+		Expect(current().Status.Provider).To(Equal(kDefaultDNSProviderName))
+		Expect(provider.creates).To(Equal(1))
+	})
+
+	It("errors when the referenced DNSProvider doesn't exist", func() {
+		dnsRecord.Spec.ProviderRef = "lab"
+		start()
+
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).To(MatchError(ContainSubstring("E#4007")))
+		Expect(provider.creates).To(Equal(0))
+	})
+
+	It("keeps the records created before DNSProviders existed on the default provider", func() {
+		dnsRecord.Status.ObservedGeneration = 1
+		conditions.SetCondition(&dnsRecord.Status.Conditions, conditions.Condition{
+			Type:   dnsrecords.ProviderCondition,
+			Status: conditions.ConditionCreated,
+			Reason: "Provider created the DNS record",
+		})
+		start(dnsProvider("previews", "local", "previews.example.com"))
+
+		record := current()
+		record.Spec.Target = "203.0.113.11"
+		record.Generation = 2
+		Expect(reconciler.Update(ctx, record)).To(Succeed())
+
+		reconcile()
+		Expect(provider.updates).To(Equal(1))
+		Expect(current().Status.Provider).To(BeEmpty())
+	})
+
+	It("keeps the record with its provider when a DNSProvider starts serving its zone", func() {
+		start()
+		reconcile()
+		Expect(current().Status.Provider).To(Equal(kDefaultDNSProviderName))
+
+		Expect(reconciler.Create(ctx, dnsProvider("previews", "local", "previews.example.com"))).To(Succeed())
+		record := current()
+		record.Spec.Target = "203.0.113.11"
+		record.Generation = 2
+		Expect(reconciler.Update(ctx, record)).To(Succeed())
+
+		reconcile()
+		Expect(provider.updates).To(Equal(1))
+		Expect(current().Status.Provider).To(Equal(kDefaultDNSProviderName))
+	})
+
+	Context("with a DNSProvider configured from a secret", func() {
+		var secret *core.Secret
+
+		BeforeEach(func() {
+			secret = &core.Secret{}
+			secret.Name = "cloudflare"
+			secret.Namespace = "sequencer-system"
+			secret.Data = map[string][]byte{"CF_API_TOKEN": []byte("token"), "CF_ZONE_ID": []byte("zone")}
+
+			cloudflare := dnsProvider("cloudflare", "cloudflare", "previews.example.com")
+			cloudflare.Spec.SecretRef = &utils.Reference{Name: secret.Name, Namespace: secret.Namespace}
+			start(cloudflare, secret)
+		})
+
+		It("reuses the client while nothing changes", func() {
+			first, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(providers.IsLocal(first)).To(BeFalse())
+
+			second, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(BeIdenticalTo(first))
+		})
+
+		It("creates a new client when the secret changes", func() {
+			first, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+			secret.Data["CF_API_TOKEN"] = []byte("rotated")
+			Expect(reconciler.Update(ctx, secret)).To(Succeed())
+
+			second, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).NotTo(BeIdenticalTo(first))
+		})
+
+		It("creates a new client when the DNSProvider changes", func() {
+			first, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).NotTo(HaveOccurred())
+
+			var cloudflare sequencer.DNSProvider
+			Expect(reconciler.Get(ctx, types.NamespacedName{Name: "cloudflare"}, &cloudflare)).To(Succeed())
+			cloudflare.Spec.Zones = append(cloudflare.Spec.Zones, "staging.example.com")
+			Expect(reconciler.Update(ctx, &cloudflare)).To(Succeed())
+
+			second, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).NotTo(BeIdenticalTo(first))
+		})
+
+		It("errors when the secret is missing a value", func() {
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(secret), secret)).To(Succeed())
+			delete(secret.Data, "CF_ZONE_ID")
+			Expect(reconciler.Update(ctx, secret)).To(Succeed())
+
+			_, err := reconciler.providerFor(ctx, "cloudflare")
+			Expect(err).To(MatchError(ContainSubstring("E#4002")))
+		})
+	})
+})
@@ -18,14 +18,20 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
+	"github.com/pier-oliviert/sequencer/pkg/propagation"
 	"github.com/pier-oliviert/sequencer/pkg/providers"
 	core "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -36,10 +42,41 @@ import (
 
 const kDNSRecordFinalizer = "dns.sequencer.io/provider-finalizer"
 
+// Interval used when ResyncInterval isn't set.
+const kDNSRecordDefaultResyncInterval = 10 * time.Minute
+
+// Timeout used when PropagationTimeout isn't set.
+const kDNSRecordDefaultPropagationTimeout = 5 * time.Minute
+
+// How long to wait between two propagation checks.
+const kDNSRecordPropagationInterval = 10 * time.Second
+
+// How long to wait between two checks of a change the provider hasn't applied yet.
+const kDNSRecordChangeInterval = 5 * time.Second
+
+// How long a provider can take to apply a change before a warning is emitted. The change keeps being
+// checked after that as the provider accepted it.
+const kDNSRecordChangeTimeout = 5 * time.Minute
+
 // DNSRecordReconciler reconciles a DNSRecord object
 type DNSRecordReconciler struct {
+	// Provider used for the records that aren't served by a DNSProvider. It can be nil
+	// when every zone is served by a DNSProvider.
 	DefaultProvider providers.Provider
 
+	providers      map[string]cachedDNSProvider
+	providersMutex sync.Mutex
+
+	// How often records are verified against the provider to correct drift.
+	ResyncInterval time.Duration
+
+	// Verifies that records answer with their target after they're created or updated. Propagation
+	// isn't verified when nil.
+	Propagation *propagation.Checker
+
+	// How long a record can take to propagate before it's reported as late.
+	PropagationTimeout time.Duration
+
 	record.EventRecorder
 	client.Client
 	Scheme *runtime.Scheme
@@ -48,6 +85,8 @@ type DNSRecordReconciler struct {
 // +kubebuilder:rbac:groups=se.quencer.io,resources=dnsrecords,verbs=get;list;watch;
 // +kubebuilder:rbac:groups=se.quencer.io,resources=dnsrecords/status,verbs=get;patch
 // +kubebuilder:rbac:groups=se.quencer.io,resources=dnsrecords/finalizers,verbs=update
+// +kubebuilder:rbac:groups=se.quencer.io,resources=dnsproviders,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 //
 // Operate on the DNSRecord custom resource that are present on the system. The reconciler isn't in charge
 // of creating the DNSRecord, it only monitors them and make sure the providers are in sync with what the system has.
@@ -62,8 +101,27 @@ func (r *DNSRecordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, fmt.Errorf("E#5001: Couldn't retrieve the DNSRecord (%s) -- %w", req.NamespacedName, err)
 	}
 
+	providerName, err := r.providerNameFor(ctx, &record)
+	if err != nil {
+		r.Event(&record, core.EventTypeWarning, string(dnsrecords.ProviderCondition), err.Error())
+		return ctrl.Result{}, err
+	}
+
+	provider, err := r.providerFor(ctx, providerName)
+	if err != nil {
+		if record.IsTerminating() && errors.Is(err, errDNSProviderNotFound) {
+			// The record can't be deleted upstream without its provider, blocking the deletion would
+			// leave the record, and its workspace, stuck forever.
+			provider = nil
+		} else {
+			r.Event(&record, core.EventTypeWarning, string(dnsrecords.ProviderCondition), err.Error())
+			return ctrl.Result{}, err
+		}
+	}
+
 	if record.IsInitializing() {
-		err := r.createRecord(ctx, &record)
+		record.Status.Provider = providerName
+		err := r.createRecord(ctx, &record, provider)
 		if err != nil {
 			r.Event(&record, core.EventTypeWarning, string(dnsrecords.ProviderCondition), err.Error())
 		}
@@ -72,7 +130,7 @@ func (r *DNSRecordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	}
 
 	if record.IsTerminating() {
-		err := r.deleteRecord(ctx, &record)
+		err := r.deleteRecord(ctx, &record, provider)
 		if err != nil {
 			r.Event(&record, core.EventTypeWarning, string(dnsrecords.ProviderCondition), err.Error())
 		}
@@ -80,10 +138,60 @@ func (r *DNSRecordReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// The provider is still creating the record.
+	if condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.ProviderCondition); condition == nil || condition.Status == conditions.ConditionLocked {
+		return ctrl.Result{}, nil
+	}
+
+	// The provider accepted a change it hasn't applied yet.
+	if record.Status.PendingChange != nil && record.Status.ObservedGeneration == record.Generation {
+		return r.verifyChange(ctx, &record, provider)
+	}
+
+	// A record that couldn't be created is only retried when its spec changes.
+	if providerErrored(&record) && record.Status.ObservedGeneration == record.Generation {
+		return ctrl.Result{}, nil
+	}
+
+	if condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.PropagatedCondition); condition != nil && condition.Status == conditions.ConditionInProgress && record.Status.ObservedGeneration == record.Generation {
+		if r.Propagation == nil {
+			// Checks were disabled while the record was propagating, nothing is going to complete it.
+			conditions.RemoveCondition(&record.Status.Conditions, dnsrecords.PropagatedCondition)
+			return ctrl.Result{}, r.Status().Patch(ctx, &record, client.Merge)
+		}
+
+		return r.verifyPropagation(ctx, &record, condition)
+	}
+
+	if wait := r.nextVerification(&record); record.Status.ObservedGeneration == record.Generation && wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+
+	if err := r.updateRecord(ctx, &record, provider); err != nil {
+		r.Event(&record, core.EventTypeWarning, string(dnsrecords.ProviderCondition), err.Error())
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: r.resyncInterval()}, nil
 }
 
-func (r *DNSRecordReconciler) createRecord(ctx context.Context, record *sequencer.DNSRecord) error {
+func (r *DNSRecordReconciler) resyncInterval() time.Duration {
+	if r.ResyncInterval <= 0 {
+		return kDNSRecordDefaultResyncInterval
+	}
+	return r.ResyncInterval
+}
+
+// Returns how long until the record needs to be verified against the provider again.
+func (r *DNSRecordReconciler) nextVerification(record *sequencer.DNSRecord) time.Duration {
+	if record.Status.LastVerifiedAt == nil {
+		return 0
+	}
+
+	return time.Until(record.Status.LastVerifiedAt.Add(r.resyncInterval()))
+}
+
+func (r *DNSRecordReconciler) createRecord(ctx context.Context, record *sequencer.DNSRecord, provider providers.Provider) error {
 	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
 		Type:   dnsrecords.ProviderCondition,
 		Status: conditions.ConditionLocked,
@@ -100,12 +208,13 @@ func (r *DNSRecordReconciler) createRecord(ctx context.Context, record *sequence
 		}
 	}
 
-	if err := r.DefaultProvider.Create(ctx, record); err != nil {
+	if err := provider.Create(ctx, record); err != nil {
 		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
 			Type:   dnsrecords.ProviderCondition,
 			Status: conditions.ConditionError,
 			Reason: err.Error(),
 		})
+		record.Status.ObservedGeneration = record.Generation
 
 		if updateErr := r.Client.Status().Patch(ctx, record, client.Merge); updateErr != nil {
 			return fmt.Errorf("%w -- %w", updateErr, err)
@@ -113,23 +222,125 @@ func (r *DNSRecordReconciler) createRecord(ctx context.Context, record *sequence
 		return err
 	}
 
+	record.Status.ObservedGeneration = record.Generation
+	record.Status.LastVerifiedAt = &meta.Time{Time: time.Now()}
+	if record.Status.PendingChange != nil {
+		waitForChange(record)
+		return r.Client.Status().Patch(ctx, record, client.Merge)
+	}
+
 	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
 		Type:   dnsrecords.ProviderCondition,
 		Status: conditions.ConditionCreated,
 		Reason: "Provider created the DNS record",
 	})
+	r.startPropagation(record)
 
 	return r.Client.Status().Patch(ctx, record, client.Merge)
 }
 
-func (r *DNSRecordReconciler) deleteRecord(ctx context.Context, record *sequencer.DNSRecord) error {
+// Apply the spec to the provider. This is done when the spec changed since it was last applied, and periodically
+// so that a record that was changed or deleted upstream is brought back in sync.
+func (r *DNSRecordReconciler) updateRecord(ctx context.Context, record *sequencer.DNSRecord, provider providers.Provider) error {
+	// A change that is still pending is replaced by the one made for the new spec.
+	patch := client.MergeFrom(record.DeepCopy())
+	record.Status.PendingChange = nil
+
+	// Errors aren't stored in the conditions as the record exists upstream and the failure might only
+	// be temporary. The error is returned so the update is retried.
+	if err := provider.Update(ctx, record); err != nil {
+		return fmt.Errorf("E#4006: Provider couldn't update the DNS record -- %w", err)
+	}
+
+	if record.Status.PendingChange != nil {
+		waitForChange(record)
+		record.Status.ObservedGeneration = record.Generation
+		record.Status.LastVerifiedAt = &meta.Time{Time: time.Now()}
+		return r.Status().Patch(ctx, record, patch)
+	}
+
+	reason := "Provider verified the DNS record"
+	if record.Status.ObservedGeneration != record.Generation {
+		reason = "Provider updated the DNS record"
+		r.startPropagation(record)
+	} else {
+		r.recheckPropagation(ctx, record)
+	}
+
+	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+		Type:   dnsrecords.ProviderCondition,
+		Status: conditions.ConditionCreated,
+		Reason: reason,
+	})
+	record.Status.ObservedGeneration = record.Generation
+	record.Status.LastVerifiedAt = &meta.Time{Time: time.Now()}
+
+	return r.Status().Patch(ctx, record, patch)
+}
+
+// The record exists upstream but the provider hasn't applied the change to its nameservers yet.
+func waitForChange(record *sequencer.DNSRecord) {
+	// Resetting the condition so the timeout starts from now, even if a previous change was still pending.
+	conditions.RemoveCondition(&record.Status.Conditions, dnsrecords.ProviderCondition)
+	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+		Type:   dnsrecords.ProviderCondition,
+		Status: conditions.ConditionInProgress,
+		Reason: fmt.Sprintf("Waiting for the provider to apply the change (%s)", *record.Status.PendingChange),
+	})
+}
+
+// Poll the provider until it applied the pending change. Errors are returned so the check is retried, they
+// never mark the record as errored as the change was accepted and the record exists upstream.
+func (r *DNSRecordReconciler) verifyChange(ctx context.Context, record *sequencer.DNSRecord, provider providers.Provider) (ctrl.Result, error) {
+	changeID := *record.Status.PendingChange
+
+	// Only providers that track their changes set a pending change. The record was moved to another one since.
+	applied := true
+	if tracker, ok := provider.(providers.ChangeTracker); ok {
+		var err error
+		applied, err = tracker.ChangeApplied(ctx, changeID)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("E#4104: Could not retrieve the status of the change (%s) -- %w", changeID, err)
+		}
+	}
+
+	patch := client.MergeFrom(record.DeepCopy())
+	if !applied {
+		condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.ProviderCondition)
+		if time.Since(condition.LastTransitionTime.Time) < kDNSRecordChangeTimeout || strings.HasPrefix(condition.Reason, "E#4104") {
+			return ctrl.Result{RequeueAfter: kDNSRecordChangeInterval}, nil
+		}
+
+		reason := fmt.Sprintf("E#4104: The change (%s) wasn't applied by the provider within %s, still waiting", changeID, kDNSRecordChangeTimeout)
+		r.Event(record, core.EventTypeWarning, string(dnsrecords.ProviderCondition), reason)
+		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+			Type:   dnsrecords.ProviderCondition,
+			Status: conditions.ConditionInProgress,
+			Reason: reason,
+		})
+
+		return ctrl.Result{RequeueAfter: kDNSRecordChangeInterval}, r.Status().Patch(ctx, record, patch)
+	}
+
+	record.Status.PendingChange = nil
+	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+		Type:   dnsrecords.ProviderCondition,
+		Status: conditions.ConditionCreated,
+		Reason: "Provider applied the change to the DNS record",
+	})
+	r.startPropagation(record)
+
+	return ctrl.Result{RequeueAfter: r.resyncInterval()}, r.Status().Patch(ctx, record, patch)
+}
+
+func (r *DNSRecordReconciler) deleteRecord(ctx context.Context, record *sequencer.DNSRecord, provider providers.Provider) error {
 	condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.ProviderCondition)
 	if condition.Status == conditions.ConditionTerminated && controllerutil.ContainsFinalizer(record, kDNSRecordFinalizer) {
 		controllerutil.RemoveFinalizer(record, kDNSRecordFinalizer)
 		return r.Update(ctx, record)
 	}
 
-	if record.IsErrored() {
+	if providerErrored(record) {
 		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
 			Type:   dnsrecords.ProviderCondition,
 			Status: conditions.ConditionTerminated,
@@ -139,6 +350,16 @@ func (r *DNSRecordReconciler) deleteRecord(ctx context.Context, record *sequence
 		return r.Status().Patch(ctx, record, client.Merge)
 	}
 
+	if provider == nil {
+		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+			Type:   dnsrecords.ProviderCondition,
+			Status: conditions.ConditionTerminated,
+			Reason: fmt.Sprintf("DNSProvider %s doesn't exist anymore, not deleting the record upstream", record.Status.Provider),
+		})
+
+		return r.Status().Patch(ctx, record, client.Merge)
+	}
+
 	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
 		Type:   dnsrecords.ProviderCondition,
 		Status: conditions.ConditionLocked,
@@ -149,7 +370,7 @@ func (r *DNSRecordReconciler) deleteRecord(ctx context.Context, record *sequence
 		return err
 	}
 
-	if err := r.DefaultProvider.Delete(ctx, record); err != nil {
+	if err := provider.Delete(ctx, record); err != nil {
 		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
 			Type:   dnsrecords.ProviderCondition,
 			Status: conditions.ConditionError,
@@ -171,6 +392,98 @@ func (r *DNSRecordReconciler) deleteRecord(ctx context.Context, record *sequence
 	return r.Status().Patch(ctx, record, client.Merge)
 }
 
+// Marks the record as propagating so it gets verified by the next reconciliation.
+func (r *DNSRecordReconciler) startPropagation(record *sequencer.DNSRecord) {
+	if r.Propagation == nil {
+		return
+	}
+
+	// Resetting the condition so the timeout starts from now, even if the previous change was still propagating.
+	conditions.RemoveCondition(&record.Status.Conditions, dnsrecords.PropagatedCondition)
+	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+		Type:   dnsrecords.PropagatedCondition,
+		Status: conditions.ConditionInProgress,
+		Reason: "Waiting for the DNS record to propagate",
+	})
+}
+
+// Query the nameservers until the record answers with its target, or until the timeout is reached.
+func (r *DNSRecordReconciler) verifyPropagation(ctx context.Context, record *sequencer.DNSRecord, condition *conditions.Condition) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	propagated, err := r.Propagation.Propagated(ctx, record.Spec.RecordType, record.Spec.Name, record.Spec.Target)
+	if err != nil {
+		// Nameservers can be unreachable for a moment, the check is retried until the timeout.
+		logger.Info("Couldn't verify the propagation of the DNS record", "error", err.Error())
+	}
+
+	if propagated {
+		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+			Type:   dnsrecords.PropagatedCondition,
+			Status: conditions.ConditionCompleted,
+			Reason: "DNS record answers with its target",
+		})
+
+		return ctrl.Result{RequeueAfter: r.resyncInterval()}, r.Status().Patch(ctx, record, client.Merge)
+	}
+
+	timeout := r.PropagationTimeout
+	if timeout <= 0 {
+		timeout = kDNSRecordDefaultPropagationTimeout
+	}
+
+	if time.Since(condition.LastTransitionTime.Time) > timeout {
+		reason := fmt.Sprintf("E#4009: DNS record %s didn't propagate within %s", record.Spec.Name, timeout)
+		if err != nil {
+			reason = fmt.Sprintf("%s -- %s", reason, err.Error())
+		}
+
+		r.Event(record, core.EventTypeWarning, string(dnsrecords.PropagatedCondition), reason)
+		conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+			Type:   dnsrecords.PropagatedCondition,
+			Status: conditions.ConditionError,
+			Reason: reason,
+		})
+
+		return ctrl.Result{RequeueAfter: r.resyncInterval()}, r.Status().Patch(ctx, record, client.Merge)
+	}
+
+	return ctrl.Result{RequeueAfter: kDNSRecordPropagationInterval}, nil
+}
+
+// A record that didn't propagate before the timeout can still propagate later, it's checked again every time the
+// record is verified and the error is cleared once it answers with its target.
+func (r *DNSRecordReconciler) recheckPropagation(ctx context.Context, record *sequencer.DNSRecord) {
+	condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.PropagatedCondition)
+	if condition == nil || condition.Status != conditions.ConditionError {
+		return
+	}
+
+	if r.Propagation == nil {
+		conditions.RemoveCondition(&record.Status.Conditions, dnsrecords.PropagatedCondition)
+		return
+	}
+
+	propagated, err := r.Propagation.Propagated(ctx, record.Spec.RecordType, record.Spec.Name, record.Spec.Target)
+	if err != nil || !propagated {
+		return
+	}
+
+	r.Event(record, core.EventTypeNormal, string(dnsrecords.PropagatedCondition), "DNS record answers with its target")
+	conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+		Type:   dnsrecords.PropagatedCondition,
+		Status: conditions.ConditionCompleted,
+		Reason: "DNS record answers with its target",
+	})
+}
+
+// Only the provider's errors mean the record might not exist upstream. A record that didn't propagate
+// still needs to be updated and deleted.
+func providerErrored(record *sequencer.DNSRecord) bool {
+	condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.ProviderCondition)
+	return condition != nil && condition.Status == conditions.ConditionError
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *DNSRecordReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -17,7 +17,28 @@ limitations under the License.
 package controller
 
 import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
+	"github.com/pier-oliviert/sequencer/pkg/propagation"
+	"github.com/pier-oliviert/sequencer/pkg/providers"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("DNSRecord Controller", func() {
@@ -30,3 +51,291 @@ var _ = Describe("DNSRecord Controller", func() {
 		})
 	})
 })
+
+// Provider that records the calls made to it. When pending is set, the changes are applied
+// asynchronously like Route53 does.
+type fakeDNSProvider struct {
+	creates, updates, deletes int
+	pending                   string
+	applied                   bool
+	err                       error
+}
+
+func (p *fakeDNSProvider) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	p.creates++
+	return p.apply(record)
+}
+
+func (p *fakeDNSProvider) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	p.updates++
+	return p.apply(record)
+}
+
+func (p *fakeDNSProvider) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
+	p.deletes++
+	return p.err
+}
+
+func (p *fakeDNSProvider) ChangeApplied(ctx context.Context, changeID string) (bool, error) {
+	return p.applied, p.err
+}
+
+func (p *fakeDNSProvider) apply(record *sequencer.DNSRecord) error {
+	if p.err != nil {
+		return p.err
+	}
+
+	if p.pending != "" {
+		record.Status.PendingChange = ptr.To(p.pending)
+	}
+	return nil
+}
+
+// Provider that can find the records it owns upstream, like the ones the sweeper removes.
+type fakeSweepingDNSProvider struct {
+	fakeDNSProvider
+	owned     []providers.OwnedRecord
+	deleted   []string
+	deleteErr error
+}
+
+func (p *fakeSweepingDNSProvider) OwnedRecords(ctx context.Context) ([]providers.OwnedRecord, error) {
+	return p.owned, nil
+}
+
+func (p *fakeSweepingDNSProvider) DeleteOwnedRecord(ctx context.Context, record providers.OwnedRecord) error {
+	if p.deleteErr != nil {
+		return p.deleteErr
+	}
+
+	p.deleted = append(p.deleted, record.Name)
+	return nil
+}
+
+var _ = Describe("DNSRecord reconciliation", func() {
+	ctx := context.Background()
+	key := types.NamespacedName{Name: "my-workspace-abcd", Namespace: "default"}
+
+	var provider *fakeDNSProvider
+	var reconciler *DNSRecordReconciler
+
+	BeforeEach(func() {
+		dnsRecord := &sequencer.DNSRecord{}
+		dnsRecord.Name = key.Name
+		dnsRecord.Namespace = key.Namespace
+		dnsRecord.Generation = 1
+		dnsRecord.Spec = sequencer.DNSRecordSpec{Zone: "example.com", RecordType: "A", Name: "my-workspace.example.com", Target: "203.0.113.10"}
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(sequencer.AddToScheme(scheme)).To(Succeed())
+
+		provider = &fakeDNSProvider{}
+		reconciler = &DNSRecordReconciler{
+			DefaultProvider: provider,
+			EventRecorder:   record.NewFakeRecorder(20),
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(dnsRecord).
+				WithStatusSubresource(&sequencer.DNSRecord{}).
+				Build(),
+		}
+	})
+
+	reconcile := func() ctrl.Result {
+		result, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+		Expect(err).NotTo(HaveOccurred())
+		return result
+	}
+
+	current := func() *sequencer.DNSRecord {
+		var record sequencer.DNSRecord
+		Expect(reconciler.Get(ctx, key, &record)).To(Succeed())
+		return &record
+	}
+
+	providerCondition := func() *conditions.Condition {
+		return conditions.FindCondition(current().Status.Conditions, dnsrecords.ProviderCondition)
+	}
+
+	Context("with a record created upstream", func() {
+		BeforeEach(func() {
+			reconciler.ResyncInterval = time.Hour
+			reconcile()
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionCreated))
+			Expect(provider.creates).To(Equal(1))
+		})
+
+		It("waits for the resync interval before verifying it again", func() {
+			result := reconcile()
+			Expect(result.RequeueAfter).To(BeNumerically(">", 59*time.Minute))
+			Expect(result.RequeueAfter).To(BeNumerically("<=", time.Hour))
+			Expect(provider.updates).To(Equal(0))
+
+			record := current()
+			record.Status.LastVerifiedAt = &meta.Time{Time: time.Now().Add(-2 * time.Hour)}
+			Expect(reconciler.Status().Update(ctx, record)).To(Succeed())
+
+			Expect(reconcile().RequeueAfter).To(Equal(time.Hour))
+			Expect(provider.updates).To(Equal(1))
+			Expect(providerCondition().Reason).To(Equal("Provider verified the DNS record"))
+		})
+
+		It("updates it as soon as its spec changes", func() {
+			record := current()
+			record.Spec.Target = "203.0.113.11"
+			record.Generation = 2
+			Expect(reconciler.Update(ctx, record)).To(Succeed())
+
+			Expect(reconcile().RequeueAfter).To(Equal(time.Hour))
+			Expect(provider.updates).To(Equal(1))
+			Expect(providerCondition().Reason).To(Equal("Provider updated the DNS record"))
+			Expect(current().Status.ObservedGeneration).To(Equal(int64(2)))
+
+			reconcile()
+			Expect(provider.updates).To(Equal(1))
+		})
+
+		It("retries an update that failed", func() {
+			record := current()
+			record.Generation = 2
+			Expect(reconciler.Update(ctx, record)).To(Succeed())
+
+			provider.err = errors.New("unavailable")
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).To(MatchError(ContainSubstring("E#4006")))
+			Expect(current().Status.ObservedGeneration).To(Equal(int64(1)))
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionCreated))
+
+			provider.err = nil
+			reconcile()
+			Expect(current().Status.ObservedGeneration).To(Equal(int64(2)))
+		})
+	})
+
+	Context("with a provider that applies changes asynchronously", func() {
+		BeforeEach(func() {
+			provider.pending = "/change/C1"
+		})
+
+		It("waits for the change to be applied without blocking", func() {
+			reconcile()
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionInProgress))
+			Expect(*current().Status.PendingChange).To(Equal("/change/C1"))
+
+			Expect(reconcile().RequeueAfter).To(Equal(kDNSRecordChangeInterval))
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionInProgress))
+
+			provider.applied = true
+			Expect(reconcile().RequeueAfter).To(Equal(reconciler.resyncInterval()))
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionCreated))
+			Expect(current().Status.PendingChange).To(BeNil())
+			Expect(provider.creates).To(Equal(1))
+			Expect(provider.updates).To(Equal(0))
+		})
+
+		It("keeps waiting for a change that is slow to apply", func() {
+			reconcile()
+
+			record := current()
+			condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.ProviderCondition)
+			condition.LastTransitionTime = meta.NewTime(time.Now().Add(-2 * kDNSRecordChangeTimeout))
+			Expect(reconciler.Status().Update(ctx, record)).To(Succeed())
+
+			Expect(reconcile().RequeueAfter).To(Equal(kDNSRecordChangeInterval))
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionInProgress))
+			Expect(providerCondition().Reason).To(HavePrefix("E#4104"))
+		})
+
+		It("retries when the status of the change can't be retrieved", func() {
+			reconcile()
+
+			provider.err = errors.New("throttled")
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).To(MatchError(ContainSubstring("E#4104")))
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionInProgress))
+		})
+
+		It("deletes a record that is waiting for its change upstream", func() {
+			reconcile()
+
+			Expect(reconciler.Delete(ctx, current())).To(Succeed())
+			reconcile()
+			Expect(provider.deletes).To(Equal(1))
+			Expect(providerCondition().Status).To(Equal(conditions.ConditionTerminated))
+		})
+	})
+
+	Context("with a record that didn't propagate in time", func() {
+		var server *dns.Server
+		var answers []dns.RR
+
+		BeforeEach(func() {
+			record := current()
+			record.Status.ObservedGeneration = record.Generation
+			conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+				Type:   dnsrecords.ProviderCondition,
+				Status: conditions.ConditionCreated,
+				Reason: "Provider created the DNS record",
+			})
+			conditions.SetCondition(&record.Status.Conditions, conditions.Condition{
+				Type:   dnsrecords.PropagatedCondition,
+				Status: conditions.ConditionError,
+				Reason: "E#4009: DNS record didn't propagate",
+			})
+			Expect(reconciler.Status().Update(ctx, record)).To(Succeed())
+
+			conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+			Expect(err).NotTo(HaveOccurred())
+
+			answers = nil
+			started := make(chan struct{})
+			server = &dns.Server{
+				PacketConn:        conn,
+				NotifyStartedFunc: func() { close(started) },
+				Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+					response := new(dns.Msg)
+					response.SetReply(r)
+					response.Answer = answers
+					_ = w.WriteMsg(response)
+				}),
+			}
+			go func() { _ = server.ActivateAndServe() }()
+			<-started
+
+			reconciler.Propagation = &propagation.Checker{Nameservers: []string{conn.LocalAddr().String()}}
+		})
+
+		AfterEach(func() {
+			Expect(server.Shutdown()).To(Succeed())
+		})
+
+		propagatedCondition := func() *conditions.Condition {
+			return conditions.FindCondition(current().Status.Conditions, dnsrecords.PropagatedCondition)
+		}
+
+		It("verifies it again when the record is resynced", func() {
+			Expect(reconcile().RequeueAfter).To(Equal(reconciler.resyncInterval()))
+			Expect(propagatedCondition().Status).To(Equal(conditions.ConditionError))
+
+			record := current()
+			record.Status.LastVerifiedAt = nil
+			Expect(reconciler.Status().Update(ctx, record)).To(Succeed())
+
+			answer, err := dns.NewRR("my-workspace.example.com. 60 IN A 203.0.113.10")
+			Expect(err).NotTo(HaveOccurred())
+			answers = []dns.RR{answer}
+
+			reconcile()
+			Expect(propagatedCondition().Status).To(Equal(conditions.ConditionCompleted))
+			Expect(provider.updates).To(Equal(2))
+		})
+
+		It("clears the error when checks are disabled", func() {
+			reconciler.Propagation = nil
+
+			reconcile()
+			Expect(propagatedCondition()).To(BeNil())
+		})
+	})
+})
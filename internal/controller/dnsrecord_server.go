@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
+	"github.com/pier-oliviert/sequencer/pkg/providers"
+	"golang.org/x/sync/errgroup"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DNSRecordServer is an authoritative DNS server for the records created through the local provider. Records
+// are read from the cache of the manager, so a record resolves as soon as the reconciler marks it as created.
+type DNSRecordServer struct {
+	// The reconciler is used to know which records are managed by a local provider.
+	Records *DNSRecordReconciler
+
+	// Address the server listens to, over UDP and TCP, eg. `:5353`.
+	Address string
+}
+
+// Every replica answers queries.
+func (s *DNSRecordServer) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *DNSRecordServer) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("dnsrecord-server")
+	handler := dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+		response, err := s.Answer(ctx, r)
+		if err != nil {
+			logger.Error(err, "Couldn't answer the DNS query", "question", r.Question)
+		}
+		_ = w.WriteMsg(response)
+	})
+
+	servers := []*dns.Server{
+		{Addr: s.Address, Net: "udp", Handler: handler},
+		{Addr: s.Address, Net: "tcp", Handler: handler},
+	}
+
+	group, ctx := errgroup.WithContext(ctx)
+	for _, server := range servers {
+		group.Go(server.ListenAndServe)
+	}
+
+	group.Go(func() error {
+		<-ctx.Done()
+		for _, server := range servers {
+			_ = server.Shutdown()
+		}
+		return nil
+	})
+
+	logger.Info("Serving the records of local DNS providers", "address", s.Address)
+	return group.Wait()
+}
+
+func (s *DNSRecordServer) Answer(ctx context.Context, r *dns.Msg) (*dns.Msg, error) {
+	response := new(dns.Msg)
+	response.SetReply(r)
+	response.Authoritative = true
+
+	if len(r.Question) != 1 {
+		response.Rcode = dns.RcodeFormatError
+		return response, nil
+	}
+
+	records, err := s.localRecords(ctx)
+	if err != nil {
+		response.Rcode = dns.RcodeServerFailure
+		return response, err
+	}
+
+	response.Rcode, response.Answer, err = providers.LocalAnswer(records, r.Question[0])
+	if response.Rcode == dns.RcodeNameError && !s.serves(records, r.Question[0].Name) {
+		// Names outside of the zones aren't answered so resolvers don't cache them as non-existent.
+		response.Authoritative = false
+		response.Rcode = dns.RcodeRefused
+	}
+
+	return response, err
+}
+
+// Returns the records that are created and managed by a local provider.
+func (s *DNSRecordServer) localRecords(ctx context.Context) ([]sequencer.DNSRecord, error) {
+	var list sequencer.DNSRecordList
+	if err := s.Records.List(ctx, &list); err != nil {
+		return nil, fmt.Errorf("E#5002: failed to retrieve the list of DNS Records -- %w", err)
+	}
+
+	local := map[string]bool{}
+	var records []sequencer.DNSRecord
+	for _, record := range list.Items {
+		if record.IsTerminating() || !conditions.IsStatusConditionPresentAndEqual(record.Status.Conditions, dnsrecords.ProviderCondition, conditions.ConditionCreated) {
+			continue
+		}
+
+		isLocal, ok := local[record.Status.Provider]
+		if !ok {
+			provider, err := s.Records.providerFor(ctx, record.Status.Provider)
+			if err != nil && !errors.Is(err, errDNSProviderNotFound) {
+				return nil, err
+			}
+			isLocal = provider != nil && providers.IsLocal(provider)
+			local[record.Status.Provider] = isLocal
+		}
+
+		if isLocal {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+// Returns true if the name is in one of the zones of the records. Records created by the certificate
+// solver don't have a zone, their name is used instead.
+func (s *DNSRecordServer) serves(records []sequencer.DNSRecord, name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	for _, record := range records {
+		zone := strings.ToLower(strings.TrimSuffix(record.Spec.Zone, "."))
+		if zone == "" {
+			zone = strings.ToLower(strings.TrimPrefix(strings.TrimSuffix(record.Spec.Name, "."), "*."))
+		}
+
+		if name == zone || strings.HasSuffix(name, "."+zone) {
+			return true
+		}
+	}
+
+	return false
+}
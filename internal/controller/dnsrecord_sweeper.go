@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/pkg/providers"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+const (
+	kOrphanActionDeleted = "deleted"
+	kOrphanActionFailed  = "failed"
+	kOrphanActionDryRun  = "dry-run"
+)
+
+// DNSRecordSweeper periodically removes the records that Sequencer created upstream but that don't have
+// a DNSRecord anymore. This happens when a record is deleted without its provider being able to remove it,
+// eg. Cloudflare records that never had their RemoteID saved.
+type DNSRecordSweeper struct {
+	// The reconciler is used to retrieve the providers so both share the same clients.
+	Records *DNSRecordReconciler
+
+	Interval time.Duration
+
+	// Only report the orphaned records, through logs, events and metrics, without deleting them.
+	DryRun bool
+}
+
+// Only one controller should sweep the records at a time.
+func (s *DNSRecordSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+func (s *DNSRecordSweeper) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("dnsrecord-sweeper")
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Sweep(log.IntoContext(ctx, logger)); err != nil {
+				logger.Error(err, "Couldn't sweep orphaned DNS records")
+			}
+		}
+	}
+}
+
+func (s *DNSRecordSweeper) Sweep(ctx context.Context) error {
+	var list sequencer.DNSRecordList
+	if err := s.Records.List(ctx, &list); err != nil {
+		return fmt.Errorf("E#5002: failed to retrieve the list of DNS Records -- %w", err)
+	}
+
+	// Records are matched by name only. The type can differ upstream, eg. a CNAME that
+	// became an alias in Route53, and keeping a record that might be used is the safer choice.
+	names := map[string]bool{}
+	for _, record := range list.Items {
+		names[recordName(record.Spec.Name)] = true
+	}
+
+	all, err := s.Records.allProviders(ctx)
+	if err != nil {
+		return err
+	}
+
+	for name, provider := range all {
+		sweeper, ok := provider.(providers.Sweeper)
+		if !ok {
+			continue
+		}
+
+		if err := s.sweep(ctx, name, sweeper, names); err != nil {
+			log.FromContext(ctx).Error(err, "Couldn't sweep the provider", "provider", name)
+		}
+	}
+
+	return nil
+}
+
+func (s *DNSRecordSweeper) sweep(ctx context.Context, providerName string, sweeper providers.Sweeper, names map[string]bool) error {
+	logger := log.FromContext(ctx).WithValues("provider", providerName)
+
+	owned, err := sweeper.OwnedRecords(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range owned {
+		if names[recordName(record.Name)] {
+			continue
+		}
+
+		if s.DryRun {
+			logger.Info("Found an orphaned DNS record", "name", record.Name, "type", record.RecordType, "target", record.Target)
+			s.event(ctx, providerName, core.EventTypeNormal, "OrphanFound", fmt.Sprintf("Orphaned %s record %s would be deleted (dry-run)", record.RecordType, record.Name))
+			orphanedRecordsCounter.WithLabelValues(providerName, kOrphanActionDryRun).Inc()
+			continue
+		}
+
+		if err := sweeper.DeleteOwnedRecord(ctx, record); err != nil {
+			logger.Error(err, "Couldn't delete an orphaned DNS record", "name", record.Name, "type", record.RecordType)
+			s.event(ctx, providerName, core.EventTypeWarning, "OrphanDeletionFailed", fmt.Sprintf("Orphaned %s record %s couldn't be deleted -- %s", record.RecordType, record.Name, err))
+			orphanedRecordsCounter.WithLabelValues(providerName, kOrphanActionFailed).Inc()
+			continue
+		}
+
+		logger.Info("Deleted an orphaned DNS record", "name", record.Name, "type", record.RecordType, "target", record.Target)
+		s.event(ctx, providerName, core.EventTypeNormal, "OrphanDeleted", fmt.Sprintf("Orphaned %s record %s was deleted", record.RecordType, record.Name))
+		orphanedRecordsCounter.WithLabelValues(providerName, kOrphanActionDeleted).Inc()
+	}
+
+	return nil
+}
+
+// Events are recorded on the DNSProvider. The default provider doesn't have an object
+// to attach the events to, the logs and the metrics are used instead.
+func (s *DNSRecordSweeper) event(ctx context.Context, providerName, eventType, reason, message string) {
+	if providerName == kDefaultDNSProviderName {
+		return
+	}
+
+	var dnsProvider sequencer.DNSProvider
+	if err := s.Records.Get(ctx, types.NamespacedName{Name: providerName}, &dnsProvider); err != nil {
+		return
+	}
+
+	s.Records.Event(&dnsProvider, eventType, reason, message)
+}
+
+func recordName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
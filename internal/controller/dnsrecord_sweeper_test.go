@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"context"
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/pkg/providers"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var _ = Describe("DNSRecord sweeper", func() {
+	ctx := context.Background()
+
+	var provider *fakeSweepingDNSProvider
+	var sweeper *DNSRecordSweeper
+
+	BeforeEach(func() {
+		dnsRecord := &sequencer.DNSRecord{}
+		dnsRecord.Name = "my-workspace-abcd"
+		dnsRecord.Namespace = "default"
+		dnsRecord.Spec = sequencer.DNSRecordSpec{Zone: "example.com", RecordType: "A", Name: "my-workspace.example.com", Target: "203.0.113.10"}
+
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(sequencer.AddToScheme(scheme)).To(Succeed())
+
+		provider = &fakeSweepingDNSProvider{owned: []providers.OwnedRecord{
+			{RecordType: "A", Name: "My-Workspace.example.com.", Target: "203.0.113.10"},
+			{RecordType: "A", Name: "old-workspace.example.com", Target: "203.0.113.11"},
+		}}
+		sweeper = &DNSRecordSweeper{Records: &DNSRecordReconciler{
+			DefaultProvider: provider,
+			EventRecorder:   record.NewFakeRecorder(20),
+			Client: fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(dnsRecord).
+				Build(),
+		}}
+	})
+
+	// The counter is shared by every test, only what a sweep adds to it is compared.
+	orphans := func(action string) float64 {
+		return testutil.ToFloat64(orphanedRecordsCounter.WithLabelValues(kDefaultDNSProviderName, action))
+	}
+
+	It("deletes the records that don't have a DNSRecord", func() {
+		deleted := orphans(kOrphanActionDeleted)
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(provider.deleted).To(Equal([]string{"old-workspace.example.com"}))
+		Expect(orphans(kOrphanActionDeleted) - deleted).To(Equal(1.0))
+	})
+
+	It("keeps the records that have a DNSRecord", func() {
+		provider.owned = provider.owned[:1]
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(provider.deleted).To(BeEmpty())
+	})
+
+	It("only reports the orphaned records during a dry run", func() {
+		sweeper.DryRun = true
+		dryRun := orphans(kOrphanActionDryRun)
+		deleted := orphans(kOrphanActionDeleted)
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(provider.deleted).To(BeEmpty())
+		Expect(orphans(kOrphanActionDryRun) - dryRun).To(Equal(1.0))
+		Expect(orphans(kOrphanActionDeleted) - deleted).To(BeZero())
+	})
+
+	It("counts the records that couldn't be deleted", func() {
+		provider.deleteErr = errors.New("unavailable")
+		failed := orphans(kOrphanActionFailed)
+
+		Expect(sweeper.Sweep(ctx)).To(Succeed())
+		Expect(provider.deleted).To(BeEmpty())
+		Expect(orphans(kOrphanActionFailed) - failed).To(Equal(1.0))
+	})
+})
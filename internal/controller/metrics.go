@@ -23,8 +23,18 @@ var (
 			Help:      "Number of builds that failed",
 		},
 	)
+
+	orphanedRecordsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "sequencer",
+			Subsystem: "dns",
+			Name:      "orphaned_records",
+			Help:      "Number of orphaned DNS records found upstream, by provider and by what was done with them (deleted, failed, dry-run)",
+		},
+		[]string{"provider", "action"},
+	)
 )
 
 func init() {
-	metrics.Registry.MustRegister(buildSuccessCounter, buildErrorCounter)
+	metrics.Registry.MustRegister(buildSuccessCounter, buildErrorCounter, orphanedRecordsCounter)
 }
@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"time"
 
+	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -35,10 +37,15 @@ import (
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"github.com/pier-oliviert/sequencer/internal/tunneling"
 
 	tasks "github.com/pier-oliviert/sequencer/internal/tasks/workspaces"
 )
 
+const (
+	kTemplateRefField = ".spec.template"
+)
+
 // WorkspaceReconciler reconciles a Workspace object
 type WorkspaceReconciler struct {
 	client.Client
@@ -52,7 +59,12 @@ type WorkspaceReconciler struct {
 //+kubebuilder:rbac:groups=se.quencer.io,resources=workspaces/finalizers,verbs=update
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses,verbs=get;watch;list;create;delete
-//+kubebuilder:rbac:groups="se.quencer.io",resources=dnsrecords,verbs=watch;get;list;create;delete
+//+kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=gateways,verbs=get;watch;list
+//+kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=httproutes,verbs=get;watch;list;create;update;delete
+//+kubebuilder:rbac:groups="se.quencer.io",resources=dnsrecords,verbs=watch;get;list;create;update;delete
+//+kubebuilder:rbac:groups="se.quencer.io",resources=components,verbs=watch;get;list;create;update;delete
+//+kubebuilder:rbac:groups="se.quencer.io",resources=workspacetemplates,verbs=watch;get;list
+//+kubebuilder:rbac:groups="apps",resources=deployments,verbs=get;watch;list;create;patch;delete
 
 func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	var workspace sequencer.Workspace
@@ -75,8 +87,41 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, r.Status().Update(ctx, &workspace)
 	}
 
+	expiry := &tasks.ExpiryReconciler{
+		Client:        r.Client,
+		EventRecorder: r.EventRecorder,
+	}
+
+	// Expiry is evaluated before the error phase as a workspace that errored still
+	// needs to be deleted when it expires.
+	if workspace.Status.Phase != workspaces.PhaseTerminating {
+		if result, err := expiry.Reconcile(ctx, &workspace); err != nil {
+			return r.workspaceFailed(ctx, ctrl.Result{}, &workspace, fmt.Errorf("Expiry->%w", err))
+		} else if result != nil {
+			return *result, nil
+		}
+	}
+
 	if workspace.Status.Phase == workspaces.PhaseError {
-		return ctrl.Result{}, nil
+		if result, err := (&tasks.ComponentsReconciler{
+			Client:        r.Client,
+			EventRecorder: r.EventRecorder,
+		}).Redeploy(ctx, &workspace); err != nil {
+			return ctrl.Result{}, err
+		} else if result != nil {
+			return *result, nil
+		}
+
+		return ctrl.Result{RequeueAfter: expiry.RequeueAfter(&workspace)}, nil
+	}
+
+	if result, err := (&tasks.TemplateReconciler{
+		Client:        r.Client,
+		EventRecorder: r.EventRecorder,
+	}).Reconcile(ctx, &workspace); err != nil {
+		return r.workspaceFailed(ctx, ctrl.Result{}, &workspace, fmt.Errorf("Template->%w", err))
+	} else if result != nil {
+		return *result, nil
 	}
 
 	if result, err := (&tasks.TunnelingReconciler{
@@ -97,6 +142,15 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return *result, r.Status().Update(ctx, &workspace)
 	}
 
+	if result, err := (&tasks.GatewayReconciler{
+		Client:        r.Client,
+		EventRecorder: r.EventRecorder,
+	}).Reconcile(ctx, &workspace); err != nil {
+		return r.workspaceFailed(ctx, ctrl.Result{}, &workspace, fmt.Errorf("Gateway->%w", err))
+	} else if result != nil {
+		return *result, r.Status().Update(ctx, &workspace)
+	}
+
 	if result, err := (&tasks.DNSReconciler{
 		Client:        r.Client,
 		EventRecorder: r.EventRecorder,
@@ -115,11 +169,33 @@ func (r *WorkspaceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return *result, r.Status().Update(ctx, &workspace)
 	}
 
-	return ctrl.Result{}, nil
+	if result, err := (&tasks.SleepReconciler{
+		Client:        r.Client,
+		EventRecorder: r.EventRecorder,
+	}).Reconcile(ctx, &workspace); err != nil {
+		return r.workspaceFailed(ctx, ctrl.Result{}, &workspace, fmt.Errorf("Sleep->%w", err))
+	} else if result != nil {
+		return *result, nil
+	}
+
+	return ctrl.Result{RequeueAfter: expiry.RequeueAfter(&workspace)}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &sequencer.Workspace{}, kTemplateRefField, func(rawObj client.Object) []string {
+		workspace := rawObj.(*sequencer.Workspace)
+		if workspace.Spec.Template == nil {
+			return nil
+		}
+
+		return []string{tasks.TemplateRef(workspace).String()}
+	})
+
+	if err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&sequencer.Workspace{}).
 		Watches(
@@ -132,9 +208,48 @@ func (r *WorkspaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			handler.EnqueueRequestsFromMapFunc(r.handleFuncForLinkedResource),
 			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
+		Watches(
+			&apps.Deployment{},
+			handler.EnqueueRequestsFromMapFunc(r.handleFuncForLinkedResource),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}, connectorPredicate),
+		).
+		Watches(
+			&sequencer.WorkspaceTemplate{},
+			handler.EnqueueRequestsFromMapFunc(r.handleFuncForTemplate),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
 		Complete(r)
 }
 
+// Only the deployments running the connector of a tunnel are relevant to workspaces.
+var connectorPredicate = predicate.NewPredicateFuncs(func(object client.Object) bool {
+	_, ok := object.GetLabels()[tunneling.ConnectorLabel]
+	return ok
+})
+
+func (r *WorkspaceReconciler) handleFuncForTemplate(ctx context.Context, template client.Object) []reconcile.Request {
+	list := &sequencer.WorkspaceList{}
+
+	err := r.List(ctx, list, &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(kTemplateRefField, client.ObjectKeyFromObject(template).String()),
+	})
+
+	if err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, item := range list.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Name:      item.GetName(),
+				Namespace: item.GetNamespace(),
+			},
+		}
+	}
+	return requests
+}
+
 func (r *WorkspaceReconciler) handleFuncForLinkedResource(ctx context.Context, obj client.Object) []reconcile.Request {
 	requests := []reconcile.Request{}
 
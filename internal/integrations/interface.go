@@ -44,6 +44,7 @@ type ProviderController interface {
 
 	Create(context.Context, client.Object, ...client.CreateOption) error
 	Delete(context.Context, client.Object, ...client.DeleteOption) error
+	Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error
 
 	SetFinalizer(ctx context.Context, finalizer string) error
 	RemoveFinalizer(ctx context.Context, finalizer string) error
@@ -89,7 +90,7 @@ func (c *controller) Workspace() *sequencer.Workspace {
 	return c.workspace
 }
 
-func (c *controller) reate(ctx context.Context, o client.Object, opts ...client.CreateOption) error {
+func (c *controller) Create(ctx context.Context, o client.Object, opts ...client.CreateOption) error {
 	o.SetOwnerReferences([]meta.OwnerReference{{
 		APIVersion: c.workspace.APIVersion,
 		Name:       c.workspace.Name,
@@ -0,0 +1,128 @@
+package components
+
+import (
+	"context"
+	"fmt"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Creates, or updates, a ConfigMap for each of the ConfigMaps defined in the component's spec. The variables
+// in their data are replaced with the values stored in the component's status.
+func reconcileConfigMaps(ctx context.Context, c client.Client, component *sequencer.Component) error {
+	values := map[string]string{}
+	for _, v := range component.Status.Variables {
+		values[v.Name] = v.Value
+	}
+
+	labels := map[string]string{
+		components.NameLabel:     component.Spec.Name,
+		components.InstanceLabel: component.Name,
+	}
+
+	if label, ok := component.Labels[workspaces.InstanceLabel]; ok {
+		labels[workspaces.InstanceLabel] = label
+	}
+
+	for i, spec := range component.Spec.ConfigMaps {
+		data := map[string]string{}
+		for key, value := range spec.Data {
+			path := field.NewPath("spec", "configMaps").Index(i).Child("data").Key(key)
+			for _, variable := range components.Variables(value) {
+				if components.IsReference(variable) {
+					return fmt.Errorf("%s: E#2020: Secrets and ConfigMaps can't be used in the data of a ConfigMap", path)
+				}
+			}
+
+			interpolated, err := components.Interpolate(value, values)
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			data[key] = interpolated
+		}
+
+		configMap := &core.ConfigMap{
+			ObjectMeta: meta.ObjectMeta{
+				Name:      configMapName(component, spec.Name),
+				Namespace: component.Namespace,
+			},
+		}
+
+		_, err := controllerutil.CreateOrUpdate(ctx, c, configMap, func() error {
+			configMap.Labels = labels
+			configMap.OwnerReferences = ownerReferences(component)
+			configMap.Data = data
+			return nil
+		})
+
+		if err != nil {
+			return fmt.Errorf("E#2018: Could not create the ConfigMap (%s) for component (%s) -- %w", spec.Name, component.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Points the references to the ConfigMaps of the component, in the volumes and the environment
+// of the pod spec, to the ConfigMaps that were created for the component.
+func assignConfigMaps(spec *core.PodSpec, component *sequencer.Component) {
+	if len(component.Spec.ConfigMaps) == 0 {
+		return
+	}
+
+	names := map[string]string{}
+	for _, cm := range component.Spec.ConfigMaps {
+		names[cm.Name] = configMapName(component, cm.Name)
+	}
+
+	rename := func(ref *core.LocalObjectReference) {
+		if name, ok := names[ref.Name]; ok {
+			ref.Name = name
+		}
+	}
+
+	for i := range spec.Volumes {
+		volume := &spec.Volumes[i]
+		if volume.ConfigMap != nil {
+			rename(&volume.ConfigMap.LocalObjectReference)
+		}
+
+		if volume.Projected != nil {
+			for j := range volume.Projected.Sources {
+				if source := volume.Projected.Sources[j].ConfigMap; source != nil {
+					rename(&source.LocalObjectReference)
+				}
+			}
+		}
+	}
+
+	containers := []*[]core.Container{&spec.InitContainers, &spec.Containers}
+	for _, list := range containers {
+		for i := range *list {
+			container := &(*list)[i]
+			for j := range container.EnvFrom {
+				if ref := container.EnvFrom[j].ConfigMapRef; ref != nil {
+					rename(&ref.LocalObjectReference)
+				}
+			}
+
+			for j := range container.Env {
+				if from := container.Env[j].ValueFrom; from != nil && from.ConfigMapKeyRef != nil {
+					rename(&from.ConfigMapKeyRef.LocalObjectReference)
+				}
+			}
+		}
+	}
+}
+
+func configMapName(component *sequencer.Component, name string) string {
+	return fmt.Sprintf("%s-%s", component.Name, name)
+}
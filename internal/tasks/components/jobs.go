@@ -0,0 +1,157 @@
+package components
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+type JobsReconciler struct {
+	client.Client
+	record.EventRecorder
+}
+
+// Run each of the jobs of the component, one after the other, in the order they are specified. The
+// Jobs condition is completed once all of them succeeded, which is when the pod of the component can be deployed.
+func (r *JobsReconciler) Reconcile(ctx context.Context, component *sequencer.Component) (*ctrl.Result, error) {
+	condition := conditions.FindCondition(component.Status.Conditions, components.JobsCondition)
+	if condition != nil && (condition.Status == conditions.ConditionCompleted || condition.Status == conditions.ConditionError) {
+		return nil, nil
+	}
+
+	// Other components can depend on the jobs of this one, the condition needs to complete
+	// even when there's nothing to run.
+	if len(component.Spec.Jobs) == 0 {
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.JobsCondition,
+			Status: conditions.ConditionCompleted,
+			Reason: "No jobs",
+		})
+
+		return &ctrl.Result{}, r.Status().Update(ctx, component)
+	}
+
+	for _, spec := range component.Spec.Jobs {
+		var job batch.Job
+		err := r.Get(ctx, client.ObjectKey{Namespace: component.Namespace, Name: jobName(component, spec)}, &job)
+		if k8sErrors.IsNotFound(err) {
+			return r.createJob(ctx, component, spec)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("E#5001: Could not retrieve the job (%s) -- %w", spec.Name, err)
+		}
+
+		// A job from a previous spec is still being removed.
+		if !job.DeletionTimestamp.IsZero() {
+			return &ctrl.Result{RequeueAfter: time.Second}, nil
+		}
+
+		if jobConditionIsTrue(&job, batch.JobFailed) {
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.JobsCondition,
+				Status: conditions.ConditionError,
+				Reason: fmt.Sprintf("Job (%s) failed", spec.Name),
+			})
+
+			return nil, fmt.Errorf("E#2017: Job (%s) failed for component (%s), the logs of the job's pods should have more information", spec.Name, component.Name)
+		}
+
+		if !jobConditionIsTrue(&job, batch.JobComplete) {
+			// The job is still running, the component will be reconciled when the job changes.
+			return &ctrl.Result{}, nil
+		}
+	}
+
+	r.Event(component, core.EventTypeNormal, string(components.JobsCondition), "All jobs completed")
+	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+		Type:   components.JobsCondition,
+		Status: conditions.ConditionCompleted,
+		Reason: "All jobs completed",
+	})
+
+	return &ctrl.Result{}, r.Status().Update(ctx, component)
+}
+
+func (r *JobsReconciler) createJob(ctx context.Context, component *sequencer.Component, spec sequencer.JobSpec) (*ctrl.Result, error) {
+	// Pods for the job can't use the component's instance label as the services for the
+	// component would send traffic their way.
+	podLabels := map[string]string{
+		components.JobLabel: spec.Name,
+	}
+
+	if label, ok := component.Labels[workspaces.InstanceLabel]; ok {
+		podLabels[workspaces.InstanceLabel] = label
+	}
+
+	job := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      jobName(component, spec),
+			Namespace: component.Namespace,
+			Labels: map[string]string{
+				components.NameLabel:     component.Spec.Name,
+				components.InstanceLabel: component.Name,
+				components.JobLabel:      spec.Name,
+			},
+			OwnerReferences: ownerReferences(component),
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit: spec.BackoffLimit,
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{
+					Labels: podLabels,
+				},
+				Spec: *spec.Pod.DeepCopy(),
+			},
+		},
+	}
+
+	if job.Spec.Template.Spec.RestartPolicy != core.RestartPolicyOnFailure {
+		job.Spec.Template.Spec.RestartPolicy = core.RestartPolicyNever
+	}
+
+	pr := PodReconciler{Client: r.Client, EventRecorder: r.EventRecorder}
+	if err := pr.assignInterpolatedVariables(&job.Spec.Template.Spec, field.NewPath("spec", "jobs").Key(spec.Name).Child("template"), component); err != nil {
+		return nil, err
+	}
+
+	if err := r.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	r.Eventf(component, core.EventTypeNormal, string(components.JobsCondition), "Running job (%s)", spec.Name)
+	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+		Type:   components.JobsCondition,
+		Status: conditions.ConditionInProgress,
+		Reason: fmt.Sprintf("Running job (%s)", spec.Name),
+	})
+	component.Status.Phase = components.PhaseDeploying
+
+	return &ctrl.Result{}, r.Status().Update(ctx, component)
+}
+
+func jobName(component *sequencer.Component, spec sequencer.JobSpec) string {
+	return fmt.Sprintf("%s-%s", component.Name, spec.Name)
+}
+
+func jobConditionIsTrue(job *batch.Job, t batch.JobConditionType) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == t && c.Status == core.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package components
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Jobs", func() {
+	ctx := context.Background()
+
+	var component *sequencer.Component
+	var reconciler *JobsReconciler
+
+	BeforeEach(func() {
+		component = &sequencer.Component{}
+		component.Name = "web"
+		component.Namespace = "default"
+		component.Status.Default()
+
+		pod := core.PodSpec{Containers: []core.Container{{Name: "migrate", Image: "app:latest"}}}
+		component.Spec.Jobs = []sequencer.JobSpec{{Name: "migrate", Pod: pod}, {Name: "seed", Pod: pod}}
+	})
+
+	JustBeforeEach(func() {
+		reconciler = &JobsReconciler{Client: newClient(component), EventRecorder: record.NewFakeRecorder(10)}
+	})
+
+	finish := func(name string, t batch.JobConditionType) {
+		var job batch.Job
+		Expect(reconciler.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, &job)).To(Succeed())
+		job.Status.Conditions = append(job.Status.Conditions, batch.JobCondition{Type: t, Status: core.ConditionTrue})
+		Expect(reconciler.Status().Update(ctx, &job)).To(Succeed())
+	}
+
+	jobsCondition := func() *conditions.Condition {
+		return conditions.FindCondition(component.Status.Conditions, components.JobsCondition)
+	}
+
+	Context("without jobs", func() {
+		BeforeEach(func() {
+			component.Spec.Jobs = nil
+		})
+
+		It("completes the condition so other components can depend on it", func() {
+			result, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+			Expect(jobsCondition().Status).To(Equal(conditions.ConditionCompleted))
+			Expect(jobsCondition().Reason).To(Equal("No jobs"))
+
+			result, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+		})
+	})
+
+	It("runs the jobs one after the other", func() {
+		_, err := reconciler.Reconcile(ctx, component)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobsCondition().Status).To(Equal(conditions.ConditionInProgress))
+		Expect(component.Status.Phase).To(Equal(components.PhaseDeploying))
+
+		var jobs batch.JobList
+		Expect(reconciler.List(ctx, &jobs)).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+		Expect(jobs.Items[0].Name).To(Equal("web-migrate"))
+		Expect(jobs.Items[0].Spec.Template.Spec.RestartPolicy).To(Equal(core.RestartPolicyNever))
+
+		// Still running
+		result, err := reconciler.Reconcile(ctx, component)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).NotTo(BeNil())
+		Expect(reconciler.List(ctx, &jobs)).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+
+		finish("web-migrate", batch.JobComplete)
+		_, err = reconciler.Reconcile(ctx, component)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobsCondition().Reason).To(Equal("Running job (seed)"))
+
+		finish("web-seed", batch.JobComplete)
+		_, err = reconciler.Reconcile(ctx, component)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(jobsCondition().Status).To(Equal(conditions.ConditionCompleted))
+		Expect(jobsCondition().Reason).To(Equal("All jobs completed"))
+	})
+
+	It("stops at the first job that fails", func() {
+		_, err := reconciler.Reconcile(ctx, component)
+		Expect(err).NotTo(HaveOccurred())
+
+		finish("web-migrate", batch.JobFailed)
+		_, err = reconciler.Reconcile(ctx, component)
+		Expect(err).To(MatchError(ContainSubstring("E#2017")))
+		Expect(jobsCondition().Status).To(Equal(conditions.ConditionError))
+
+		var jobs batch.JobList
+		Expect(reconciler.List(ctx, &jobs)).To(Succeed())
+		Expect(jobs.Items).To(HaveLen(1))
+	})
+})
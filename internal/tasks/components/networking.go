@@ -9,6 +9,7 @@ import (
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -44,7 +45,36 @@ func (n *NetworkReconciler) Reconcile(ctx context.Context, component *sequencer.
 		return nil, err
 	}
 
+	// A component that was redeployed still has the services from its previous spec. Those
+	// services are reused so that their names, and the hostnames derived from them, don't change.
+	var existing core.ServiceList
+	err := n.List(ctx, &existing, client.InNamespace(component.Namespace), client.MatchingLabels{
+		components.InstanceLabel: component.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("E#5002: failure to retrieve a list of services for component (%s) -- %w", component.Name, err)
+	}
+
+	services := map[string]*core.Service{}
+	for i := range existing.Items {
+		svc := &existing.Items[i]
+		services[svc.Labels[components.NetworkLabel]] = svc
+	}
+
 	for _, ns := range component.Spec.Networks {
+		if svc, ok := services[ns.Name]; ok {
+			delete(services, ns.Name)
+			if len(svc.Spec.Ports) == 1 && equality.Semantic.DeepEqual(svc.Spec.Ports[0], ns.ServicePort) {
+				continue
+			}
+
+			svc.Spec.Ports = []core.ServicePort{ns.ServicePort}
+			if err := n.Client.Update(ctx, svc); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
 		svc := &core.Service{
 			ObjectMeta: meta.ObjectMeta{
 				GenerateName: fmt.Sprintf("%s-", component.Spec.Name),
@@ -78,6 +108,13 @@ func (n *NetworkReconciler) Reconcile(ctx context.Context, component *sequencer.
 		}
 	}
 
+	// Whatever is left are services for networks that were removed from the spec.
+	for _, svc := range services {
+		if err := n.Client.Delete(ctx, svc); client.IgnoreNotFound(err) != nil {
+			return nil, err
+		}
+	}
+
 	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
 		Type:   components.NetworkCondition,
 		Status: conditions.ConditionCompleted,
@@ -3,7 +3,7 @@ package components
 import (
 	"context"
 	"fmt"
-	"strings"
+	"time"
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
@@ -12,6 +12,7 @@ import (
 	core "k8s.io/api/core/v1"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -32,20 +33,30 @@ func (p *PodReconciler) Reconcile(ctx context.Context, component *sequencer.Comp
 		}
 	}
 
-	if condition.Status == conditions.ConditionHealthy {
-		err := p.monitorPod(ctx, component)
+	if component.Spec.Workload.Managed() {
+		return p.reconcileWorkload(ctx, component, condition)
+	}
+
+	if condition.Status == conditions.ConditionHealthy || condition.Status == conditions.ConditionNotHealthy || isStarting(condition) {
+		ready, err := p.monitorPod(ctx, component)
 		if err != nil {
 			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
 				Type:   components.PodCondition,
 				Status: conditions.ConditionError,
 				Reason: "Pod became unhealthy",
 			})
+			return nil, err
 		}
-		return nil, err
+
+		return p.updateReadiness(ctx, component, condition, ready)
 	}
 
+	// A component that wakes up gets its pod deployed again. The variables were already
+	// resolved so the pod will be the same as the one before the component went to sleep.
+	wakingUp := condition.Status == conditions.ConditionTerminated && condition.Reason == components.ConditionReasonSleeping
+
 	// Do nothing more if the condition is not unknown
-	if condition.Status != conditions.ConditionUnknown {
+	if condition.Status != conditions.ConditionUnknown && !wakingUp {
 		return nil, nil
 	}
 
@@ -69,11 +80,91 @@ func (p *PodReconciler) Reconcile(ctx context.Context, component *sequencer.Comp
 		return nil, err
 	}
 
-	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
-		Type:   components.PodCondition,
-		Status: conditions.ConditionHealthy,
-		Reason: components.ConditionReasonCompleted,
-	})
+	return &ctrl.Result{}, nil
+}
+
+// Move the Pod condition according to the readiness of the pods. A component only becomes healthy
+// once its pods are ready, which means dependents waiting on the component's Pod condition are only deployed when
+// the component can actually serve requests. If the pods never become ready, the component errors after its startup timeout.
+func (p *PodReconciler) updateReadiness(ctx context.Context, component *sequencer.Component, condition *conditions.Condition, ready bool) (*ctrl.Result, error) {
+	switch {
+	case ready && condition.Status != conditions.ConditionHealthy:
+		p.Event(component, core.EventTypeNormal, string(components.PodCondition), "Component is ready")
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.PodCondition,
+			Status: conditions.ConditionHealthy,
+			Reason: components.ConditionReasonReady,
+		})
+		component.Status.Phase = components.PhaseHealthy
+
+		return &ctrl.Result{}, p.Status().Update(ctx, component)
+
+	case !ready && condition.Status == conditions.ConditionHealthy:
+		p.Event(component, core.EventTypeWarning, string(components.PodCondition), "Component is not ready")
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.PodCondition,
+			Status: conditions.ConditionNotHealthy,
+			Reason: components.ConditionReasonNotReady,
+		})
+		component.Status.Phase = components.PhaseDeploying
+
+		return &ctrl.Result{}, p.Status().Update(ctx, component)
+
+	case !ready && isStarting(condition) && component.Spec.StartupTimeout != nil:
+		timeout := component.Spec.StartupTimeout.Duration
+		remaining := time.Until(condition.LastTransitionTime.Add(timeout))
+		if remaining <= 0 {
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.PodCondition,
+				Status: conditions.ConditionError,
+				Reason: "Startup timeout exceeded",
+			})
+			return nil, fmt.Errorf("E#2016: Component (%s) did not become ready within its startup timeout (%s)", component.Name, timeout)
+		}
+
+		// Pods that stay unready don't trigger any event, the timeout needs to be checked again once it expires.
+		return &ctrl.Result{RequeueAfter: remaining + time.Second}, nil
+	}
+
+	return nil, nil
+}
+
+// A component is starting while its pods are deployed but not yet ready for the first time.
+func isStarting(condition *conditions.Condition) bool {
+	return condition.Status == conditions.ConditionInProgress && (condition.Reason == components.ConditionReasonStarting || condition.Reason == components.ConditionReasonRollingOut)
+}
+
+// Sleep removes the pod for the component. Everything else stays in place so that
+// the component can be woken up later on.
+func (p *PodReconciler) Sleep(ctx context.Context, component *sequencer.Component) (*ctrl.Result, error) {
+	if component.Status.Phase == components.PhaseSleeping {
+		return nil, nil
+	}
+
+	if err := p.removeWorkloads(ctx, component, ""); err != nil {
+		return nil, err
+	}
+
+	if conditions.FindCondition(component.Status.Conditions, components.PodCondition) != nil {
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.PodCondition,
+			Status: conditions.ConditionTerminated,
+			Reason: components.ConditionReasonSleeping,
+		})
+	}
+
+	// Dependencies needs to be met again when the component wakes up as the pods for the
+	// other components are going to be deployed at the same time.
+	if conditions.IsStatusConditionPresentAndEqual(component.Status.Conditions, components.DependenciesCondition, conditions.ConditionCompleted) {
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.DependenciesCondition,
+			Status: conditions.ConditionWaiting,
+			Reason: components.ConditionReasonSleeping,
+		})
+	}
+
+	p.Event(component, core.EventTypeNormal, string(components.PodCondition), "Component is going to sleep")
+	component.Status.Phase = components.PhaseSleeping
 
 	return &ctrl.Result{}, p.Status().Update(ctx, component)
 }
@@ -96,7 +187,7 @@ func (p *PodReconciler) deployPod(ctx context.Context, component *sequencer.Comp
 				},
 			},
 		},
-		Spec: component.Spec.Pod,
+		Spec: *component.Spec.Pod.DeepCopy(),
 	}
 
 	if label, ok := component.Labels[workspaces.InstanceLabel]; ok {
@@ -104,7 +195,12 @@ func (p *PodReconciler) deployPod(ctx context.Context, component *sequencer.Comp
 	}
 
 	pod.Spec.RestartPolicy = core.RestartPolicyNever
-	if err := p.assignInterpolatedVariables(&pod.Spec, component); err != nil {
+	if err := p.assignInterpolatedVariables(&pod.Spec, field.NewPath("spec", "template"), component); err != nil {
+		return err
+	}
+
+	// The component might have been running through a Deployment or a StatefulSet before.
+	if err := p.removeWorkloads(ctx, component, components.WorkloadPod); err != nil {
 		return err
 	}
 
@@ -113,62 +209,56 @@ func (p *PodReconciler) deployPod(ctx context.Context, component *sequencer.Comp
 		return err
 	}
 
-	// Pod is created and as far as this reconciler loop is concerned, we're done.
+	// The pod is created, but the component only becomes healthy once the pod is ready.
 	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
 		Type:   components.PodCondition,
-		Status: conditions.ConditionHealthy,
-		Reason: "Pod is deployed",
+		Status: conditions.ConditionInProgress,
+		Reason: components.ConditionReasonStarting,
 	})
 
-	component.Status.Phase = components.PhaseHealthy
+	component.Status.Phase = components.PhaseDeploying
 
 	return p.Client.Status().Update(ctx, component)
 }
 
-func (p *PodReconciler) assignInterpolatedVariables(spec *core.PodSpec, component *sequencer.Component) error {
-	for i := range spec.Containers {
-		container := &spec.Containers[i]
-		if strings.HasPrefix(container.Image, components.InterpolationDelimStart) {
-			p.Eventf(component, "Normal", string(components.PhaseInitializing), "Container uses a build, replacing the image %s", container.Image)
-			found := false
-			for _, v := range component.Status.Variables {
-				if v.Name == container.Image {
-					container.Image = v.Value
-					found = true
-				}
-			}
+// Replaces the variables found in the pod spec with the values resolved by the VariablesReconciler, and points
+// the references to the component's ConfigMaps to the ones that were created for it. The path is used to
+// report which field has a variable that couldn't be interpolated.
+func (p *PodReconciler) assignInterpolatedVariables(spec *core.PodSpec, path *field.Path, component *sequencer.Component) error {
+	if err := components.AssignReferences(spec, path); err != nil {
+		return err
+	}
 
-			if !found {
-				return fmt.Errorf("E#2001: Could not find a variable to interpolate for %s", container.Image)
-			}
-		}
+	values := map[string]string{}
+	for _, v := range component.Status.Variables {
+		values[v.Name] = v.Value
+	}
 
-		for j := range container.Env {
-			env := &container.Env[j]
-			if strings.HasPrefix(env.Value, components.InterpolationDelimStart) {
-				found := false
-				for _, v := range component.Status.Variables {
-					if v.Name == env.Value {
-						env.Value = v.Value
-						found = true
-					}
-				}
-				if !found {
-					return fmt.Errorf("E#2001: Could not find a variable to interpolate for %s", env.Value)
-				}
-			}
+	err := components.WalkPodSpec(spec, path, func(p *field.Path, value *string) error {
+		interpolated, err := components.Interpolate(*value, values)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
 		}
+
+		*value = interpolated
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	assignConfigMaps(spec, component)
 	return nil
 }
 
-func (p *PodReconciler) monitorPod(ctx context.Context, component *sequencer.Component) error {
+// Returns true if the pod of the component is ready. An error is returned if the pod is missing or if
+// one of its containers failed, as a pod is never restarted.
+func (p *PodReconciler) monitorPod(ctx context.Context, component *sequencer.Component) (bool, error) {
 	list := &core.PodList{}
 
 	selector, err := labels.Parse(fmt.Sprintf("%s=%s", components.InstanceLabel, component.Name))
 	if err != nil {
-		return fmt.Errorf("E#3001: failed to parse the label selector -- %w", err)
+		return false, fmt.Errorf("E#3001: failed to parse the label selector -- %w", err)
 	}
 
 	err = p.List(ctx, list, &client.ListOptions{
@@ -177,26 +267,45 @@ func (p *PodReconciler) monitorPod(ctx context.Context, component *sequencer.Com
 	})
 
 	if err != nil {
-		return fmt.Errorf("E#5002: failure to retrieve a list of pods for component (%s) -- Label Selector: %s", component.Name, selector.String())
+		return false, fmt.Errorf("E#5002: failure to retrieve a list of pods for component (%s) -- Label Selector: %s", component.Name, selector.String())
 	}
 
-	if len(list.Items) != 1 {
-		if len(list.Items) == 0 {
-			return fmt.Errorf("E#2003: no pod exists for component (%s)", component.Name)
+	// Pods from a previous deployment of this component might still be shutting down, or
+	// be managed by a workload that is being removed.
+	var pods []core.Pod
+	for _, pod := range list.Items {
+		if pod.DeletionTimestamp.IsZero() && ownedBy(&pod, component) {
+			pods = append(pods, pod)
+		}
+	}
+
+	if len(pods) != 1 {
+		if len(pods) == 0 {
+			return false, fmt.Errorf("E#2003: no pod exists for component (%s)", component.Name)
 		}
 
-		return fmt.Errorf("E#2004: Expected to retrieve a single pod for component (%s), got %d. Label Selector: %s", component.Name, list.Size(), selector.String())
+		return false, fmt.Errorf("E#2004: Expected to retrieve a single pod for component (%s), got %d. Label Selector: %s", component.Name, len(pods), selector.String())
 	}
 
-	pod := list.Items[0]
+	pod := pods[0]
 	// Are pod still running?
 	for _, cs := range pod.Status.ContainerStatuses {
 		if cs.State.Terminated != nil {
 			if cs.State.Terminated.ExitCode != 0 {
-				return fmt.Errorf("E#2005: Pod (%s) had a failure in one of the container (%s) -- Reason: %s", pod.Name, cs.Name, cs.State.Terminated.Reason)
+				return false, fmt.Errorf("E#2005: Pod (%s) had a failure in one of the container (%s) -- Reason: %s", pod.Name, cs.Name, cs.State.Terminated.Reason)
 			}
 		}
 	}
 
-	return nil
+	if pod.Status.Phase == core.PodFailed {
+		return false, fmt.Errorf("E#2005: Pod (%s) failed -- Reason: %s", pod.Name, pod.Status.Reason)
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == core.PodReady {
+			return c.Status == core.ConditionTrue, nil
+		}
+	}
+
+	return false, nil
 }
@@ -0,0 +1,234 @@
+package components
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Returns a pod that belongs to the component, like the ones created by deployPod.
+func componentPod(component *sequencer.Component, name string) *core.Pod {
+	pod := &core.Pod{}
+	pod.Name = name
+	pod.Namespace = component.Namespace
+	pod.Labels = map[string]string{components.InstanceLabel: component.Name}
+	pod.OwnerReferences = []meta.OwnerReference{{Name: component.Name, UID: component.UID}}
+	return pod
+}
+
+var _ = Describe("Pod", func() {
+	ctx := context.Background()
+
+	var component *sequencer.Component
+	var pod *core.Pod
+	var reconciler *PodReconciler
+
+	BeforeEach(func() {
+		component = &sequencer.Component{}
+		component.Name = "web"
+		component.Namespace = "default"
+		component.UID = "web-uid"
+		component.Status.Default()
+		component.Spec.Pod = core.PodSpec{Containers: []core.Container{{Name: "web", Image: "app:latest"}}}
+
+		pod = componentPod(component, "component-web-abcd")
+	})
+
+	JustBeforeEach(func() {
+		reconciler = &PodReconciler{Client: newClient(component, pod), EventRecorder: record.NewFakeRecorder(10)}
+	})
+
+	podCondition := func() *conditions.Condition {
+		return conditions.FindCondition(component.Status.Conditions, components.PodCondition)
+	}
+
+	Context("Sleep", func() {
+		BeforeEach(func() {
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.PodCondition,
+				Status: conditions.ConditionHealthy,
+				Reason: components.ConditionReasonReady,
+			})
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.DependenciesCondition,
+				Status: conditions.ConditionCompleted,
+			})
+			component.Status.Phase = components.PhaseHealthy
+		})
+
+		It("removes the pod and keeps the component around", func() {
+			other := componentPod(&sequencer.Component{ObjectMeta: meta.ObjectMeta{Name: "web", Namespace: "default", UID: "other-uid"}}, "component-web-efgh")
+			Expect(reconciler.Create(ctx, other)).To(Succeed())
+
+			result, err := reconciler.Sleep(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+
+			var pods core.PodList
+			Expect(reconciler.List(ctx, &pods)).To(Succeed())
+			Expect(pods.Items).To(HaveLen(1))
+			Expect(pods.Items[0].Name).To(Equal(other.Name))
+
+			Expect(component.Status.Phase).To(Equal(components.PhaseSleeping))
+			Expect(podCondition().Status).To(Equal(conditions.ConditionTerminated))
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonSleeping))
+
+			var stored sequencer.Component
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(component), &stored)).To(Succeed())
+			Expect(stored.Status.Phase).To(Equal(components.PhaseSleeping))
+		})
+
+		It("waits for the dependencies again when the component wakes up", func() {
+			_, err := reconciler.Sleep(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			dependencies := conditions.FindCondition(component.Status.Conditions, components.DependenciesCondition)
+			Expect(dependencies.Status).To(Equal(conditions.ConditionWaiting))
+			Expect(dependencies.Reason).To(Equal(components.ConditionReasonSleeping))
+		})
+
+		It("doesn't touch the dependencies that weren't met", func() {
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.DependenciesCondition,
+				Status: conditions.ConditionWaiting,
+				Reason: components.ConditionReasonDependsOn,
+			})
+
+			_, err := reconciler.Sleep(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(conditions.FindCondition(component.Status.Conditions, components.DependenciesCondition).Reason).To(Equal(components.ConditionReasonDependsOn))
+		})
+
+		It("does nothing once the component is sleeping", func() {
+			_, err := reconciler.Sleep(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			result, err := reconciler.Sleep(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+		})
+
+		It("deploys the pod again when the component wakes up", func() {
+			_, err := reconciler.Sleep(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionInProgress))
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonStarting))
+			Expect(component.Status.Phase).To(Equal(components.PhaseDeploying))
+
+			var pods core.PodList
+			Expect(reconciler.List(ctx, &pods)).To(Succeed())
+			Expect(pods.Items).To(HaveLen(1))
+			Expect(pods.Items[0].Spec.RestartPolicy).To(Equal(core.RestartPolicyNever))
+		})
+	})
+
+	Context("Readiness", func() {
+		BeforeEach(func() {
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.PodCondition,
+				Status: conditions.ConditionInProgress,
+				Reason: components.ConditionReasonStarting,
+			})
+			component.Status.Phase = components.PhaseDeploying
+		})
+
+		ready := func(status core.ConditionStatus) {
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(pod), pod)).To(Succeed())
+			pod.Status.Conditions = []core.PodCondition{{Type: core.PodReady, Status: status}}
+			Expect(reconciler.Status().Update(ctx, pod)).To(Succeed())
+		}
+
+		It("stays in progress until the pod is ready", func() {
+			result, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonStarting))
+
+			ready(core.ConditionTrue)
+			result, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionHealthy))
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonReady))
+			Expect(component.Status.Phase).To(Equal(components.PhaseHealthy))
+		})
+
+		It("follows the readiness of the pod once it's healthy", func() {
+			ready(core.ConditionTrue)
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			ready(core.ConditionFalse)
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionNotHealthy))
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonNotReady))
+			Expect(component.Status.Phase).To(Equal(components.PhaseDeploying))
+
+			ready(core.ConditionTrue)
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionHealthy))
+		})
+
+		It("errors when the pod is missing", func() {
+			Expect(reconciler.Delete(ctx, pod)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).To(MatchError(ContainSubstring("E#2003")))
+			Expect(podCondition().Status).To(Equal(conditions.ConditionError))
+		})
+
+		Context("with a startup timeout", func() {
+			BeforeEach(func() {
+				component.Spec.StartupTimeout = &meta.Duration{Duration: time.Minute}
+			})
+
+			It("checks the timeout again once it expires", func() {
+				result, err := reconciler.Reconcile(ctx, component)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+				Expect(result.RequeueAfter).To(BeNumerically("<=", time.Minute+time.Second))
+				Expect(podCondition().Status).To(Equal(conditions.ConditionInProgress))
+			})
+
+			It("errors when the pod isn't ready in time", func() {
+				podCondition().LastTransitionTime = meta.NewTime(time.Now().Add(-2 * time.Minute))
+
+				_, err := reconciler.Reconcile(ctx, component)
+				Expect(err).To(MatchError(ContainSubstring("E#2016")))
+				Expect(podCondition().Status).To(Equal(conditions.ConditionError))
+				Expect(podCondition().Reason).To(Equal("Startup timeout exceeded"))
+			})
+
+			It("doesn't apply once the pod was ready", func() {
+				ready(core.ConditionTrue)
+				_, err := reconciler.Reconcile(ctx, component)
+				Expect(err).NotTo(HaveOccurred())
+
+				podCondition().LastTransitionTime = meta.NewTime(time.Now().Add(-2 * time.Minute))
+				ready(core.ConditionFalse)
+				_, err = reconciler.Reconcile(ctx, component)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(podCondition().Status).To(Equal(conditions.ConditionNotHealthy))
+
+				podCondition().LastTransitionTime = meta.NewTime(time.Now().Add(-2 * time.Minute))
+				result, err := reconciler.Reconcile(ctx, component)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result).To(BeNil())
+			})
+		})
+	})
+})
@@ -4,24 +4,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"regexp"
+	"net/url"
+	"strconv"
 	"strings"
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/builds"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
 	core "k8s.io/api/core/v1"
-	networking "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
-var resolverParser = regexp.MustCompile(`^\${([a-zA-Z]+)::((?:[a-zA-Z]+\.?)+)}`)
-var ErrNotAResolvableValue = errors.New("E#2006: Variable couldn't be parsed")
+var resolverParser = components.VariableParser
+var ErrNotAResolvableValue = components.ErrNotAResolvableValue
 
 type VariablesReconciler struct {
 	client.Client
@@ -54,8 +56,13 @@ func (r *VariablesReconciler) Reconcile(ctx context.Context, component *sequence
 		return nil, err
 	}
 
-	// If the component is not part of a workspace, this reconciliation loop can be skipped.
+	// If the component is not part of a workspace, there's nothing to resolve. The ConfigMaps are still
+	// created as the pod might be using them.
 	if _, ok := component.Labels[workspaces.InstanceLabel]; !ok {
+		if err := reconcileConfigMaps(ctx, r.Client, component); err != nil {
+			return nil, r.variablesFailed(component, err)
+		}
+
 		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
 			Type:   components.VariablesCondition,
 			Status: conditions.ConditionCompleted,
@@ -64,40 +71,40 @@ func (r *VariablesReconciler) Reconcile(ctx context.Context, component *sequence
 		return &ctrl.Result{}, r.Status().Update(ctx, component)
 	}
 
-	for _, container := range component.Spec.Pod.Containers {
-		if strings.HasPrefix(container.Image, components.InterpolationDelimStart) {
-			variable, err := r.VariableFrom(ctx, container.Image, component)
-			if err != nil {
-				conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
-					Type:   components.VariablesCondition,
-					Status: conditions.ConditionError,
-					Reason: err.Error(),
-				})
-
-				return nil, err
-			}
-
-			component.Status.Variables = append(component.Status.Variables, *variable)
-		}
+	resolved := map[string]bool{}
+	for _, v := range component.Status.Variables {
+		resolved[v.Name] = true
+	}
 
-		for _, env := range container.Env {
-			if !strings.HasPrefix(env.Value, components.InterpolationDelimStart) {
+	// The walk is done on a copy as the callback receives pointers to the fields of the spec.
+	spec := component.Spec.DeepCopy()
+	err := spec.WalkVariables(field.NewPath("spec"), func(path *field.Path, value *string) error {
+		for _, expression := range components.Variables(*value) {
+			if resolved[expression] {
 				continue
 			}
 
-			variable, err := r.VariableFrom(ctx, env.Value, component)
+			variable, err := r.VariableFrom(ctx, expression, component)
 			if err != nil {
-				conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
-					Type:   components.VariablesCondition,
-					Status: conditions.ConditionError,
-					Reason: err.Error(),
-				})
-
-				return nil, err
+				return fmt.Errorf("%s: %w", path, err)
 			}
 
-			component.Status.Variables = append(component.Status.Variables, *variable)
+			// References to Secrets and ConfigMaps are rendered as `valueFrom` when the pod is deployed.
+			if !components.IsReference(expression) {
+				component.Status.Variables = append(component.Status.Variables, *variable)
+			}
+			resolved[expression] = true
 		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, r.variablesFailed(component, err)
+	}
+
+	if err := reconcileConfigMaps(ctx, r.Client, component); err != nil {
+		return nil, r.variablesFailed(component, err)
 	}
 
 	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
@@ -109,6 +116,17 @@ func (r *VariablesReconciler) Reconcile(ctx context.Context, component *sequence
 	return &ctrl.Result{}, r.Client.Status().Update(ctx, component)
 }
 
+// Marks the variables condition as errored and returns the error that caused it.
+func (r *VariablesReconciler) variablesFailed(component *sequencer.Component, err error) error {
+	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+		Type:   components.VariablesCondition,
+		Status: conditions.ConditionError,
+		Reason: err.Error(),
+	})
+
+	return err
+}
+
 func (r *VariablesReconciler) VariableFrom(ctx context.Context, rawPath string, component *sequencer.Component) (*components.GeneratedVariable, error) {
 	resolver, err := newResolver(rawPath, component)
 	if err != nil {
@@ -133,15 +151,17 @@ func newResolver(content string, component *sequencer.Component) (variableResolv
 	}
 
 	switch t := string(parsed[1]); t {
-	case "build":
+	case components.BuildResolver:
+		buildName, field, _ := strings.Cut(string(parsed[2]), ".")
 		return &buildResolver{
 			namespace:     component.Namespace,
 			componentName: component.Name,
-			buildName:     string(parsed[2]),
+			buildName:     buildName,
+			field:         field,
 			content:       content,
 		}, nil
 
-	case "components":
+	case components.ComponentsResolver:
 		return &serviceResolver{
 			namespace:     component.Namespace,
 			workspaceName: component.Labels[workspaces.InstanceLabel],
@@ -149,13 +169,26 @@ func newResolver(content string, component *sequencer.Component) (variableResolv
 			content:       content,
 		}, nil
 
-	case "ingress":
+	case components.IngressResolver:
 		return &ingressResolver{
 			namespace:     component.Namespace,
 			workspaceName: component.Labels[workspaces.InstanceLabel],
 			params:        strings.Split(string(parsed[2]), "."),
 			content:       content,
 		}, nil
+
+	case components.WorkspaceResolver:
+		return &workspaceResolver{
+			namespace:     component.Namespace,
+			workspaceName: component.Labels[workspaces.InstanceLabel],
+			field:         string(parsed[2]),
+		}, nil
+
+	case components.SecretsResolver, components.ConfigMapsResolver:
+		return &referenceResolver{
+			namespace: component.Namespace,
+			content:   content,
+		}, nil
 	}
 
 	return nil, fmt.Errorf("E#2007: no resolver exists for (%s)", string(parsed[1]))
@@ -169,6 +202,7 @@ type buildResolver struct {
 	namespace     string
 	componentName string
 	buildName     string
+	field         string
 	content       string
 }
 
@@ -205,9 +239,10 @@ func (br buildResolver) Value(ctx context.Context, c client.Client) (string, err
 		return "", fmt.Errorf("E#2009: Failed to find a build within component(%s) that has the name (%s)", br.componentName, br.buildName)
 	}
 
-	var name, digest string
-	for _, image := range build.Status.Images {
-		indexManifest, err := image.ParseIndexManifest()
+	var image *builds.Image
+	var digest string
+	for _, i := range build.Status.Images {
+		indexManifest, err := i.ParseIndexManifest()
 
 		if err != nil {
 			return "", fmt.Errorf("E#2010: failed to decode the index manifest -- %w", err)
@@ -221,16 +256,31 @@ func (br buildResolver) Value(ctx context.Context, c client.Client) (string, err
 		}
 
 		if digest != "" {
-			name = image.URL
+			image = i
 			break
 		}
 	}
 
-	if name == "" || digest == "" {
+	if image == nil || image.URL == "" || digest == "" {
 		return "", errors.New("E#2011: Build referenced by the component don't include a valid image")
 	}
 
-	return fmt.Sprintf("%s@%s", name, digest), nil
+	switch br.field {
+	case components.BuildFieldDigest:
+		return digest, nil
+	case components.BuildFieldTag:
+		if len(image.Tags) == 0 {
+			return "", fmt.Errorf("E#2011: Build (%s) wasn't pushed with any tag", br.buildName)
+		}
+		return image.Tags[0], nil
+	case components.BuildFieldGitSHA:
+		if build.Status.GitSHA == "" {
+			return "", fmt.Errorf("E#2011: Build (%s) didn't import a git repository", br.buildName)
+		}
+		return build.Status.GitSHA, nil
+	}
+
+	return fmt.Sprintf("%s@%s", image.URL, digest), nil
 }
 
 type serviceResolver struct {
@@ -241,7 +291,7 @@ type serviceResolver struct {
 }
 
 func (sr serviceResolver) Value(ctx context.Context, c client.Client) (string, error) {
-	if len(sr.params) != 3 {
+	if len(sr.params) != 3 && len(sr.params) != 4 {
 		return "", fmt.Errorf("E#2012: Unexpected value. Expected format `${components::componentName.section.serviceName}`, got %s", sr.content)
 	}
 
@@ -276,7 +326,20 @@ func (sr serviceResolver) Value(ctx context.Context, c client.Client) (string, e
 		return "", fmt.Errorf("E#2008: couldn't find a service for matching %s=%s & %s=%s", components.InstanceLabel, sr.ComponentName(), components.NetworkLabel, sr.NetworkName())
 	}
 
-	return resolverParser.ReplaceAllString(sr.content, fmt.Sprintf("%s.%s.svc.cluster.local", service.Name, service.Namespace)), nil
+	if sr.WantsPort() {
+		if len(service.Spec.Ports) == 0 {
+			return "", fmt.Errorf("E#2008: service (%s) doesn't expose any port", service.Name)
+		}
+
+		return strconv.Itoa(int(service.Spec.Ports[0].Port)), nil
+	}
+
+	return fmt.Sprintf("%s.%s.svc.cluster.local", service.Name, service.Namespace), nil
+}
+
+// Returns true if the variable asks for the port of the network, eg. `${components::mysql.networks.tcp.port}`
+func (sr serviceResolver) WantsPort() bool {
+	return len(sr.params) == 4 && sr.params[3] == components.NetworkFieldPort
 }
 
 func (sr serviceResolver) ComponentName() string {
@@ -298,41 +361,41 @@ type ingressResolver struct {
 	content       string
 }
 
+// Returns the public URL of an ingress rule of the workspace, eg. `${ingress::app}` returns `https://app.my-workspace.example.com/path`.
+// With a subdomain, `${ingress::api.app}`, the subdomain is prepended to the host of the rule.
 func (ir ingressResolver) Value(ctx context.Context, c client.Client) (string, error) {
-	var ingress *networking.Ingress
 	if len(ir.params) > 2 {
 		return "", fmt.Errorf("E#2012: Unexpected value. Expected format `${ingress::subdomain?.ingressName}`, got `%s`", ir.content)
 	}
 
-	selector, err := labels.Parse(fmt.Sprintf("%s=%s", workspaces.InstanceLabel, ir.workspaceName))
-	if err != nil {
-		return "", fmt.Errorf("E#3001: failed to parse the label selector -- %w", err)
+	var workspace sequencer.Workspace
+	if err := c.Get(ctx, client.ObjectKey{Namespace: ir.namespace, Name: ir.workspaceName}, &workspace); err != nil {
+		return "", fmt.Errorf("E#2008: couldn't find the workspace (%s) -- %w", ir.workspaceName, err)
 	}
 
-	var list networking.IngressList
-	err = c.List(ctx, &list, &client.ListOptions{
-		LabelSelector: selector,
-		Namespace:     ir.namespace,
-	})
-	if err != nil {
-		return "", err
-	}
-
-	for _, i := range list.Items {
-		if i.Labels[workspaces.InstanceLabel] == ir.workspaceName && i.Labels[workspaces.IngressLabel] == ir.RuleName() {
-			ingress = &i
+	var rule *workspaces.RuleStatus
+	for i := range workspace.Status.Rules {
+		if workspace.Status.Rules[i].Name == ir.RuleName() {
+			rule = &workspace.Status.Rules[i]
 			break
 		}
 	}
 
-	if ingress == nil {
-		return "", fmt.Errorf("E#2008: couldn't find an ingress for matching %s=%s & %s=%s", workspaces.InstanceLabel, ir.workspaceName, workspaces.IngressLabel, ir.RuleName())
+	if rule == nil {
+		return "", fmt.Errorf("E#2008: couldn't find an ingress rule named (%s) in workspace (%s)", ir.RuleName(), ir.workspaceName)
 	}
 
-	if ir.HasSubdomain() {
-		return fmt.Sprintf("%s.%s", ir.Subdomain(), ingress.Name), nil
+	if !ir.HasSubdomain() {
+		return rule.URL, nil
 	}
-	return ingress.Name, nil
+
+	u, err := url.Parse(rule.URL)
+	if err != nil {
+		return "", fmt.Errorf("E#2008: ingress rule (%s) has an invalid URL (%s) -- %w", rule.Name, rule.URL, err)
+	}
+	u.Host = fmt.Sprintf("%s.%s", ir.Subdomain(), u.Host)
+
+	return u.String(), nil
 }
 
 func (ir ingressResolver) RuleName() string {
@@ -348,5 +411,72 @@ func (ir ingressResolver) HasSubdomain() bool {
 }
 
 func (ir ingressResolver) Subdomain() string {
-	return ir.params[1]
+	return ir.params[0]
+}
+
+type workspaceResolver struct {
+	namespace     string
+	workspaceName string
+	field         string
+}
+
+func (wr workspaceResolver) Value(ctx context.Context, c client.Client) (string, error) {
+	var workspace sequencer.Workspace
+	if err := c.Get(ctx, client.ObjectKey{Namespace: wr.namespace, Name: wr.workspaceName}, &workspace); err != nil {
+		return "", fmt.Errorf("E#2008: couldn't find the workspace (%s) -- %w", wr.workspaceName, err)
+	}
+
+	switch wr.field {
+	case components.WorkspaceFieldHost:
+		if workspace.Status.Host == "" {
+			return "", fmt.Errorf("E#2008: workspace (%s) doesn't have a host, networking needs to be configured", workspace.Name)
+		}
+		return workspace.Status.Host, nil
+	case components.WorkspaceFieldName:
+		return workspace.Name, nil
+	case components.WorkspaceFieldNamespace:
+		return workspace.Namespace, nil
+	}
+
+	return "", fmt.Errorf("E#2013: Invalid field (%s), only supported fields are: %s, %s, %s", wr.field, components.WorkspaceFieldHost, components.WorkspaceFieldName, components.WorkspaceFieldNamespace)
+}
+
+// Secrets and ConfigMaps are never copied in the status of a component. The resolver only makes sure the key
+// exists so the error is reported on the component instead of a pod that can't start.
+type referenceResolver struct {
+	namespace string
+	content   string
+}
+
+func (rr referenceResolver) Value(ctx context.Context, c client.Client) (string, error) {
+	resolver, name, key, err := components.ParseReference(rr.content)
+	if err != nil {
+		return "", err
+	}
+
+	if resolver == components.SecretsResolver {
+		var secret core.Secret
+		if err := c.Get(ctx, client.ObjectKey{Namespace: rr.namespace, Name: name}, &secret); err != nil {
+			return "", fmt.Errorf("E#2019: couldn't find the secret (%s) -- %w", name, err)
+		}
+
+		if _, ok := secret.Data[key]; !ok {
+			return "", fmt.Errorf("E#2019: secret (%s) doesn't have the key (%s)", name, key)
+		}
+
+		return "", nil
+	}
+
+	var configMap core.ConfigMap
+	if err := c.Get(ctx, client.ObjectKey{Namespace: rr.namespace, Name: name}, &configMap); err != nil {
+		return "", fmt.Errorf("E#2019: couldn't find the ConfigMap (%s) -- %w", name, err)
+	}
+
+	_, inData := configMap.Data[key]
+	_, inBinaryData := configMap.BinaryData[key]
+	if !inData && !inBinaryData {
+		return "", fmt.Errorf("E#2019: ConfigMap (%s) doesn't have the key (%s)", name, key)
+	}
+
+	return "", nil
 }
@@ -0,0 +1,311 @@
+package components
+
+import (
+	"context"
+	"fmt"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Reconcile a component that runs its pod template through a Deployment or a StatefulSet. Unlike a single pod,
+// the workload is updated in place when the component is redeployed, and the health of the component follows
+// the rollout status of the workload: it becomes unhealthy when replicas become unavailable and recovers
+// once the workload controller has replaced them.
+func (p *PodReconciler) reconcileWorkload(ctx context.Context, component *sequencer.Component, condition *conditions.Condition) (*ctrl.Result, error) {
+	wakingUp := condition.Status == conditions.ConditionTerminated && condition.Reason == components.ConditionReasonSleeping
+
+	if condition.Status == conditions.ConditionHealthy || condition.Status == conditions.ConditionNotHealthy || isStarting(condition) {
+		return p.monitorWorkload(ctx, component, condition)
+	}
+
+	if condition.Status != conditions.ConditionUnknown && !wakingUp {
+		return nil, nil
+	}
+
+	if err := p.deployWorkload(ctx, component); err != nil {
+		p.EventRecorder.Event(component, core.EventTypeWarning, string(components.PodCondition), err.Error())
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.PodCondition,
+			Status: conditions.ConditionError,
+			Reason: fmt.Sprintf("%s deployment failed", component.Spec.Workload.Kind),
+		})
+
+		return nil, err
+	}
+
+	conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+		Type:   components.PodCondition,
+		Status: conditions.ConditionInProgress,
+		Reason: components.ConditionReasonRollingOut,
+	})
+	component.Status.Phase = components.PhaseDeploying
+
+	return &ctrl.Result{}, p.Status().Update(ctx, component)
+}
+
+func (p *PodReconciler) deployWorkload(ctx context.Context, component *sequencer.Component) error {
+	workload := component.Spec.Workload
+
+	labels := map[string]string{
+		components.NameLabel:     component.Spec.Name,
+		components.InstanceLabel: component.Name,
+	}
+
+	if label, ok := component.Labels[workspaces.InstanceLabel]; ok {
+		labels[workspaces.InstanceLabel] = label
+	}
+
+	template := core.PodTemplateSpec{
+		ObjectMeta: meta.ObjectMeta{
+			Labels: labels,
+		},
+		Spec: *component.Spec.Pod.DeepCopy(),
+	}
+
+	// Workload controllers only support pods that are always restarted.
+	template.Spec.RestartPolicy = core.RestartPolicyAlways
+	if err := p.assignInterpolatedVariables(&template.Spec, field.NewPath("spec", "template"), component); err != nil {
+		return err
+	}
+
+	// The workload kind might have changed since the last deployment.
+	if err := p.removeWorkloads(ctx, component, workload.Kind); err != nil {
+		return err
+	}
+
+	replicas := workload.ReplicaCount()
+	selector := &meta.LabelSelector{
+		MatchLabels: map[string]string{
+			components.InstanceLabel: component.Name,
+		},
+	}
+
+	var obj client.Object
+	var mutate controllerutil.MutateFn
+
+	switch workload.Kind {
+	case components.WorkloadDeployment:
+		deployment := &apps.Deployment{ObjectMeta: p.workloadMeta(component)}
+		obj = deployment
+		mutate = func() error {
+			deployment.Labels = labels
+			deployment.OwnerReferences = ownerReferences(component)
+			deployment.Spec.Replicas = &replicas
+			deployment.Spec.Selector = selector
+			deployment.Spec.Template = template
+			return nil
+		}
+	case components.WorkloadStatefulSet:
+		if err := p.deployGoverningService(ctx, component); err != nil {
+			return err
+		}
+
+		statefulSet := &apps.StatefulSet{ObjectMeta: p.workloadMeta(component)}
+		obj = statefulSet
+		mutate = func() error {
+			statefulSet.Labels = labels
+			statefulSet.OwnerReferences = ownerReferences(component)
+			statefulSet.Spec.Replicas = &replicas
+			statefulSet.Spec.ServiceName = component.Name
+			statefulSet.Spec.Selector = selector
+			statefulSet.Spec.Template = template
+			return nil
+		}
+	default:
+		return fmt.Errorf("E#2014: Workload kind (%s) is not supported", workload.Kind)
+	}
+
+	result, err := controllerutil.CreateOrUpdate(ctx, p.Client, obj, mutate)
+	if err != nil {
+		return err
+	}
+
+	if result != controllerutil.OperationResultNone {
+		p.Eventf(component, core.EventTypeNormal, string(components.PodCondition), "%s %s, rolling out %d replica(s)", workload.Kind, result, replicas)
+	}
+
+	return nil
+}
+
+func (p *PodReconciler) monitorWorkload(ctx context.Context, component *sequencer.Component, condition *conditions.Condition) (*ctrl.Result, error) {
+	done, err := p.rolloutStatus(ctx, component)
+	if err != nil {
+		conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+			Type:   components.PodCondition,
+			Status: conditions.ConditionError,
+			Reason: "Rollout failed",
+		})
+		return nil, err
+	}
+
+	return p.updateReadiness(ctx, component, condition, done)
+}
+
+// A StatefulSet needs a headless Service to give each of its pods a stable hostname, eg. `web-0.web`. The
+// Service is named after the StatefulSet and isn't labeled with the component's instance so that the
+// NetworkReconciler doesn't mistake it for a network that was removed.
+func (p *PodReconciler) deployGoverningService(ctx context.Context, component *sequencer.Component) error {
+	service := &core.Service{ObjectMeta: p.workloadMeta(component)}
+
+	_, err := controllerutil.CreateOrUpdate(ctx, p.Client, service, func() error {
+		service.Labels = map[string]string{
+			components.NameLabel: component.Spec.Name,
+		}
+		if label, ok := component.Labels[workspaces.InstanceLabel]; ok {
+			service.Labels[workspaces.InstanceLabel] = label
+		}
+
+		service.OwnerReferences = ownerReferences(component)
+		service.Spec.ClusterIP = core.ClusterIPNone
+		service.Spec.Selector = map[string]string{
+			components.InstanceLabel: component.Name,
+		}
+
+		service.Spec.Ports = nil
+		for _, network := range component.Spec.Networks {
+			service.Spec.Ports = append(service.Spec.Ports, network.ServicePort)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("E#2021: Could not create the governing Service of the StatefulSet for component (%s) -- %w", component.Name, err)
+	}
+
+	return nil
+}
+
+// Returns true when every replica of the workload runs the latest spec and is available. An
+// error is returned if the workload doesn't exist or if the rollout can't make progress.
+func (p *PodReconciler) rolloutStatus(ctx context.Context, component *sequencer.Component) (bool, error) {
+	key := client.ObjectKey{Namespace: component.Namespace, Name: component.Name}
+	replicas := component.Spec.Workload.ReplicaCount()
+
+	switch component.Spec.Workload.Kind {
+	case components.WorkloadDeployment:
+		var deployment apps.Deployment
+		if err := p.Get(ctx, key, &deployment); err != nil {
+			return false, workloadRetrievalError(component, err)
+		}
+
+		for _, c := range deployment.Status.Conditions {
+			if c.Type == apps.DeploymentProgressing && c.Status == core.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+				return false, fmt.Errorf("E#2015: Deployment (%s) exceeded its progress deadline -- %s", deployment.Name, c.Message)
+			}
+		}
+
+		status := deployment.Status
+		return status.ObservedGeneration >= deployment.Generation &&
+			status.Replicas == replicas &&
+			status.UpdatedReplicas == replicas &&
+			status.AvailableReplicas == replicas, nil
+
+	case components.WorkloadStatefulSet:
+		var statefulSet apps.StatefulSet
+		if err := p.Get(ctx, key, &statefulSet); err != nil {
+			return false, workloadRetrievalError(component, err)
+		}
+
+		status := statefulSet.Status
+		return status.ObservedGeneration >= statefulSet.Generation &&
+			status.UpdatedReplicas == replicas &&
+			status.ReadyReplicas == replicas &&
+			status.CurrentRevision == status.UpdateRevision, nil
+	}
+
+	return false, fmt.Errorf("E#2014: Workload kind (%s) is not supported", component.Spec.Workload.Kind)
+}
+
+// Remove the resources running the pods of the component, except for the kind specified.
+// Passing an empty kind removes all of them.
+func (p *PodReconciler) removeWorkloads(ctx context.Context, component *sequencer.Component, keep components.WorkloadKind) error {
+	if keep != components.WorkloadDeployment {
+		deployment := &apps.Deployment{ObjectMeta: p.workloadMeta(component)}
+		if err := p.Delete(ctx, deployment); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	if keep != components.WorkloadStatefulSet {
+		statefulSet := &apps.StatefulSet{ObjectMeta: p.workloadMeta(component)}
+		if err := p.Delete(ctx, statefulSet); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+
+		service := &core.Service{ObjectMeta: p.workloadMeta(component)}
+		if err := p.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	if keep != components.WorkloadPod {
+		var list core.PodList
+		err := p.List(ctx, &list, client.InNamespace(component.Namespace), client.MatchingLabels{
+			components.InstanceLabel: component.Name,
+		})
+		if err != nil {
+			return fmt.Errorf("E#5002: failure to retrieve a list of pods for component (%s) -- %w", component.Name, err)
+		}
+
+		for i := range list.Items {
+			if !ownedBy(&list.Items[i], component) {
+				continue
+			}
+
+			if err := p.Delete(ctx, &list.Items[i]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *PodReconciler) workloadMeta(component *sequencer.Component) meta.ObjectMeta {
+	return meta.ObjectMeta{
+		Name:      component.Name,
+		Namespace: component.Namespace,
+	}
+}
+
+func ownerReferences(component *sequencer.Component) []meta.OwnerReference {
+	return []meta.OwnerReference{
+		{
+			APIVersion: component.APIVersion,
+			Kind:       component.Kind,
+			Name:       component.Name,
+			UID:        component.UID,
+		},
+	}
+}
+
+// Pods created directly by a component are owned by it, pods created by a workload are owned by
+// the workload's ReplicaSet or StatefulSet.
+func ownedBy(obj client.Object, component *sequencer.Component) bool {
+	for _, owner := range obj.GetOwnerReferences() {
+		if owner.UID == component.UID {
+			return true
+		}
+	}
+	return false
+}
+
+func workloadRetrievalError(component *sequencer.Component, err error) error {
+	if k8sErrors.IsNotFound(err) {
+		return fmt.Errorf("E#2003: no %s exists for component (%s)", component.Spec.Workload.Kind, component.Name)
+	}
+
+	return fmt.Errorf("E#5001: Could not retrieve the %s for component (%s) -- %w", component.Spec.Workload.Kind, component.Name, err)
+}
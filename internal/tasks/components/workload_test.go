@@ -0,0 +1,234 @@
+package components
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Workload", func() {
+	ctx := context.Background()
+
+	var component *sequencer.Component
+	var reconciler *PodReconciler
+
+	BeforeEach(func() {
+		component = &sequencer.Component{}
+		component.Name = "web"
+		component.Namespace = "default"
+		component.UID = "web-uid"
+		component.Status.Default()
+		component.Spec.Pod = core.PodSpec{Containers: []core.Container{{Name: "web", Image: "app:latest"}}}
+		component.Spec.Workload = components.WorkloadSpec{Kind: components.WorkloadDeployment, Replicas: ptr.To[int32](2)}
+	})
+
+	JustBeforeEach(func() {
+		reconciler = &PodReconciler{Client: newClient(component), EventRecorder: record.NewFakeRecorder(10)}
+
+		_, err := reconciler.Reconcile(ctx, component)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	podCondition := func() *conditions.Condition {
+		return conditions.FindCondition(component.Status.Conditions, components.PodCondition)
+	}
+
+	deployment := func() *apps.Deployment {
+		var deployment apps.Deployment
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(component), &deployment)).To(Succeed())
+		return &deployment
+	}
+
+	statefulSet := func() *apps.StatefulSet {
+		var statefulSet apps.StatefulSet
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(component), &statefulSet)).To(Succeed())
+		return &statefulSet
+	}
+
+	Context("Deployment", func() {
+		rollout := func(available int32) {
+			d := deployment()
+			d.Status.ObservedGeneration = d.Generation
+			d.Status.Replicas = 2
+			d.Status.UpdatedReplicas = 2
+			d.Status.AvailableReplicas = available
+			Expect(reconciler.Status().Update(ctx, d)).To(Succeed())
+		}
+
+		It("rolls out the replicas of the component", func() {
+			Expect(podCondition().Status).To(Equal(conditions.ConditionInProgress))
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonRollingOut))
+			Expect(component.Status.Phase).To(Equal(components.PhaseDeploying))
+
+			d := deployment()
+			Expect(*d.Spec.Replicas).To(Equal(int32(2)))
+			Expect(d.Spec.Selector.MatchLabels).To(Equal(map[string]string{components.InstanceLabel: "web"}))
+			Expect(d.Spec.Template.Spec.RestartPolicy).To(Equal(core.RestartPolicyAlways))
+			Expect(d.OwnerReferences[0].UID).To(BeEquivalentTo("web-uid"))
+		})
+
+		It("becomes healthy once every replica is available", func() {
+			rollout(1)
+			result, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+			Expect(podCondition().Reason).To(Equal(components.ConditionReasonRollingOut))
+
+			rollout(2)
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionHealthy))
+			Expect(component.Status.Phase).To(Equal(components.PhaseHealthy))
+		})
+
+		It("follows the availability of the replicas", func() {
+			rollout(2)
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			rollout(1)
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionNotHealthy))
+			Expect(component.Status.Phase).To(Equal(components.PhaseDeploying))
+
+			rollout(2)
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionHealthy))
+		})
+
+		It("waits for the controller to observe the latest spec", func() {
+			rollout(2)
+			d := deployment()
+			d.Status.ObservedGeneration = d.Generation - 1
+			Expect(reconciler.Status().Update(ctx, d)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionInProgress))
+		})
+
+		It("errors when the rollout exceeds its progress deadline", func() {
+			d := deployment()
+			d.Status.Conditions = []apps.DeploymentCondition{{
+				Type:    apps.DeploymentProgressing,
+				Status:  core.ConditionFalse,
+				Reason:  "ProgressDeadlineExceeded",
+				Message: `ReplicaSet "web-abcd" has timed out progressing.`,
+			}}
+			Expect(reconciler.Status().Update(ctx, d)).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).To(MatchError(ContainSubstring("E#2015")))
+			Expect(podCondition().Status).To(Equal(conditions.ConditionError))
+		})
+
+		It("errors when the deployment is missing", func() {
+			Expect(reconciler.Delete(ctx, deployment())).To(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).To(MatchError(ContainSubstring("E#2003")))
+			Expect(podCondition().Status).To(Equal(conditions.ConditionError))
+		})
+
+		It("replaces the deployment when the component switches to a StatefulSet", func() {
+			component.Spec.Workload.Kind = components.WorkloadStatefulSet
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.PodCondition,
+				Status: conditions.ConditionUnknown,
+				Reason: components.ConditionReasonInitialized,
+			})
+
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			var deployments apps.DeploymentList
+			Expect(reconciler.List(ctx, &deployments)).To(Succeed())
+			Expect(deployments.Items).To(BeEmpty())
+			Expect(statefulSet().Spec.ServiceName).To(Equal("web"))
+		})
+	})
+
+	Context("StatefulSet", func() {
+		BeforeEach(func() {
+			component.Spec.Workload.Kind = components.WorkloadStatefulSet
+			component.Spec.Networks = []sequencer.NetworkSpec{{ServicePort: core.ServicePort{Name: "http", Port: 8080}}}
+		})
+
+		rollout := func(current, update string) {
+			s := statefulSet()
+			s.Status.ObservedGeneration = s.Generation
+			s.Status.UpdatedReplicas = 2
+			s.Status.ReadyReplicas = 2
+			s.Status.CurrentRevision = current
+			s.Status.UpdateRevision = update
+			Expect(reconciler.Status().Update(ctx, s)).To(Succeed())
+		}
+
+		It("gives the pods a stable hostname through a headless Service", func() {
+			var service core.Service
+			Expect(reconciler.Get(ctx, client.ObjectKey{Namespace: "default", Name: statefulSet().Spec.ServiceName}, &service)).To(Succeed())
+			Expect(service.Spec.ClusterIP).To(Equal(core.ClusterIPNone))
+			Expect(service.Spec.Selector).To(Equal(statefulSet().Spec.Selector.MatchLabels))
+			Expect(service.Spec.Ports).To(Equal([]core.ServicePort{{Name: "http", Port: 8080}}))
+			Expect(service.OwnerReferences[0].UID).To(BeEquivalentTo("web-uid"))
+
+			// The NetworkReconciler removes the services of the component that aren't for one of its networks.
+			Expect(service.Labels).NotTo(HaveKey(components.InstanceLabel))
+		})
+
+		It("removes the Service along with the StatefulSet", func() {
+			component.Spec.Workload.Kind = components.WorkloadDeployment
+			conditions.SetCondition(&component.Status.Conditions, conditions.Condition{
+				Type:   components.PodCondition,
+				Status: conditions.ConditionUnknown,
+				Reason: components.ConditionReasonInitialized,
+			})
+
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			var services core.ServiceList
+			Expect(reconciler.List(ctx, &services)).To(Succeed())
+			Expect(services.Items).To(BeEmpty())
+			deployment()
+		})
+
+		It("becomes healthy once every replica runs the latest revision", func() {
+			rollout("web-1", "web-2")
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionInProgress))
+
+			rollout("web-2", "web-2")
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionHealthy))
+			Expect(component.Status.Phase).To(Equal(components.PhaseHealthy))
+		})
+
+		It("becomes unhealthy when a replica isn't ready", func() {
+			rollout("web-1", "web-1")
+			_, err := reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+
+			s := statefulSet()
+			s.Status.ReadyReplicas = 1
+			Expect(reconciler.Status().Update(ctx, s)).To(Succeed())
+
+			_, err = reconciler.Reconcile(ctx, component)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(podCondition().Status).To(Equal(conditions.ConditionNotHealthy))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package workspaces
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+)
+
+var errAddressNotYetAssigned = errors.New("internal: Waiting on an address to be assigned")
+
+// Returns the DNS records that point the workspace's hostname, and its subdomains, to the addresses provided. The first
+// IPv4 and the first IPv6 addresses are used for the A and AAAA records, which supports dual-stack load balancers. Hostnames
+// are only used, as a CNAME, when no IP is available as a CNAME can't coexist with other records for the same name.
+func addressRecords(hostname string, ips []string, hostnames []string) ([]workspaces.DNS, error) {
+	var ipv4, ipv6 string
+	for _, address := range ips {
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address (%s)", address)
+		}
+
+		if ip.To4() != nil && ipv4 == "" {
+			ipv4 = address
+		}
+
+		if ip.To4() == nil && ipv6 == "" {
+			ipv6 = address
+		}
+	}
+
+	var records []workspaces.DNS
+	add := func(recordType, target string) {
+		records = append(records, workspaces.DNS{
+			RecordType: recordType,
+			Name:       hostname,
+			Target:     target,
+		}, workspaces.DNS{
+			RecordType: recordType,
+			Name:       fmt.Sprintf("*.%s", hostname),
+			Target:     target,
+		})
+	}
+
+	if ipv4 != "" {
+		add("A", ipv4)
+	}
+
+	if ipv6 != "" {
+		add("AAAA", ipv6)
+	}
+
+	if len(records) == 0 {
+		for _, h := range hostnames {
+			if h != "" {
+				add("CNAME", h)
+				break
+			}
+		}
+	}
+
+	if len(records) == 0 {
+		return nil, errAddressNotYetAssigned
+	}
+
+	return records, nil
+}
@@ -0,0 +1,66 @@
+package workspaces
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+)
+
+var _ = Describe("Addresses", func() {
+	hostname := "my-workspace.example.com"
+
+	loadBalancer := func(ingresses ...core.LoadBalancerIngress) *core.Service {
+		service := &core.Service{}
+		service.Status.LoadBalancer.Ingress = ingresses
+		return service
+	}
+
+	It("waits for the load balancer to be provisioned", func() {
+		_, err := loadBalancerRecords(loadBalancer(), hostname)
+		Expect(err).To(MatchError(errAddressNotYetAssigned))
+	})
+
+	It("uses an A record for an IP", func() {
+		records, err := loadBalancerRecords(loadBalancer(core.LoadBalancerIngress{IP: "203.0.113.10"}), hostname)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(Equal([]workspaces.DNS{
+			{RecordType: "A", Name: hostname, Target: "203.0.113.10"},
+			{RecordType: "A", Name: "*." + hostname, Target: "203.0.113.10"},
+		}))
+	})
+
+	It("uses a CNAME record for a hostname", func() {
+		records, err := loadBalancerRecords(loadBalancer(core.LoadBalancerIngress{Hostname: "lb.elb.amazonaws.com"}), hostname)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0]).To(Equal(workspaces.DNS{RecordType: "CNAME", Name: hostname, Target: "lb.elb.amazonaws.com"}))
+	})
+
+	It("supports dual-stack load balancers", func() {
+		records, err := loadBalancerRecords(loadBalancer(
+			core.LoadBalancerIngress{IP: "203.0.113.10"},
+			core.LoadBalancerIngress{IP: "2001:db8::10"},
+			core.LoadBalancerIngress{IP: "203.0.113.11"},
+		), hostname)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(Equal([]workspaces.DNS{
+			{RecordType: "A", Name: hostname, Target: "203.0.113.10"},
+			{RecordType: "A", Name: "*." + hostname, Target: "203.0.113.10"},
+			{RecordType: "AAAA", Name: hostname, Target: "2001:db8::10"},
+			{RecordType: "AAAA", Name: "*." + hostname, Target: "2001:db8::10"},
+		}))
+	})
+
+	It("prefers IPs over hostnames", func() {
+		records, err := loadBalancerRecords(loadBalancer(core.LoadBalancerIngress{IP: "203.0.113.10", Hostname: "lb.example.net"}), hostname)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(records).To(HaveLen(2))
+		Expect(records[0].RecordType).To(Equal("A"))
+	})
+
+	It("rejects an invalid IP", func() {
+		_, err := loadBalancerRecords(loadBalancer(core.LoadBalancerIngress{IP: "not-an-ip"}), hostname)
+		Expect(err).To(MatchError(ContainSubstring("E#3013")))
+	})
+})
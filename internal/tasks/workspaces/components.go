@@ -3,6 +3,8 @@ package workspaces
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	core "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -13,6 +15,7 @@ import (
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"k8s.io/apimachinery/pkg/api/equality"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -43,6 +46,12 @@ func (r *ComponentsReconciler) ReconcileComponentHealth(ctx context.Context, wor
 	var componentsHealthy []*sequencer.Component
 
 	for _, component := range list.Items {
+		// Components that are being deleted, or that haven't yet picked up their latest spec, can't
+		// be used to determine the health of the workspace.
+		if !component.DeletionTimestamp.IsZero() || component.Status.ObservedGeneration != component.Generation {
+			continue
+		}
+
 		if component.Status.Phase == components.PhaseHealthy {
 			componentsHealthy = append(componentsHealthy, &component)
 			continue
@@ -63,7 +72,15 @@ func (r *ComponentsReconciler) ReconcileComponentHealth(ctx context.Context, wor
 		}
 	}
 
-	if len(componentsHealthy) == len(workspace.Spec.Components) && workspace.Status.Phase != workspaces.PhaseHealthy {
+	// A sleeping workspace has no healthy components, the SleepReconciler is in charge of it.
+	if workspace.Status.Phase == workspaces.PhaseSleeping {
+		return nil, nil
+	}
+
+	// The workspace isn't reachable until its DNS records propagated.
+	dnsPending := conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.DNSCondition, conditions.ConditionInProgress)
+
+	if len(componentsHealthy) == len(workspace.Spec.Components) && workspace.Status.Phase != workspaces.PhaseHealthy && !dnsPending {
 		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
 			Type:   workspaces.ComponentCondition,
 			Status: conditions.ConditionHealthy,
@@ -75,6 +92,19 @@ func (r *ComponentsReconciler) ReconcileComponentHealth(ctx context.Context, wor
 		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
 	}
 
+	// Components running as a Deployment or a StatefulSet can go back to deploying when
+	// some of their replicas become unavailable.
+	if len(componentsHealthy) != len(workspace.Spec.Components) && workspace.Status.Phase == workspaces.PhaseHealthy {
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.ComponentCondition,
+			Status: conditions.ConditionInProgress,
+			Reason: fmt.Sprintf("%d/%d components are healthy", len(componentsHealthy), len(workspace.Spec.Components)),
+		})
+		workspace.Status.Phase = workspaces.PhaseDeploying
+
+		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	}
+
 	return nil, nil
 }
 
@@ -95,6 +125,10 @@ func (r *ComponentsReconciler) Reconcile(ctx context.Context, workspace *sequenc
 	}
 
 	if condition.Status != conditions.ConditionUnknown {
+		if result, err := r.ReconcileComponentSpecs(ctx, workspace); err != nil || result != nil {
+			return result, err
+		}
+
 		return r.ReconcileComponentHealth(ctx, workspace)
 	}
 
@@ -128,6 +162,7 @@ func (r *ComponentsReconciler) Reconcile(ctx context.Context, workspace *sequenc
 		Status: conditions.ConditionCreated,
 		Reason: workspaces.ConditionReasonDeploying,
 	})
+	workspace.Status.ObservedGeneration = workspace.Generation
 	if err := r.Status().Update(ctx, workspace); err != nil {
 		return nil, err
 	}
@@ -135,6 +170,132 @@ func (r *ComponentsReconciler) Reconcile(ctx context.Context, workspace *sequenc
 	return &ctrl.Result{}, nil
 }
 
+// Redeploy takes an errored workspace out of its error phase when its spec changed since the components
+// were last reconciled. A new spec is the way a user can recover an errored workspace, the components are then
+// updated in place by ReconcileComponentSpecs.
+func (r *ComponentsReconciler) Redeploy(ctx context.Context, workspace *sequencer.Workspace) (*ctrl.Result, error) {
+	if workspace.Status.Phase != workspaces.PhaseError {
+		return nil, nil
+	}
+
+	// Workspaces that errored before their components were created have nothing to diff against.
+	if workspace.Status.ObservedGeneration == 0 || workspace.Status.ObservedGeneration == workspace.Generation {
+		return nil, nil
+	}
+
+	r.Eventf(workspace, core.EventTypeNormal, string(workspaces.PhaseDeploying), "Spec changed (generation %d), redeploying workspace", workspace.Generation)
+	workspace.Status.Phase = workspaces.PhaseDeploying
+
+	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+}
+
+// ReconcileComponentSpecs diffs the components defined in the workspace's spec against the
+// Component resources that exist for this workspace. Components are matched by name, and each of them
+// is either created, updated in place or deleted so the workspace reflects its spec.
+func (r *ComponentsReconciler) ReconcileComponentSpecs(ctx context.Context, workspace *sequencer.Workspace) (*ctrl.Result, error) {
+	// Only diff the components when the spec changed. The list of components comes from the cache
+	// and could be missing components that were just created.
+	if workspace.Status.ObservedGeneration == workspace.Generation {
+		return nil, nil
+	}
+
+	// Workspaces created before the generation was tracked already have their components.
+	if workspace.Status.ObservedGeneration == 0 {
+		workspace.Status.ObservedGeneration = workspace.Generation
+		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	}
+
+	var list sequencer.ComponentList
+	err := r.List(ctx, &list, client.InNamespace(workspace.Namespace), client.MatchingLabels{
+		workspaces.InstanceLabel: workspace.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("E#5002: failed to retrieve the list of components -- %w", err)
+	}
+
+	existing := map[string]*sequencer.Component{}
+	for i := range list.Items {
+		component := &list.Items[i]
+		if component.DeletionTimestamp.IsZero() {
+			existing[component.Labels[components.NameLabel]] = component
+		}
+	}
+
+	var actions []string
+	for i := range workspace.Spec.Components {
+		spec := &workspace.Spec.Components[i]
+		component, ok := existing[spec.Name]
+		delete(existing, spec.Name)
+
+		if !ok {
+			if err := r.createComponent(ctx, workspace, spec); err != nil {
+				return nil, r.componentActionFailed(ctx, workspace, fmt.Errorf("E#3002: Error creating component (%s) -- %w", spec.Name, err))
+			}
+
+			r.Eventf(workspace, core.EventTypeNormal, "Components", "Component (%s) created", spec.Name)
+			actions = append(actions, fmt.Sprintf("created (%s)", spec.Name))
+			continue
+		}
+
+		if equality.Semantic.DeepEqual(component.Spec, *spec) {
+			continue
+		}
+
+		component.Spec = *spec
+		if err := r.Update(ctx, component); err != nil {
+			return nil, r.componentActionFailed(ctx, workspace, fmt.Errorf("E#3014: Error updating component (%s) -- %w", spec.Name, err))
+		}
+
+		r.Eventf(workspace, core.EventTypeNormal, "Components", "Component (%s) updated", spec.Name)
+		actions = append(actions, fmt.Sprintf("updated (%s)", spec.Name))
+	}
+
+	// Anything left in the map is a component that was removed from the workspace's spec.
+	for name, component := range existing {
+		if err := r.Delete(ctx, component); client.IgnoreNotFound(err) != nil {
+			return nil, r.componentActionFailed(ctx, workspace, fmt.Errorf("E#3015: Error deleting component (%s) -- %w", name, err))
+		}
+
+		r.Eventf(workspace, core.EventTypeNormal, "Components", "Component (%s) deleted", name)
+		actions = append(actions, fmt.Sprintf("deleted (%s)", name))
+	}
+
+	workspace.Status.ObservedGeneration = workspace.Generation
+	if len(actions) == 0 {
+		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	}
+
+	sort.Strings(actions)
+	conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+		Type:   workspaces.ComponentCondition,
+		Status: conditions.ConditionInProgress,
+		Reason: fmt.Sprintf("Spec changed, components %s", strings.Join(actions, ", ")),
+	})
+
+	// Components of a sleeping workspace are updated but stay asleep.
+	if workspace.Status.Phase != workspaces.PhaseSleeping {
+		workspace.Status.Phase = workspaces.PhaseDeploying
+	}
+
+	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+}
+
+// Marks the component condition as errored and returns the error that caused it. The generation is
+// observed so the spec isn't diffed again until it changes, which is what takes the workspace out of its error.
+func (r *ComponentsReconciler) componentActionFailed(ctx context.Context, workspace *sequencer.Workspace, err error) error {
+	workspace.Status.ObservedGeneration = workspace.Generation
+	conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+		Type:   workspaces.ComponentCondition,
+		Status: conditions.ConditionError,
+		Reason: err.Error(),
+	})
+	if updateErr := r.Status().Update(ctx, workspace); updateErr != nil {
+		return fmt.Errorf("%w -- %w", updateErr, err)
+	}
+
+	return err
+}
+
 func (r *ComponentsReconciler) createComponent(ctx context.Context, workspace *sequencer.Workspace, spec *sequencer.ComponentSpec) error {
 	component := sequencer.Component{
 		ObjectMeta: meta.ObjectMeta{
@@ -156,5 +317,11 @@ func (r *ComponentsReconciler) createComponent(ctx context.Context, workspace *s
 		Spec: *spec,
 	}
 
+	if workspace.Spec.Sleep {
+		component.Annotations = map[string]string{
+			components.SleepAnnotation: "true",
+		}
+	}
+
 	return r.Create(ctx, &component)
 }
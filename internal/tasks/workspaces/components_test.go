@@ -0,0 +1,128 @@
+package workspaces
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Components", func() {
+	ctx := context.Background()
+
+	var workspace *sequencer.Workspace
+
+	component := func(name, image string) *sequencer.Component {
+		component := &sequencer.Component{}
+		component.Name = name + "-abcd"
+		component.Namespace = "default"
+		component.Labels = map[string]string{
+			workspaces.InstanceLabel: "my-workspace",
+			components.NameLabel:     name,
+		}
+		component.Spec = spec(name, image)
+		return component
+	}
+
+	BeforeEach(func() {
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Generation = 2
+		workspace.Status = workspaces.DefaultStatus()
+		workspace.Status.ObservedGeneration = 1
+		workspace.Spec.Components = []sequencer.ComponentSpec{spec("web", "web:v2"), spec("db", "postgres:16")}
+	})
+
+	Context("Specs", func() {
+		It("creates, updates and deletes components to match the spec", func() {
+			c := newClient(workspace, component("web", "web:v1"), component("worker", "worker:v1"))
+			reconciler := &ComponentsReconciler{Client: c, EventRecorder: record.NewFakeRecorder(10)}
+
+			result, err := reconciler.ReconcileComponentSpecs(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+
+			var list sequencer.ComponentList
+			Expect(c.List(ctx, &list)).To(Succeed())
+			images := map[string]string{}
+			for _, component := range list.Items {
+				images[component.Labels[components.NameLabel]] = component.Spec.Pod.Containers[0].Image
+			}
+			Expect(images).To(Equal(map[string]string{"web": "web:v2", "db": "postgres:16"}))
+
+			condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.ComponentCondition)
+			Expect(condition.Status).To(Equal(conditions.ConditionInProgress))
+			Expect(condition.Reason).To(Equal("Spec changed, components created (db), deleted (worker), updated (web)"))
+			Expect(workspace.Status.Phase).To(Equal(workspaces.PhaseDeploying))
+			Expect(workspace.Status.ObservedGeneration).To(Equal(int64(2)))
+		})
+
+		It("only diffs the components when the spec changed", func() {
+			workspace.Status.ObservedGeneration = 2
+			c := newClient(workspace, component("worker", "worker:v1"))
+			reconciler := &ComponentsReconciler{Client: c, EventRecorder: record.NewFakeRecorder(10)}
+
+			result, err := reconciler.ReconcileComponentSpecs(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+
+			var list sequencer.ComponentList
+			Expect(c.List(ctx, &list)).To(Succeed())
+			Expect(list.Items).To(HaveLen(1))
+		})
+
+		It("keeps the components of a sleeping workspace asleep", func() {
+			workspace.Status.Phase = workspaces.PhaseSleeping
+			c := newClient(workspace, component("web", "web:v1"), component("db", "postgres:16"))
+			reconciler := &ComponentsReconciler{Client: c, EventRecorder: record.NewFakeRecorder(10)}
+
+			_, err := reconciler.ReconcileComponentSpecs(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workspace.Status.Phase).To(Equal(workspaces.PhaseSleeping))
+
+			var web sequencer.Component
+			Expect(c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "web-abcd"}, &web)).To(Succeed())
+			Expect(web.Spec.Pod.Containers[0].Image).To(Equal("web:v2"))
+		})
+	})
+
+	Context("Redeploy", func() {
+		BeforeEach(func() {
+			workspace.Status.Phase = workspaces.PhaseError
+		})
+
+		It("recovers an errored workspace when its spec changed", func() {
+			reconciler := &ComponentsReconciler{Client: newClient(workspace), EventRecorder: record.NewFakeRecorder(10)}
+
+			result, err := reconciler.Redeploy(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+			Expect(workspace.Status.Phase).To(Equal(workspaces.PhaseDeploying))
+		})
+
+		It("leaves the workspace errored until its spec changes", func() {
+			workspace.Status.ObservedGeneration = 2
+			reconciler := &ComponentsReconciler{Client: newClient(workspace), EventRecorder: record.NewFakeRecorder(10)}
+
+			result, err := reconciler.Redeploy(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+			Expect(workspace.Status.Phase).To(Equal(workspaces.PhaseError))
+		})
+	})
+})
+
+func spec(name, image string) sequencer.ComponentSpec {
+	return sequencer.ComponentSpec{
+		Name: name,
+		Pod:  core.PodSpec{Containers: []core.Container{{Name: name, Image: image}}},
+	}
+}
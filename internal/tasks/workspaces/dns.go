@@ -3,10 +3,15 @@ package workspaces
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
+	"maps"
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/tools/record"
@@ -25,10 +30,10 @@ func (r *DNSReconciler) Reconcile(ctx context.Context, workspace *sequencer.Work
 		return nil, nil
 	}
 
-	if conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.DNSCondition, conditions.ConditionCompleted) {
-		// This workspace reconciliation loop is completed, but it's possible that the DNSRecord that were created here failed.
-		// If any of the DNSRecord created has an error, mark this condition as errored by fetching the conditions' error on the DNS record
-		// and copying it to this condition.
+	if condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.DNSCondition); condition != nil {
+		// The records were created, but it's possible that they failed or that they're still propagating.
+		// If the provider of any of the DNSRecord created has an error, mark this condition as errored by copying the
+		// error of the DNS record to this condition. Records that didn't propagate in time keep being verified, they're not fatal.
 		selector, err := labels.Parse(fmt.Sprintf("%s=%s", workspaces.InstanceLabel, workspace.Name))
 		if err != nil {
 			return nil, fmt.Errorf("E#3001: failed to parse the label selector -- %w", err)
@@ -44,12 +49,25 @@ func (r *DNSReconciler) Reconcile(ctx context.Context, workspace *sequencer.Work
 			return nil, fmt.Errorf("E#5002: failed to retrieve the list of DNS Records -- %w", err)
 		}
 
+		// The records can change after they were created, eg. when the routes of a tunnel change.
+		if changed, err := r.syncRecords(ctx, workspace, list.Items); err != nil {
+			return nil, err
+		} else if changed {
+			conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.DNSCondition,
+				Status: conditions.ConditionInProgress,
+				Reason: "Waiting for the DNS records to propagate",
+			})
+
+			return &ctrl.Result{}, r.Status().Patch(ctx, workspace, client.Merge)
+		}
+
 		for _, record := range list.Items {
-			if err := record.ConditionError(); err != nil {
+			if provider := conditions.FindCondition(record.Status.Conditions, dnsrecords.ProviderCondition); provider != nil && provider.Status == conditions.ConditionError {
 				conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
 					Type:   workspaces.DNSCondition,
 					Status: conditions.ConditionError,
-					Reason: err.Error(),
+					Reason: provider.Reason,
 				})
 
 				workspace.Status.Phase = workspaces.PhaseError
@@ -61,51 +79,172 @@ func (r *DNSReconciler) Reconcile(ctx context.Context, workspace *sequencer.Work
 			}
 		}
 
+		if condition.Status == conditions.ConditionInProgress {
+			for _, record := range list.Items {
+				if !propagated(&record) {
+					// Components don't depend on the records, they keep being deployed in the meantime. The
+					// workspace is reconciled again when the records' status change.
+					return r.waitForPropagation(ctx, workspace, &record)
+				}
+			}
+
+			conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.DNSCondition,
+				Status: conditions.ConditionCompleted,
+				Reason: "DNS Hostname configured",
+			})
+
+			return &ctrl.Result{}, r.Status().Patch(ctx, workspace, client.Merge)
+		}
+
 		// The condition is completed and all DNS Records entries are healthy as far as this reconciliation loop is concerned.
 		return nil, nil
 	}
 
 	for _, expected := range workspace.Status.DNS {
-		record := &sequencer.DNSRecord{
-			ObjectMeta: meta.ObjectMeta{
-				Labels: map[string]string{
-					workspaces.InstanceLabel: workspace.Name,
-				},
-				OwnerReferences: []meta.OwnerReference{{
-					APIVersion: workspace.APIVersion,
-					Name:       workspace.Name,
-					Kind:       workspace.Kind,
-					UID:        workspace.UID,
-				}},
-				GenerateName: fmt.Sprintf("%s-", workspace.Name),
-				Namespace:    workspace.Namespace,
-			},
-			Spec: sequencer.DNSRecordSpec{
-				RecordType: expected.RecordType,
-				Name:       expected.Name,
-				Target:     expected.Target,
-				Properties: expected.Properties,
-				Zone:       workspace.Spec.Networking.DNS.Zone,
-			},
-		}
-
-		if err := r.Create(ctx, record); err != nil {
+		if err := r.createRecord(ctx, workspace, expected); err != nil {
 			conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
 				Type:   workspaces.DNSCondition,
 				Status: conditions.ConditionError,
 				Reason: err.Error(),
 			})
 
-			return nil, fmt.Errorf("E#3003: Could not create a DNS Record: %w", err)
+			return nil, err
 		}
+	}
+
+	condition := conditions.Condition{
+		Type:   workspaces.DNSCondition,
+		Status: conditions.ConditionInProgress,
+		Reason: "Waiting for the DNS records to propagate",
+	}
+	if len(workspace.Status.DNS) == 0 {
+		condition.Status = conditions.ConditionCompleted
+		condition.Reason = "DNS Hostname configured"
+	}
+	conditions.SetCondition(&workspace.Status.Conditions, condition)
+
+	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+}
+
+// Records are named after the entry they're created for, a record that is created again while the cache
+// doesn't know about it yet already exists.
+func (r *DNSReconciler) createRecord(ctx context.Context, workspace *sequencer.Workspace, expected workspaces.DNS) error {
+	id := fnv.New32a()
+	id.Write([]byte(expected.RecordType + "/" + expected.Name))
+
+	record := &sequencer.DNSRecord{
+		ObjectMeta: meta.ObjectMeta{
+			Labels: map[string]string{
+				workspaces.InstanceLabel: workspace.Name,
+			},
+			OwnerReferences: []meta.OwnerReference{{
+				APIVersion: workspace.APIVersion,
+				Name:       workspace.Name,
+				Kind:       workspace.Kind,
+				UID:        workspace.UID,
+			}},
+			Name:      fmt.Sprintf("%s-%x", workspace.Name, id.Sum32()),
+			Namespace: workspace.Namespace,
+		},
+		Spec: sequencer.DNSRecordSpec{
+			RecordType:  expected.RecordType,
+			Name:        expected.Name,
+			Target:      expected.Target,
+			Properties:  expected.Properties,
+			Zone:        workspace.Spec.Networking.DNS.Zone,
+			ProviderRef: workspace.Spec.Networking.DNS.ProviderRef,
+		},
+	}
 
+	if err := r.Create(ctx, record); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return fmt.Errorf("E#3003: Could not create a DNS Record: %w", err)
 	}
 
-	conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+	return nil
+}
+
+// Bring the DNS records in line with the ones expected by the workspace. Records are matched by name and type: missing
+// ones are created, the ones that point somewhere else are updated and the ones that aren't expected anymore are deleted.
+// Returns true if any record changed.
+func (r *DNSReconciler) syncRecords(ctx context.Context, workspace *sequencer.Workspace, records []sequencer.DNSRecord) (changed bool, _ error) {
+	existing := map[string]*sequencer.DNSRecord{}
+	for i := range records {
+		existing[records[i].Spec.RecordType+"/"+records[i].Spec.Name] = &records[i]
+	}
+
+	for _, expected := range workspace.Status.DNS {
+		key := expected.RecordType + "/" + expected.Name
+		record, ok := existing[key]
+		delete(existing, key)
+
+		if !ok {
+			if err := r.createRecord(ctx, workspace, expected); err != nil {
+				return changed, err
+			}
+
+			r.Eventf(workspace, core.EventTypeNormal, string(workspaces.DNSCondition), "Creating DNS record (%s)", expected.Name)
+			changed = true
+			continue
+		}
+
+		if record.Spec.Target == expected.Target && maps.Equal(record.Spec.Properties, expected.Properties) {
+			continue
+		}
+
+		record.Spec.Target = expected.Target
+		record.Spec.Properties = expected.Properties
+		if err := r.Update(ctx, record); err != nil {
+			return changed, fmt.Errorf("E#3003: Could not update the DNS Record (%s) -- %w", record.Name, err)
+		}
+
+		r.Eventf(workspace, core.EventTypeNormal, string(workspaces.DNSCondition), "Updating DNS record (%s)", expected.Name)
+		changed = true
+	}
+
+	for _, record := range existing {
+		if !record.DeletionTimestamp.IsZero() {
+			continue
+		}
+
+		if err := r.Delete(ctx, record); err != nil && !k8sErrors.IsNotFound(err) {
+			return changed, fmt.Errorf("E#3003: Could not delete the DNS Record (%s) -- %w", record.Name, err)
+		}
+
+		r.Eventf(workspace, core.EventTypeNormal, string(workspaces.DNSCondition), "Deleting DNS record (%s)", record.Spec.Name)
+		changed = true
+	}
+
+	return changed, nil
+}
+
+// Surface the records that are late to propagate through the DNS condition. The condition stays in progress
+// as the DNS controller keeps verifying them.
+func (r *DNSReconciler) waitForPropagation(ctx context.Context, workspace *sequencer.Workspace, record *sequencer.DNSRecord) (*ctrl.Result, error) {
+	reason := "Waiting for the DNS records to propagate"
+	if condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.PropagatedCondition); condition != nil && condition.Status == conditions.ConditionError {
+		reason = fmt.Sprintf("%s, still verifying", condition.Reason)
+	}
+
+	changed := conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
 		Type:   workspaces.DNSCondition,
-		Status: conditions.ConditionCompleted,
-		Reason: "DNS Hostname configured",
+		Status: conditions.ConditionInProgress,
+		Reason: reason,
 	})
+	if !changed {
+		return nil, nil
+	}
 
-	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	return &ctrl.Result{}, r.Status().Patch(ctx, workspace, client.Merge)
+}
+
+// A record is propagated once the provider created it and, when the DNS controller verifies
+// propagation, once the nameservers answer with its target.
+func propagated(record *sequencer.DNSRecord) bool {
+	if !conditions.IsStatusConditionPresentAndEqual(record.Status.Conditions, dnsrecords.ProviderCondition, conditions.ConditionCreated) {
+		return false
+	}
+
+	condition := conditions.FindCondition(record.Status.Conditions, dnsrecords.PropagatedCondition)
+	return condition == nil || condition.Status == conditions.ConditionCompleted
 }
@@ -0,0 +1,170 @@
+package workspaces
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("DNS", func() {
+	dnsRecord := func(statuses ...conditions.Condition) *sequencer.DNSRecord {
+		record := &sequencer.DNSRecord{}
+		record.Status.Conditions = statuses
+		return record
+	}
+
+	created := conditions.Condition{Type: dnsrecords.ProviderCondition, Status: conditions.ConditionCreated}
+
+	It("waits for the provider to create the record", func() {
+		Expect(propagated(dnsRecord(conditions.Condition{Type: dnsrecords.ProviderCondition, Status: conditions.ConditionLocked}))).To(BeFalse())
+	})
+
+	It("considers a created record propagated when propagation isn't verified", func() {
+		Expect(propagated(dnsRecord(created))).To(BeTrue())
+	})
+
+	It("waits for the record to propagate", func() {
+		Expect(propagated(dnsRecord(created, conditions.Condition{Type: dnsrecords.PropagatedCondition, Status: conditions.ConditionInProgress}))).To(BeFalse())
+		Expect(propagated(dnsRecord(created, conditions.Condition{Type: dnsrecords.PropagatedCondition, Status: conditions.ConditionCompleted}))).To(BeTrue())
+	})
+
+	Context("Reconcile", func() {
+		ctx := context.Background()
+
+		expected := workspaces.DNS{Name: "my-workspace.example.com", RecordType: "CNAME", Target: "abcd.cfargotunnel.com"}
+
+		newWorkspace := func(records ...workspaces.DNS) *sequencer.Workspace {
+			workspace := &sequencer.Workspace{}
+			workspace.Name = "my-workspace"
+			workspace.Namespace = "default"
+			workspace.Status = workspaces.DefaultStatus()
+			workspace.Status.Phase = workspaces.PhaseDeploying
+			workspace.Status.DNS = records
+			conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.DNSCondition,
+				Status: conditions.ConditionInProgress,
+				Reason: "Waiting for the DNS records to propagate",
+			})
+			return workspace
+		}
+
+		newRecord := func(dns workspaces.DNS, statuses ...conditions.Condition) *sequencer.DNSRecord {
+			record := dnsRecord(statuses...)
+			record.Name = "my-workspace-abcd"
+			record.Namespace = "default"
+			record.Labels = map[string]string{workspaces.InstanceLabel: "my-workspace"}
+			record.Spec = sequencer.DNSRecordSpec{Name: dns.Name, RecordType: dns.RecordType, Target: dns.Target}
+			return record
+		}
+
+		reconcile := func(dnsRecord *sequencer.DNSRecord) *sequencer.Workspace {
+			workspace := newWorkspace(expected)
+			dnsRecord.Name = "my-workspace-abcd"
+			dnsRecord.Namespace = workspace.Namespace
+			dnsRecord.Labels = map[string]string{workspaces.InstanceLabel: workspace.Name}
+			dnsRecord.Spec = sequencer.DNSRecordSpec{Name: expected.Name, RecordType: expected.RecordType, Target: expected.Target}
+
+			reconciler := &DNSReconciler{
+				Client:        newClient(workspace, dnsRecord),
+				EventRecorder: record.NewFakeRecorder(10),
+			}
+
+			_, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(workspace), workspace)).To(Succeed())
+			return workspace
+		}
+
+		It("keeps waiting for a record that didn't propagate in time", func() {
+			workspace := reconcile(dnsRecord(created, conditions.Condition{
+				Type:   dnsrecords.PropagatedCondition,
+				Status: conditions.ConditionError,
+				Reason: "E#4009: DNS record didn't propagate",
+			}))
+
+			condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.DNSCondition)
+			Expect(condition.Status).To(Equal(conditions.ConditionInProgress))
+			Expect(condition.Reason).To(Equal("E#4009: DNS record didn't propagate, still verifying"))
+			Expect(workspace.Status.Phase).To(Equal(workspaces.PhaseDeploying))
+		})
+
+		It("errors when the provider couldn't create a record", func() {
+			workspace := reconcile(dnsRecord(conditions.Condition{
+				Type:   dnsrecords.ProviderCondition,
+				Status: conditions.ConditionError,
+				Reason: "E#4005: Provider couldn't create the DNS record",
+			}))
+
+			condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.DNSCondition)
+			Expect(condition.Status).To(Equal(conditions.ConditionError))
+			Expect(condition.Reason).To(HavePrefix("E#4005"))
+			Expect(workspace.Status.Phase).To(Equal(workspaces.PhaseError))
+		})
+
+		It("completes once the records propagated", func() {
+			workspace := reconcile(dnsRecord(created, conditions.Condition{Type: dnsrecords.PropagatedCondition, Status: conditions.ConditionCompleted}))
+			Expect(conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.DNSCondition, conditions.ConditionCompleted)).To(BeTrue())
+		})
+
+		Context("when the expected records change", func() {
+			records := func(c client.Client) map[string]string {
+				var list sequencer.DNSRecordList
+				Expect(c.List(ctx, &list)).To(Succeed())
+
+				targets := map[string]string{}
+				for _, record := range list.Items {
+					targets[record.Spec.Name] = record.Spec.Target
+				}
+				return targets
+			}
+
+			sync := func(workspace *sequencer.Workspace, existing *sequencer.DNSRecord) (client.Client, *sequencer.Workspace) {
+				c := newClient(workspace, existing)
+				reconciler := &DNSReconciler{Client: c, EventRecorder: record.NewFakeRecorder(10)}
+
+				_, err := reconciler.Reconcile(ctx, workspace)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(c.Get(ctx, client.ObjectKeyFromObject(workspace), workspace)).To(Succeed())
+				return c, workspace
+			}
+
+			propagatedRecord := func(dns workspaces.DNS) *sequencer.DNSRecord {
+				return newRecord(dns, created, conditions.Condition{Type: dnsrecords.PropagatedCondition, Status: conditions.ConditionCompleted})
+			}
+
+			It("creates the records that are missing", func() {
+				route := workspaces.DNS{Name: "api.my-workspace.example.com", RecordType: "CNAME", Target: expected.Target}
+				workspace := newWorkspace(expected, route)
+				conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{Type: workspaces.DNSCondition, Status: conditions.ConditionCompleted, Reason: "DNS Hostname configured"})
+
+				c, workspace := sync(workspace, propagatedRecord(expected))
+				Expect(records(c)).To(Equal(map[string]string{expected.Name: expected.Target, route.Name: route.Target}))
+				Expect(conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.DNSCondition, conditions.ConditionInProgress)).To(BeTrue())
+			})
+
+			It("updates the records that point somewhere else", func() {
+				c, _ := sync(newWorkspace(expected), propagatedRecord(workspaces.DNS{Name: expected.Name, RecordType: expected.RecordType, Target: "old.cfargotunnel.com"}))
+				Expect(records(c)).To(Equal(map[string]string{expected.Name: expected.Target}))
+			})
+
+			It("deletes the records that aren't expected anymore", func() {
+				c, _ := sync(newWorkspace(), propagatedRecord(expected))
+				Expect(records(c)).To(BeEmpty())
+			})
+
+			It("leaves the records in sync alone", func() {
+				c, workspace := sync(newWorkspace(expected), propagatedRecord(expected))
+				Expect(records(c)).To(HaveLen(1))
+				Expect(conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.DNSCondition, conditions.ConditionCompleted)).To(BeTrue())
+			})
+		})
+	})
+})
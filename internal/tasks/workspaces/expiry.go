@@ -0,0 +1,109 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+)
+
+type ExpiryReconciler struct {
+	client.Client
+	record.EventRecorder
+}
+
+func (r *ExpiryReconciler) Reconcile(ctx context.Context, workspace *sequencer.Workspace) (*ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	spec := workspace.Spec.Expiry
+	if spec == nil {
+		if workspace.Status.ExpiresAt == nil {
+			return nil, nil
+		}
+
+		// The expiry was removed from the spec
+		workspace.Status.ExpiresAt = nil
+		conditions.RemoveCondition(&workspace.Status.Conditions, workspaces.ExpiryCondition)
+		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	}
+
+	expiry := spec.ExpiryTime(workspace.CreationTimestamp.Time, r.lastActivity(ctx, workspace))
+	if expiry == nil {
+		return nil, nil
+	}
+
+	if workspace.Status.ExpiresAt == nil || !workspace.Status.ExpiresAt.Time.Equal(expiry.Truncate(time.Second)) {
+		workspace.Status.ExpiresAt = &meta.Time{Time: expiry.Truncate(time.Second)}
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.ExpiryCondition,
+			Status: conditions.ConditionInitialized,
+			Reason: fmt.Sprintf("Workspace expires at %s", expiry.Format(time.RFC3339)),
+		})
+		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	}
+
+	now := time.Now()
+	if !now.Before(*expiry) {
+		r.Eventf(workspace, core.EventTypeWarning, string(workspaces.ExpiryCondition), "Workspace expired at %s, deleting", expiry.Format(time.RFC3339))
+		logger.Info("Deleting expired workspace", "ExpiresAt", expiry)
+		return &ctrl.Result{}, client.IgnoreNotFound(r.Delete(ctx, workspace))
+	}
+
+	condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.ExpiryCondition)
+	if condition != nil && condition.Status == conditions.ConditionInitialized && !now.Before(expiry.Add(-spec.Warning())) {
+		r.Eventf(workspace, core.EventTypeWarning, string(workspaces.ExpiryCondition), "Workspace will be deleted at %s", expiry.Format(time.RFC3339))
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.ExpiryCondition,
+			Status: conditions.ConditionWaiting,
+			Reason: fmt.Sprintf("Workspace will be deleted at %s", expiry.Format(time.RFC3339)),
+		})
+		return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+	}
+
+	return nil, nil
+}
+
+// RequeueAfter returns the duration until the next time the expiry of the workspace needs
+// to be evaluated, either to send the warning, or to delete the workspace. Returns 0 if
+// the workspace doesn't expire.
+func (r *ExpiryReconciler) RequeueAfter(workspace *sequencer.Workspace) time.Duration {
+	if workspace.Spec.Expiry == nil || workspace.Status.ExpiresAt == nil {
+		return 0
+	}
+
+	next := workspace.Status.ExpiresAt.Time
+	if conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.ExpiryCondition, conditions.ConditionInitialized) {
+		next = next.Add(-workspace.Spec.Expiry.Warning())
+	}
+
+	// Adding a second to make sure the reconciliation happens after the deadline.
+	return max(time.Until(next), 0) + time.Second
+}
+
+// Parse the last activity from the annotation set on the workspace. If the annotation
+// is missing or malformed, the zero time is returned and the workspace's creation time
+// will be used instead.
+func (r *ExpiryReconciler) lastActivity(ctx context.Context, workspace *sequencer.Workspace) time.Time {
+	value, ok := workspace.Annotations[workspaces.LastActivityAnnotation]
+	if !ok {
+		return time.Time{}
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Ignoring malformed annotation", "Annotation", workspaces.LastActivityAnnotation)
+		return time.Time{}
+	}
+
+	return t
+}
@@ -0,0 +1,213 @@
+package workspaces
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Expiry", func() {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	var workspace *sequencer.Workspace
+	var recorder *record.FakeRecorder
+	var reconciler *ExpiryReconciler
+
+	BeforeEach(func() {
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.CreationTimestamp = meta.NewTime(now.Add(-3 * time.Hour))
+		workspace.Status = workspaces.DefaultStatus()
+		workspace.Spec.Expiry = &workspaces.ExpirySpec{}
+	})
+
+	// The reconciler is created once the workspace is configured by the test.
+	start := func() {
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &ExpiryReconciler{Client: newClient(workspace), EventRecorder: recorder}
+	}
+
+	// Changes to the spec need to be stored as updating the status reloads the workspace.
+	update := func(mutate func()) {
+		mutate()
+		Expect(reconciler.Update(ctx, workspace)).To(Succeed())
+	}
+
+	expiryCondition := func() *conditions.Condition {
+		return conditions.FindCondition(workspace.Status.Conditions, workspaces.ExpiryCondition)
+	}
+
+	deleted := func() bool {
+		err := reconciler.Get(ctx, client.ObjectKeyFromObject(workspace), &sequencer.Workspace{})
+		if k8sErrors.IsNotFound(err) {
+			return true
+		}
+
+		Expect(err).NotTo(HaveOccurred())
+		return false
+	}
+
+	Context("without an expiry", func() {
+		BeforeEach(func() {
+			workspace.Spec.Expiry = nil
+		})
+
+		It("does nothing", func() {
+			start()
+
+			result, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+			Expect(reconciler.RequeueAfter(workspace)).To(BeZero())
+		})
+
+		It("clears the expiry when it's removed from the spec", func() {
+			workspace.Status.ExpiresAt = &meta.Time{Time: now.Add(time.Hour)}
+			conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.ExpiryCondition,
+				Status: conditions.ConditionInitialized,
+			})
+
+			start()
+
+			result, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+			Expect(workspace.Status.ExpiresAt).To(BeNil())
+			Expect(expiryCondition()).To(BeNil())
+		})
+	})
+
+	It("deletes the workspace once its TTL is reached", func() {
+		workspace.Spec.Expiry.TTL = &meta.Duration{Duration: 2 * time.Hour}
+
+		start()
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(workspace.Status.ExpiresAt.Time).To(BeTemporally("==", now.Add(-time.Hour)))
+		Expect(expiryCondition().Status).To(Equal(conditions.ConditionInitialized))
+		Expect(deleted()).To(BeFalse())
+
+		_, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted()).To(BeTrue())
+		Expect(<-recorder.Events).To(ContainSubstring("deleting"))
+	})
+
+	It("deletes the workspace at the time it expires", func() {
+		workspace.Spec.Expiry.ExpiresAt = &meta.Time{Time: now.Add(-time.Minute)}
+		workspace.Spec.Expiry.TTL = &meta.Duration{Duration: 72 * time.Hour}
+
+		start()
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(workspace.Status.ExpiresAt.Time).To(BeTemporally("==", now.Add(-time.Minute)))
+
+		_, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted()).To(BeTrue())
+	})
+
+	It("warns before deleting the workspace", func() {
+		workspace.Spec.Expiry.ExpiresAt = &meta.Time{Time: now.Add(2 * time.Hour)}
+		workspace.Spec.Expiry.WarnBefore = &meta.Duration{Duration: 30 * time.Minute}
+
+		start()
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.RequeueAfter(workspace)).To(BeNumerically("~", 90*time.Minute, 5*time.Second))
+
+		result, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+		Expect(recorder.Events).To(BeEmpty())
+
+		// Within the warning period
+		update(func() { workspace.Spec.Expiry.WarnBefore = &meta.Duration{Duration: 3 * time.Hour} })
+		_, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(expiryCondition().Status).To(Equal(conditions.ConditionWaiting))
+		Expect(<-recorder.Events).To(ContainSubstring("Workspace will be deleted at"))
+		Expect(reconciler.RequeueAfter(workspace)).To(BeNumerically("~", 2*time.Hour, 5*time.Second))
+
+		// The warning is only sent once
+		result, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+		Expect(recorder.Events).To(BeEmpty())
+		Expect(deleted()).To(BeFalse())
+	})
+
+	Context("with an idle timeout", func() {
+		BeforeEach(func() {
+			workspace.Spec.Expiry.IdleTimeout = &meta.Duration{Duration: 2 * time.Hour}
+		})
+
+		It("expires after the last activity", func() {
+			workspace.Annotations = map[string]string{workspaces.LastActivityAnnotation: now.Add(-30 * time.Minute).Format(time.RFC3339)}
+
+			start()
+
+			_, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workspace.Status.ExpiresAt.Time).To(BeTemporally("==", now.Add(90*time.Minute)))
+
+			_, err = reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted()).To(BeFalse())
+		})
+
+		It("moves the expiry when there's new activity", func() {
+			workspace.Annotations = map[string]string{workspaces.LastActivityAnnotation: now.Add(-30 * time.Minute).Format(time.RFC3339)}
+
+			start()
+
+			_, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+
+			update(func() { workspace.Annotations[workspaces.LastActivityAnnotation] = now.Format(time.RFC3339) })
+			_, err = reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workspace.Status.ExpiresAt.Time).To(BeTemporally("==", now.Add(2*time.Hour)))
+			Expect(expiryCondition().Status).To(Equal(conditions.ConditionInitialized))
+		})
+
+		It("uses the creation time when the annotation is malformed", func() {
+			workspace.Annotations = map[string]string{workspaces.LastActivityAnnotation: "yesterday"}
+
+			start()
+
+			_, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(workspace.Status.ExpiresAt.Time).To(BeTemporally("==", now.Add(-time.Hour)))
+
+			_, err = reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted()).To(BeTrue())
+		})
+	})
+
+	It("requeues right away when the workspace already expired", func() {
+		workspace.Spec.Expiry.ExpiresAt = &meta.Time{Time: now.Add(-time.Minute)}
+
+		start()
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reconciler.RequeueAfter(workspace)).To(Equal(time.Second))
+	})
+})
@@ -0,0 +1,242 @@
+package workspaces
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	gateway "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type GatewayReconciler struct {
+	client.Client
+	record.EventRecorder
+}
+
+func (g *GatewayReconciler) Reconcile(ctx context.Context, workspace *sequencer.Workspace) (*ctrl.Result, error) {
+	if workspace.Spec.Networking.Gateway == nil {
+		return nil, nil
+	}
+
+	logger := log.FromContext(ctx)
+
+	condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.GatewayCondition)
+
+	if condition == nil {
+		logger.Info("Workspace has a gateway defined. Initializing condition")
+		g.Event(workspace, core.EventTypeNormal, "Conditions", "Initializing Gateway routes for workspace")
+
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.GatewayCondition,
+			Status: conditions.ConditionInitialized,
+			Reason: "Workspace requires routes on a Gateway",
+		})
+
+		return &ctrl.Result{}, g.Status().Update(ctx, workspace)
+	}
+
+	if condition.Status == conditions.ConditionCompleted {
+		return nil, nil
+	}
+
+	spec := workspace.Spec.Networking.Gateway
+
+	if condition.Status == conditions.ConditionInitialized {
+		var gw gateway.Gateway
+		if err := g.Get(ctx, spec.GatewayRef.NamespacedName(), &gw); err != nil {
+			return nil, fmt.Errorf("E#3025: Could not find the Gateway with Reference: %s -- %w", spec.GatewayRef.String(), err)
+		}
+
+		hostname := fmt.Sprintf("%s.%s", workspace.Name, workspace.Spec.Networking.DNS.Zone)
+		records, err := gatewayRecords(gw.Status.Addresses, hostname)
+		if errors.Is(err, errAddressNotYetAssigned) {
+			// The Gateway isn't watched, its address is checked again until it's assigned. The event is only
+			// emitted the first time around.
+			changed := conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.GatewayCondition,
+				Status: conditions.ConditionInitialized,
+				Reason: "Waiting for the Gateway to have an address",
+			})
+			if changed {
+				g.Event(workspace, core.EventTypeNormal, string(workspaces.GatewayCondition), "Waiting for the Gateway to have an address")
+			}
+
+			return &ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		workspace.Status.DNS = append(workspace.Status.DNS, records...)
+		workspace.Status.Host = hostname
+
+		// The rules are known before the routes are created so that components can use their URLs.
+		workspace.Status.Rules = nil
+		for _, rule := range spec.Rules {
+			workspace.Status.Rules = append(workspace.Status.Rules, rule.Status(hostname))
+		}
+
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.GatewayCondition,
+			Status: conditions.ConditionWaiting,
+			Reason: "Waiting on component to be ready",
+		})
+		g.Event(workspace, core.EventTypeNormal, string(workspaces.GatewayCondition), "Waiting for components to be ready")
+
+		return &ctrl.Result{}, g.Status().Update(ctx, workspace)
+	}
+
+	services, err := findServices(ctx, g.Client, g.EventRecorder, workspace, spec.Rules)
+	if errors.Is(err, ErrServiceNotYetReady) {
+		// The workspace is reconciled again when the components change.
+		return nil, nil
+	}
+
+	if err != nil {
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.GatewayCondition,
+			Status: conditions.ConditionError,
+			Reason: err.Error(),
+		})
+		return nil, err
+	}
+
+	g.Event(workspace, core.EventTypeNormal, string(workspaces.GatewayCondition), "Networks configured, creating the routes")
+
+	// The routes are named after the workspace and the rule so they can be created again
+	// if an update to the status fails.
+	for i, rule := range spec.Rules {
+		if err := g.reconcileRoute(ctx, workspace, rule, services[i]); err != nil {
+			conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.GatewayCondition,
+				Status: conditions.ConditionError,
+				Reason: err.Error(),
+			})
+
+			return nil, err
+		}
+	}
+
+	conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+		Type:   workspaces.GatewayCondition,
+		Status: conditions.ConditionCompleted,
+		Reason: "Routes are created",
+	})
+
+	return &ctrl.Result{}, g.Status().Update(ctx, workspace)
+}
+
+func (g *GatewayReconciler) reconcileRoute(ctx context.Context, workspace *sequencer.Workspace, rule workspaces.RuleSpec, service *core.Service) error {
+	spec := workspace.Spec.Networking.Gateway
+	status := rule.Status(workspace.Status.Host)
+
+	route := &gateway.HTTPRoute{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", workspace.Name, rule.Name),
+			Namespace: workspace.Namespace,
+		},
+	}
+
+	parent := gateway.ParentReference{
+		Name: gateway.ObjectName(spec.GatewayRef.Name),
+	}
+
+	if spec.GatewayRef.Namespace != "" {
+		namespace := gateway.Namespace(spec.GatewayRef.Namespace)
+		parent.Namespace = &namespace
+	}
+
+	if spec.SectionName != nil {
+		section := gateway.SectionName(*spec.SectionName)
+		parent.SectionName = &section
+	}
+
+	pathType := gateway.PathMatchPathPrefix
+	path := "/"
+	if rule.Path != nil {
+		path = *rule.Path
+	}
+
+	port := gateway.PortNumber(service.Spec.Ports[0].Port)
+
+	_, err := controllerutil.CreateOrUpdate(ctx, g.Client, route, func() error {
+		route.Labels = map[string]string{
+			workspaces.InstanceLabel: workspace.Name,
+			workspaces.RuleLabel:     rule.Name,
+		}
+		route.Annotations = map[string]string{
+			workspaces.RuleAnnotationPrefix + rule.Name: status.URL,
+		}
+		route.OwnerReferences = []meta.OwnerReference{
+			{
+				Name:       workspace.Name,
+				Kind:       workspace.Kind,
+				APIVersion: workspace.APIVersion,
+				UID:        workspace.UID,
+			},
+		}
+		route.Spec = gateway.HTTPRouteSpec{
+			CommonRouteSpec: gateway.CommonRouteSpec{
+				ParentRefs: []gateway.ParentReference{parent},
+			},
+			Hostnames: []gateway.Hostname{gateway.Hostname(status.Host)},
+			Rules: []gateway.HTTPRouteRule{{
+				Matches: []gateway.HTTPRouteMatch{{
+					Path: &gateway.HTTPPathMatch{
+						Type:  &pathType,
+						Value: &path,
+					},
+				}},
+				BackendRefs: []gateway.HTTPBackendRef{{
+					BackendRef: gateway.BackendRef{
+						BackendObjectReference: gateway.BackendObjectReference{
+							Name: gateway.ObjectName(service.Name),
+							Port: &port,
+						},
+					},
+				}},
+			}},
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("E#3026: Could not create the HTTPRoute for rule (%s) -- %w", rule.Name, err)
+	}
+
+	return nil
+}
+
+// Returns the DNS records pointing the workspace's hostname, and its subdomains, to the addresses of the Gateway.
+// Named addresses are specific to the implementation of the Gateway and can't be used for DNS.
+func gatewayRecords(addresses []gateway.GatewayStatusAddress, hostname string) ([]workspaces.DNS, error) {
+	var ips, hostnames []string
+	for _, address := range addresses {
+		switch {
+		case address.Type == nil || *address.Type == gateway.IPAddressType:
+			ips = append(ips, address.Value)
+		case *address.Type == gateway.HostnameAddressType:
+			hostnames = append(hostnames, address.Value)
+		}
+	}
+
+	records, err := addressRecords(hostname, ips, hostnames)
+	if err != nil && !errors.Is(err, errAddressNotYetAssigned) {
+		return nil, fmt.Errorf("E#3025: Gateway has an address that can't be used -- %w", err)
+	}
+
+	return records, err
+}
@@ -0,0 +1,85 @@
+package workspaces
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gateway "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var _ = Describe("Gateway", func() {
+	ctx := context.Background()
+
+	var workspace *sequencer.Workspace
+	var gw *gateway.Gateway
+	var recorder *record.FakeRecorder
+	var reconciler *GatewayReconciler
+
+	BeforeEach(func() {
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Status = workspaces.DefaultStatus()
+		workspace.Spec.Networking.DNS.Zone = "previews.example.com"
+		workspace.Spec.Networking.Gateway = &workspaces.GatewaySpec{
+			GatewayRef: utils.Reference{Name: "public", Namespace: "gateways"},
+			Rules:      []workspaces.RuleSpec{{Name: "web", ComponentName: "app", NetworkName: "http"}},
+		}
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.GatewayCondition,
+			Status: conditions.ConditionInitialized,
+			Reason: "Workspace requires routes on a Gateway",
+		})
+
+		gw = &gateway.Gateway{}
+		gw.Name = "public"
+		gw.Namespace = "gateways"
+
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &GatewayReconciler{Client: newClient(workspace, gw), EventRecorder: recorder}
+	})
+
+	assign := func() {
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(gw), gw)).To(Succeed())
+		gw.Status.Addresses = []gateway.GatewayStatusAddress{{Value: "203.0.113.10"}}
+		// The Gateway is a custom resource without a status subresource in the fake client.
+		Expect(reconciler.Update(ctx, gw)).To(Succeed())
+	}
+
+	It("only reports once that the Gateway doesn't have an address", func() {
+		for range 3 {
+			result, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).NotTo(BeZero())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("Waiting for the Gateway to have an address"))
+		Expect(conditions.FindCondition(workspace.Status.Conditions, workspaces.GatewayCondition).Status).To(Equal(conditions.ConditionInitialized))
+	})
+
+	It("only reports once that it's waiting for the components", func() {
+		assign()
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditions.FindCondition(workspace.Status.Conditions, workspaces.GatewayCondition).Status).To(Equal(conditions.ConditionWaiting))
+		Expect(workspace.Status.DNS).To(HaveLen(2))
+
+		for range 3 {
+			result, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("Waiting for components to be ready"))
+	})
+})
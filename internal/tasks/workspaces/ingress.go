@@ -61,28 +61,46 @@ func (i *IngressReconciler) Reconcile(ctx context.Context, workspace *sequencer.
 			return nil, fmt.Errorf("E#3013: Could not find load balancer with Reference: %s -- %w", workspace.Spec.Networking.Ingress.LoadBalancerRef.String(), err)
 		}
 
-		ingressHostname := loadBalancerSvc.Status.LoadBalancer.Ingress[0].Hostname
 		hostname := fmt.Sprintf("%s.%s", workspace.Name, workspace.Spec.Networking.DNS.Zone)
 
 		// Ingresses requires two DNSRecord to work properly. A top-level DNS record that can be used
 		// by the application as the primary point. The other is a wildcard DNS record for any subdomain that the
 		// application might need.
-		workspace.Status.DNS = append(workspace.Status.DNS, workspaces.DNS{
-			RecordType: "A",
-			Name:       hostname,
-			Target:     ingressHostname,
-		}, workspaces.DNS{
-			RecordType: "A",
-			Name:       fmt.Sprintf("*.%s", hostname),
-			Target:     ingressHostname,
-		})
+		records, err := loadBalancerRecords(loadBalancerSvc, hostname)
+		if errors.Is(err, errAddressNotYetAssigned) {
+			// The load balancer isn't watched, its address is checked again until it's provisioned. The event is
+			// only emitted the first time around.
+			changed := conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+				Type:   workspaces.IngressCondition,
+				Status: conditions.ConditionInitialized,
+				Reason: "Waiting for the load balancer to be provisioned",
+			})
+			if changed {
+				i.Event(workspace, core.EventTypeNormal, string(workspaces.IngressCondition), "Waiting for the load balancer to be provisioned")
+			}
+
+			return &ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		workspace.Status.DNS = append(workspace.Status.DNS, records...)
 		workspace.Status.Host = hostname
 
+		// The rules are known before the ingress is created so that components can use their URLs.
+		workspace.Status.Rules = nil
+		for _, rule := range workspace.Spec.Networking.Ingress.Rules {
+			workspace.Status.Rules = append(workspace.Status.Rules, rule.Status(hostname))
+		}
+
 		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
 			Type:   workspaces.IngressCondition,
 			Status: conditions.ConditionWaiting,
 			Reason: "Waiting on component to be ready",
 		})
+		i.Event(workspace, core.EventTypeNormal, string(workspaces.IngressCondition), "Waiting for components to be ready")
 
 		return &ctrl.Result{}, i.Status().Update(ctx, workspace)
 	}
@@ -92,12 +110,11 @@ func (i *IngressReconciler) Reconcile(ctx context.Context, workspace *sequencer.
 
 	if condition.Status == conditions.ConditionWaiting {
 		var err error
-		services, err = i.findServices(ctx, workspace, spec.Rules)
+		services, err = findServices(ctx, i.Client, i.EventRecorder, workspace, spec.Rules)
 		if errors.Is(err, ErrServiceNotYetReady) {
-			i.Event(workspace, core.EventTypeNormal, string(workspaces.IngressCondition), "Waiting for components to be ready")
-			// nil implicitly means that if the status updates returns no errors, the
-			// main reconciliation loop will keep going.
-			return nil, i.Status().Update(ctx, workspace)
+			// nil means the main reconciliation loop keeps going, the workspace is
+			// reconciled again when the components change.
+			return nil, nil
 		}
 
 		// Future-proofing for when findServices can return a different error
@@ -158,6 +175,10 @@ func (i *IngressReconciler) Reconcile(ctx context.Context, workspace *sequencer.
 	}
 
 	ingress.Spec.Rules = i.ingressRules(spec.Rules, services, workspace.Status.Host)
+	for _, rule := range spec.Rules {
+		ingress.Annotations[workspaces.RuleAnnotationPrefix+rule.Name] = rule.Status(workspace.Status.Host).URL
+	}
+
 	if err := i.Create(ctx, &ingress); err != nil {
 		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
 			Type:   workspaces.IngressCondition,
@@ -181,12 +202,9 @@ func (i *IngressReconciler) ingressRules(specs []workspaces.RuleSpec, services [
 	rules := []networking.IngressRule{}
 	for _, spec := range specs {
 		rule := networking.IngressRule{
-			Host: hostname,
+			Host: spec.Host(hostname),
 		}
 
-		if spec.Subdomain != nil {
-			rule.Host = fmt.Sprintf("%s.%s", *spec.Subdomain, hostname)
-		}
 		path := networking.HTTPIngressPath{
 			PathType: new(networking.PathType),
 		}
@@ -225,7 +243,9 @@ func (i *IngressReconciler) ingressRules(specs []workspaces.RuleSpec, services [
 	return rules
 }
 
-func (i *IngressReconciler) findServices(ctx context.Context, workspace *sequencer.Workspace, ruleSpecs []workspaces.RuleSpec) ([]*core.Service, error) {
+// Returns the service for each of the rules, in the same order. ErrServiceNotYetReady is returned if
+// one of the components hasn't created the service for its network yet.
+func findServices(ctx context.Context, c client.Client, recorder record.EventRecorder, workspace *sequencer.Workspace, ruleSpecs []workspaces.RuleSpec) ([]*core.Service, error) {
 	var list core.ServiceList
 	var services []*core.Service
 
@@ -234,13 +254,13 @@ func (i *IngressReconciler) findServices(ctx context.Context, workspace *sequenc
 		return nil, fmt.Errorf("E#3001: Failed to parse the label selector -- %w", err)
 	}
 
-	err = i.List(ctx, &list, &client.ListOptions{
+	err = c.List(ctx, &list, &client.ListOptions{
 		Namespace:     workspace.Namespace,
 		LabelSelector: selector,
 	})
 
 	if err != nil {
-		i.Event(workspace, core.EventTypeWarning, "Fetching Services", err.Error())
+		recorder.Event(workspace, core.EventTypeWarning, "Fetching Services", err.Error())
 		return nil, fmt.Errorf("E#5002: Couldn't retrieve the list of services -- %w", err)
 	}
 
@@ -264,13 +284,42 @@ func (i *IngressReconciler) findServices(ctx context.Context, workspace *sequenc
 }
 
 func (i *IngressReconciler) hostForWorkspace(dns []workspaces.DNS) (hosts []string) {
+	seen := map[string]bool{}
 	for _, r := range dns {
+		// Dual-stack load balancers have an A and an AAAA record for the same host.
+		if seen[r.Name] {
+			continue
+		}
+
+		seen[r.Name] = true
 		hosts = append(hosts, r.Name)
 	}
 
 	return hosts
 }
 
+// Returns the DNS records pointing the workspace's hostname to the load balancer. Load balancers can publish
+// IPs (MetalLB, GKE), hostnames (AWS) or both, and the list is empty until the load balancer is provisioned.
+func loadBalancerRecords(service *core.Service, hostname string) ([]workspaces.DNS, error) {
+	var ips, hostnames []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			ips = append(ips, ingress.IP)
+		}
+
+		if ingress.Hostname != "" {
+			hostnames = append(hostnames, ingress.Hostname)
+		}
+	}
+
+	records, err := addressRecords(hostname, ips, hostnames)
+	if err != nil && !errors.Is(err, errAddressNotYetAssigned) {
+		return nil, fmt.Errorf("E#3013: Load balancer (%s) has an address that can't be used -- %w", service.Name, err)
+	}
+
+	return records, err
+}
+
 // Retrieves the Service using the passed reference. The Service needs to be of type `LoadBalancer`. Returns
 // the service found if it is the proper type. Otherwise, it will return a contextualized error.
 func (i *IngressReconciler) getLoadBalancer(ctx context.Context, ref utils.Reference) (*core.Service, error) {
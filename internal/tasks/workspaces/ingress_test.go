@@ -0,0 +1,85 @@
+package workspaces
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Ingress", func() {
+	ctx := context.Background()
+
+	var workspace *sequencer.Workspace
+	var loadBalancer *core.Service
+	var recorder *record.FakeRecorder
+	var reconciler *IngressReconciler
+
+	BeforeEach(func() {
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Status = workspaces.DefaultStatus()
+		workspace.Spec.Networking.DNS.Zone = "previews.example.com"
+		workspace.Spec.Networking.Ingress = &workspaces.IngressSpec{
+			LoadBalancerRef: utils.Reference{Name: "ingress-nginx", Namespace: "ingress"},
+			Rules:           []workspaces.RuleSpec{{Name: "web", ComponentName: "app", NetworkName: "http"}},
+		}
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.IngressCondition,
+			Status: conditions.ConditionInitialized,
+			Reason: "Workspace requires an Ingress",
+		})
+
+		loadBalancer = &core.Service{}
+		loadBalancer.Name = "ingress-nginx"
+		loadBalancer.Namespace = "ingress"
+		loadBalancer.Spec.Type = core.ServiceTypeLoadBalancer
+
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &IngressReconciler{Client: newClient(workspace, loadBalancer), EventRecorder: recorder}
+	})
+
+	provision := func() {
+		Expect(reconciler.Get(ctx, client.ObjectKeyFromObject(loadBalancer), loadBalancer)).To(Succeed())
+		loadBalancer.Status.LoadBalancer.Ingress = []core.LoadBalancerIngress{{IP: "203.0.113.10"}}
+		Expect(reconciler.Status().Update(ctx, loadBalancer)).To(Succeed())
+	}
+
+	It("only reports once that the load balancer isn't provisioned", func() {
+		for range 3 {
+			result, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).NotTo(BeZero())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("Waiting for the load balancer to be provisioned"))
+		Expect(conditions.FindCondition(workspace.Status.Conditions, workspaces.IngressCondition).Status).To(Equal(conditions.ConditionInitialized))
+	})
+
+	It("only reports once that it's waiting for the components", func() {
+		provision()
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditions.FindCondition(workspace.Status.Conditions, workspaces.IngressCondition).Status).To(Equal(conditions.ConditionWaiting))
+		Expect(workspace.Status.DNS).To(HaveLen(2))
+
+		for range 3 {
+			result, err := reconciler.Reconcile(ctx, workspace)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result).To(BeNil())
+		}
+
+		Expect(recorder.Events).To(HaveLen(1))
+		Expect(<-recorder.Events).To(ContainSubstring("Waiting for components to be ready"))
+	})
+})
@@ -0,0 +1,86 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+)
+
+// SleepReconciler puts the components of a workspace to sleep, or wakes them up, depending on the
+// workspace's spec. Only the pods are affected, the networking for the workspace (DNS, Ingress, Tunnel) and
+// the services for each of the components stay in place.
+type SleepReconciler struct {
+	client.Client
+	record.EventRecorder
+}
+
+func (r *SleepReconciler) Reconcile(ctx context.Context, workspace *sequencer.Workspace) (*ctrl.Result, error) {
+	if workspace.Status.Phase == workspaces.PhaseTerminating {
+		return nil, nil
+	}
+
+	sleeping := workspace.Status.Phase == workspaces.PhaseSleeping
+	if workspace.Spec.Sleep == sleeping {
+		return nil, nil
+	}
+
+	var list sequencer.ComponentList
+	err := r.List(ctx, &list, client.InNamespace(workspace.Namespace), client.MatchingLabels{
+		workspaces.InstanceLabel: workspace.Name,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("E#5002: failed to retrieve the list of components -- %w", err)
+	}
+
+	for i := range list.Items {
+		component := &list.Items[i]
+		if component.IsSleeping() == workspace.Spec.Sleep {
+			continue
+		}
+
+		annotations := component.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		if workspace.Spec.Sleep {
+			annotations[components.SleepAnnotation] = "true"
+		} else {
+			delete(annotations, components.SleepAnnotation)
+		}
+
+		component.SetAnnotations(annotations)
+		if err := r.Update(ctx, component); err != nil {
+			return nil, err
+		}
+	}
+
+	if workspace.Spec.Sleep {
+		r.Event(workspace, core.EventTypeNormal, string(workspaces.PhaseSleeping), "Workspace is going to sleep")
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.ComponentCondition,
+			Status: conditions.ConditionInProgress,
+			Reason: "Components are sleeping",
+		})
+		workspace.Status.Phase = workspaces.PhaseSleeping
+	} else {
+		r.Event(workspace, core.EventTypeNormal, string(workspaces.PhaseDeploying), "Waking up workspace")
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.ComponentCondition,
+			Status: conditions.ConditionInProgress,
+			Reason: "Waking up components",
+		})
+		workspace.Status.Phase = workspaces.PhaseDeploying
+	}
+
+	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+}
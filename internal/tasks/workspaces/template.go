@@ -0,0 +1,125 @@
+package workspaces
+
+import (
+	"context"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+)
+
+type TemplateReconciler struct {
+	client.Client
+	record.EventRecorder
+}
+
+// Render the WorkspaceTemplate referenced by the workspace into its spec. The template is rendered
+// when the workspace's spec changed since the last render, or, if AutoUpdate is enabled, when the template
+// itself changed. The components created from the spec are then updated by the ComponentsReconciler like they
+// would if a user had changed the spec directly.
+func (r *TemplateReconciler) Reconcile(ctx context.Context, workspace *sequencer.Workspace) (*ctrl.Result, error) {
+	spec := workspace.Spec.Template
+	if spec == nil {
+		return nil, nil
+	}
+
+	status := workspace.Status.Template
+	rendered := status != nil && status.RenderedGeneration == workspace.Generation
+	if rendered && !spec.AutoUpdate {
+		return nil, nil
+	}
+
+	ref := TemplateRef(workspace)
+
+	var template sequencer.WorkspaceTemplate
+	if err := r.Get(ctx, ref, &template); err != nil {
+		if !k8sErrors.IsNotFound(err) {
+			return nil, fmt.Errorf("E#5001: Could not retrieve the template (%s) -- %w", ref, err)
+		}
+
+		if rendered {
+			// The template was removed after the workspace was rendered, the workspace keeps its current spec.
+			return nil, nil
+		}
+
+		return r.waitForTemplate(ctx, workspace, ref)
+	}
+
+	if rendered && status.Name == template.Name && status.Namespace == template.Namespace && status.Generation == template.Generation {
+		return nil, nil
+	}
+
+	components, networking, err := template.Render(spec.Parameters)
+	if err != nil {
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+			Type:   workspaces.TemplateCondition,
+			Status: conditions.ConditionError,
+			Reason: err.Error(),
+		})
+		return nil, err
+	}
+
+	workspace.Spec.Components = components
+	workspace.Spec.Networking = *networking
+	if err := r.Update(ctx, workspace); err != nil {
+		return nil, err
+	}
+
+	workspace.Status.Template = &workspaces.TemplateStatus{
+		Name:               template.Name,
+		Namespace:          template.Namespace,
+		Generation:         template.Generation,
+		RenderedGeneration: workspace.Generation,
+	}
+
+	conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+		Type:   workspaces.TemplateCondition,
+		Status: conditions.ConditionCompleted,
+		Reason: fmt.Sprintf("Rendered from template (%s), generation %d", ref, template.Generation),
+	})
+
+	r.Eventf(workspace, core.EventTypeNormal, string(workspaces.TemplateCondition), "Rendered from template (%s), generation %d", ref, template.Generation)
+
+	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+}
+
+// The template might be created after the workspace referencing it. The workspace waits for it instead of
+// erroring, and is reconciled again when the template is created.
+func (r *TemplateReconciler) waitForTemplate(ctx context.Context, workspace *sequencer.Workspace, ref types.NamespacedName) (*ctrl.Result, error) {
+	reason := fmt.Sprintf("E#3016: Template (%s) does not exist", ref)
+	changed := conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{
+		Type:   workspaces.TemplateCondition,
+		Status: conditions.ConditionWaiting,
+		Reason: reason,
+	})
+	if !changed {
+		return &ctrl.Result{}, nil
+	}
+
+	r.Event(workspace, core.EventTypeWarning, string(workspaces.TemplateCondition), reason)
+	return &ctrl.Result{}, r.Status().Update(ctx, workspace)
+}
+
+// TemplateRef returns the namespaced name of the template referenced by the workspace. The template
+// lives in the workspace's namespace unless one is specified.
+func TemplateRef(workspace *sequencer.Workspace) types.NamespacedName {
+	spec := workspace.Spec.Template
+	ref := types.NamespacedName{
+		Name:      spec.Name,
+		Namespace: workspace.Namespace,
+	}
+
+	if spec.Namespace != nil {
+		ref.Namespace = *spec.Namespace
+	}
+
+	return ref
+}
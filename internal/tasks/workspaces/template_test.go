@@ -0,0 +1,118 @@
+package workspaces
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/templates"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Template", func() {
+	ctx := context.Background()
+
+	var workspace *sequencer.Workspace
+	var template *sequencer.WorkspaceTemplate
+
+	BeforeEach(func() {
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Generation = 1
+		workspace.Status = workspaces.DefaultStatus()
+		workspace.Spec.Template = &workspaces.TemplateSpec{
+			Name:       "preview",
+			Parameters: map[string]string{"tag": "v2"},
+		}
+
+		template = &sequencer.WorkspaceTemplate{}
+		template.Name = "preview"
+		template.Namespace = "default"
+		template.Generation = 3
+		template.Spec.Parameters = []templates.Parameter{{Name: "tag"}, {Name: "db", Default: ptr.To("postgres:16")}}
+		template.Spec.Components = []sequencer.ComponentSpec{spec("web", "web:${parameters::tag}"), spec("db", "${parameters::db}")}
+	})
+
+	current := func(c client.Client) *sequencer.Workspace {
+		var workspace sequencer.Workspace
+		Expect(c.Get(ctx, client.ObjectKey{Name: "my-workspace", Namespace: "default"}, &workspace)).To(Succeed())
+		return &workspace
+	}
+
+	It("renders the parameters into the workspace's spec", func() {
+		c := newClient(workspace, template)
+		reconciler := &TemplateReconciler{Client: c, EventRecorder: record.NewFakeRecorder(10)}
+
+		result, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).NotTo(BeNil())
+
+		workspace = current(c)
+		Expect(workspace.Spec.Components).To(HaveLen(2))
+		Expect(workspace.Spec.Components[0].Pod.Containers[0].Image).To(Equal("web:v2"))
+		Expect(workspace.Spec.Components[1].Pod.Containers[0].Image).To(Equal("postgres:16"))
+		Expect(workspace.Status.Template).To(Equal(&workspaces.TemplateStatus{
+			Name:               "preview",
+			Namespace:          "default",
+			Generation:         3,
+			RenderedGeneration: workspace.Generation,
+		}))
+		Expect(conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.TemplateCondition, conditions.ConditionCompleted)).To(BeTrue())
+
+		result, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+	})
+
+	It("waits for a template that doesn't exist yet", func() {
+		c := newClient(workspace)
+		recorder := record.NewFakeRecorder(10)
+		reconciler := &TemplateReconciler{Client: c, EventRecorder: recorder}
+
+		result, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).NotTo(BeNil())
+
+		condition := conditions.FindCondition(current(c).Status.Conditions, workspaces.TemplateCondition)
+		Expect(condition.Status).To(Equal(conditions.ConditionWaiting))
+		Expect(condition.Reason).To(HavePrefix("E#3016"))
+		Expect(current(c).Status.Phase).To(Equal(workspaces.PhaseDeploying))
+		Expect(recorder.Events).To(HaveLen(1))
+
+		workspace = current(c)
+		_, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.Events).To(HaveLen(1))
+
+		Expect(c.Create(ctx, template)).To(Succeed())
+		_, err = reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conditions.IsStatusConditionPresentAndEqual(current(c).Status.Conditions, workspaces.TemplateCondition, conditions.ConditionCompleted)).To(BeTrue())
+		Expect(current(c).Spec.Components).To(HaveLen(2))
+	})
+
+	It("keeps the rendered spec when the template is removed", func() {
+		workspace.Spec.Template.AutoUpdate = true
+		workspace.Status.Template = &workspaces.TemplateStatus{Name: "preview", Namespace: "default", Generation: 3, RenderedGeneration: 1}
+		reconciler := &TemplateReconciler{Client: newClient(workspace), EventRecorder: record.NewFakeRecorder(10)}
+
+		result, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result).To(BeNil())
+	})
+
+	It("errors when a required parameter is missing", func() {
+		workspace.Spec.Template.Parameters = nil
+		reconciler := &TemplateReconciler{Client: newClient(workspace, template), EventRecorder: record.NewFakeRecorder(10)}
+
+		_, err := reconciler.Reconcile(ctx, workspace)
+		Expect(err).To(HaveOccurred())
+		Expect(conditions.IsStatusConditionPresentAndEqual(workspace.Status.Conditions, workspaces.TemplateCondition, conditions.ConditionError)).To(BeTrue())
+	})
+})
@@ -0,0 +1,328 @@
+package tunneling
+
+import (
+	"context"
+	"fmt"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"github.com/pier-oliviert/sequencer/internal/integrations"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const kTunnelAgentLabel = "tunneling.se.quencer.io/agent"
+
+// Labels the resources running the connector of a tunnel. The workspace watches them to
+// follow the health of its tunnel.
+const ConnectorLabel = "tunneling.se.quencer.io/connector"
+
+// Key of the owned secret that holds the credentials of the agent.
+const kTunnelAgentSecretKey = "token"
+
+// An agent exposes the workspace by running next to it and connecting to the tunnel service, which
+// routes the traffic for the workspace's host. Unlike Cloudflare, there's nothing to configure through an API.
+type agent interface {
+	// Name of the agent, used to label its resources.
+	name() string
+
+	route() tunneling.RouteSpec
+
+	// Secret holding the credentials of the agent, if it needs any.
+	secretKeyRef() *utils.SecretKeyRef
+
+	// DNS records that point the host to the tunnel service.
+	records(host string) []workspaces.DNS
+
+	// Resources needed to run the agent, the last one being its Deployment. The credentials are stored in the secret
+	// given, under kTunnelAgentSecretKey.
+	resources(host string, service *core.Service, secret *core.Secret) ([]client.Object, error)
+}
+
+type agentProvider struct {
+	agent
+	integrations.ProviderController
+}
+
+func (p agentProvider) Reconcile(ctx context.Context) (*ctrl.Result, error) {
+	condition := p.Condition()
+
+	if condition.Status == conditions.ConditionInitialized {
+		return &ctrl.Result{}, p.Guard(ctx, fmt.Sprintf("Configuring the %s tunnel", p.name()), func() (conditions.ConditionStatus, string, error) {
+			status := &p.Workspace().Status
+			status.Host = fmt.Sprintf("%s.%s", p.Workspace().Name, p.Workspace().Spec.Networking.DNS.Zone)
+			status.DNS = append(status.DNS, p.records(status.Host)...)
+			status.Tunnel = &workspaces.Tunnel{
+				RemoteID: status.Host,
+				ProviderMeta: map[string]string{
+					kTunnelAgentLabel: p.name(),
+				},
+			}
+
+			return conditions.ConditionCreated, "Tunnel configured", p.Update(ctx, *status)
+		})
+	}
+
+	if condition.Status == conditions.ConditionCreated {
+		route := p.route()
+		service, err := serviceFor(ctx, p, route.ComponentName, route.NetworkName)
+		if err != nil {
+			return nil, err
+		}
+
+		if service == nil {
+			p.Eventf(core.EventTypeNormal, "Tunneling", "Waiting for (%s:%s) service", route.ComponentName, route.NetworkName)
+			return nil, nil
+		}
+
+		return &ctrl.Result{}, p.Guard(ctx, fmt.Sprintf("Deploying the %s agent", p.name()), func() (conditions.ConditionStatus, string, error) {
+			secret, err := p.agentSecret(ctx)
+			if err != nil {
+				return "", "", err
+			}
+
+			resources, err := p.resources(p.Workspace().Status.Host, service, secret)
+			if err != nil {
+				return "", "", fmt.Errorf("E#3027: Could not deploy the %s agent -- %w", p.name(), err)
+			}
+
+			for _, resource := range append([]client.Object{secret}, resources...) {
+				if deployment, ok := resource.(*apps.Deployment); ok {
+					p.nameDeployment(deployment)
+				}
+
+				if resource.GetName() == "" {
+					continue
+				}
+
+				resource.SetLabels(labels.Merge(resource.GetLabels(), map[string]string{kTunnelAgentLabel: p.name()}))
+				// Named resources can exist if a previous attempt failed after creating them.
+				if err := p.Create(ctx, resource); err != nil && !k8sErrors.IsAlreadyExists(err) {
+					return "", "", fmt.Errorf("E#3027: Could not deploy the %s agent -- %w", p.name(), err)
+				}
+			}
+
+			p.Eventf(core.EventTypeNormal, "Tunneling", "Agent deployed, tunnel pointing to service (%s)", service.Name)
+			return conditions.ConditionInProgress, "Waiting for the connector to be ready", nil
+		})
+	}
+
+	switch condition.Status {
+	case conditions.ConditionInProgress, conditions.ConditionCompleted, conditions.ConditionNotHealthy:
+		return p.monitorAgent(ctx)
+	}
+
+	return nil, nil
+}
+
+// Report the health of the agent through the condition, the same way the connector of a Cloudflare tunnel is.
+func (p agentProvider) monitorAgent(ctx context.Context) (*ctrl.Result, error) {
+	workspace := p.Workspace()
+
+	var deployment apps.Deployment
+	err := p.Get(ctx, types.NamespacedName{Name: agentName(workspace, p.name()), Namespace: workspace.Namespace}, &deployment)
+	if k8sErrors.IsNotFound(err) {
+		// Workspaces created before the agent was a Deployment ran it as a pod. The pod is replaced by a Deployment.
+		if err := p.removeAgentPods(ctx); err != nil {
+			return nil, err
+		}
+		return &ctrl.Result{}, p.UpdateCondition(ctx, conditions.ConditionCreated, "Tunnel configured")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status, reason := connectorHealth(&deployment, p.Condition().Status)
+	if status == p.Condition().Status && reason == p.Condition().Reason {
+		return nil, nil
+	}
+
+	if status == conditions.ConditionNotHealthy {
+		p.Event(core.EventTypeWarning, "Tunneling", reason)
+	}
+
+	return &ctrl.Result{}, p.UpdateCondition(ctx, status, reason)
+}
+
+func (p agentProvider) removeAgentPods(ctx context.Context) error {
+	selector, err := labels.Parse(fmt.Sprintf("%s=%s,%s=%s", workspaces.InstanceLabel, p.Workspace().Name, kTunnelAgentLabel, p.name()))
+	if err != nil {
+		return err
+	}
+
+	var pods core.PodList
+	if err := p.List(ctx, &pods, &client.ListOptions{Namespace: p.Namespace(), LabelSelector: selector}); err != nil {
+		return err
+	}
+
+	for i := range pods.Items {
+		if err := p.Delete(ctx, &pods.Items[i]); err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The Deployment is named after the workspace so retries don't create a second agent, and its pods
+// are selected by workspace as the namespace can hold the agents of many workspaces.
+func (p agentProvider) nameDeployment(deployment *apps.Deployment) {
+	selector := map[string]string{
+		workspaces.InstanceLabel: p.Workspace().Name,
+		ConnectorLabel:           p.name(),
+	}
+
+	deployment.Name = agentName(p.Workspace(), p.name())
+	deployment.Labels = labels.Merge(deployment.Labels, selector)
+	deployment.Spec.Selector = &meta.LabelSelector{MatchLabels: selector}
+	deployment.Spec.Template.Labels = labels.Merge(deployment.Spec.Template.Labels, selector)
+}
+
+// The resources of the agent are owned by the workspace and are deleted with it, and the
+// tunnel services drop the routes of an agent that disconnects.
+func (p agentProvider) Terminate(ctx context.Context) (*ctrl.Result, error) {
+	if p.Condition().Status == conditions.ConditionTerminated {
+		return nil, nil
+	}
+
+	return nil, p.UpdateCondition(ctx, conditions.ConditionTerminated, "Agent removed with the workspace")
+}
+
+// Copy the credentials of the agent to a secret owned by the workspace, in its namespace, so the pod can reference
+// them. The secret is empty, and isn't created, when the agent doesn't need credentials.
+func (p agentProvider) agentSecret(ctx context.Context) (*core.Secret, error) {
+	secret := &core.Secret{}
+
+	ref := p.secretKeyRef()
+	if ref == nil {
+		return secret, nil
+	}
+
+	namespacedName := types.NamespacedName{Name: ref.Name, Namespace: p.Namespace()}
+	if ref.Namespace != nil {
+		namespacedName.Namespace = *ref.Namespace
+	}
+
+	var source core.Secret
+	if err := p.Get(ctx, namespacedName, &source); err != nil {
+		return nil, err
+	}
+
+	value, ok := source.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("E#3004: secret %s doesn't include a value at key %s", source.Name, ref.Key)
+	}
+
+	secret.Name = fmt.Sprintf("%s-%s-agent", p.Workspace().Name, p.name())
+	secret.Data = map[string][]byte{kTunnelAgentSecretKey: value}
+
+	return secret, nil
+}
+
+// Returns the service of the component's network, or nil if it doesn't exist yet.
+func serviceFor(ctx context.Context, controller integrations.ProviderController, componentName, networkName string) (*core.Service, error) {
+	var services core.ServiceList
+
+	selector, err := labels.Parse(fmt.Sprintf("%s=%s", workspaces.InstanceLabel, controller.Workspace().Name))
+	if err != nil {
+		return nil, err
+	}
+
+	err = controller.List(ctx, &services, &client.ListOptions{
+		Namespace:     controller.Namespace(),
+		LabelSelector: selector,
+	})
+
+	if err != nil {
+		controller.Event(core.EventTypeWarning, "Fetching Services", err.Error())
+		return nil, err
+	}
+
+	for i := range services.Items {
+		s := &services.Items[i]
+		if s.Labels[components.NameLabel] == componentName && s.Labels[components.NetworkLabel] == networkName {
+			return s, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Returns the URL of the service the agent forwards the traffic to.
+func upstream(route tunneling.RouteSpec, service *core.Service) string {
+	protocol := route.Protocol
+	if protocol == "" {
+		protocol = "http"
+	}
+
+	return fmt.Sprintf("%s://%s:%d", protocol, service.Name, service.Spec.Ports[0].Port)
+}
+
+// Environment variable sourced from the agent's secret.
+func secretEnv(name string, secret *core.Secret) core.EnvVar {
+	return core.EnvVar{
+		Name: name,
+		ValueFrom: &core.EnvVarSource{
+			SecretKeyRef: &core.SecretKeySelector{
+				LocalObjectReference: core.LocalObjectReference{Name: secret.Name},
+				Key:                  kTunnelAgentSecretKey,
+			},
+		},
+	}
+}
+
+// Name of the Deployment running the agent of the workspace.
+func agentName(workspace *sequencer.Workspace, agent string) string {
+	return fmt.Sprintf("%s-%s-agent", workspace.Name, agent)
+}
+
+// Deployment running a single replica of the agent's container. Its name and selector are set when it's deployed.
+// The readiness probe needs to fail while the agent isn't connected as the health of the tunnel follows it.
+func agentDeployment(name, image string, args []string, env []core.EnvVar, readiness *core.Probe) *apps.Deployment {
+	replicas := int32(1)
+	return &apps.Deployment{
+		Spec: apps.DeploymentSpec{
+			Replicas: &replicas,
+			Template: core.PodTemplateSpec{
+				Spec: core.PodSpec{
+					Containers: []core.Container{{
+						Name:           name,
+						Image:          image,
+						Args:           args,
+						Env:            env,
+						ReadinessProbe: readiness,
+					}},
+				},
+			},
+		},
+	}
+}
+
+// Mounts the configuration of the agent, stored in a ConfigMap, at the path given.
+func mountConfig(deployment *apps.Deployment, config *core.ConfigMap, path string) {
+	pod := &deployment.Spec.Template
+	pod.Spec.Volumes = append(pod.Spec.Volumes, core.Volume{
+		Name: "config",
+		VolumeSource: core.VolumeSource{
+			ConfigMap: &core.ConfigMapVolumeSource{
+				LocalObjectReference: core.LocalObjectReference{Name: config.Name},
+			},
+		},
+	})
+
+	pod.Spec.Containers[0].VolumeMounts = append(pod.Spec.Containers[0].VolumeMounts, core.VolumeMount{
+		Name:      "config",
+		MountPath: path,
+		ReadOnly:  true,
+	})
+}
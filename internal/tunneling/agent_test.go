@@ -0,0 +1,254 @@
+package tunneling
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"github.com/pier-oliviert/sequencer/internal/integrations"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Agents", func() {
+	const host = "my-workspace.previews.example.com"
+
+	service := &core.Service{}
+	service.Name = "app-http"
+	service.Namespace = "default"
+	service.Spec.Ports = []core.ServicePort{{Port: 8080}}
+
+	secret := &core.Secret{}
+	secret.Name = "my-workspace-agent"
+
+	route := tunneling.RouteSpec{ComponentName: "app", NetworkName: "http"}
+
+	Context("ngrok", func() {
+		It("runs the agent for the host", func() {
+			resources, err := ngrok{spec: &tunneling.NgrokTunnelSpec{Route: route}}.resources(host, service, secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources).To(HaveLen(1))
+
+			container := resources[0].(*apps.Deployment).Spec.Template.Spec.Containers[0]
+			Expect(container.Image).To(Equal(kNgrokDefaultImage))
+			Expect(container.Args).To(Equal([]string{"http", "http://app-http:8080", "--url=https://" + host, "--log=stdout"}))
+			Expect(container.Env[0].ValueFrom.SecretKeyRef.Name).To(Equal(secret.Name))
+		})
+
+		It("is ready once the tunnel is online", func() {
+			resources, err := ngrok{spec: &tunneling.NgrokTunnelSpec{Route: route}}.resources(host, service, secret)
+			Expect(err).NotTo(HaveOccurred())
+
+			probe := resources[0].(*apps.Deployment).Spec.Template.Spec.Containers[0].ReadinessProbe
+			Expect(probe.HTTPGet.Path).To(Equal("/api/tunnels/command_line"))
+			Expect(probe.HTTPGet.Port.IntValue()).To(Equal(kNgrokAPIPort))
+		})
+
+		It("only creates a record when the CNAME target is set", func() {
+			Expect(ngrok{spec: &tunneling.NgrokTunnelSpec{}}.records(host)).To(BeEmpty())
+			Expect(ngrok{spec: &tunneling.NgrokTunnelSpec{CNAMETarget: "abc.ngrok-cname.com"}}.records(host)).To(Equal([]workspaces.DNS{
+				{Name: host, RecordType: "CNAME", Target: "abc.ngrok-cname.com"},
+			}))
+		})
+	})
+
+	Context("tailscale", func() {
+		It("serves the upstream through funnel", func() {
+			resources, err := tailscale{spec: &tunneling.TailscaleTunnelSpec{Route: route}}.resources("my-workspace.tail1234.ts.net", service, secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources).To(HaveLen(2))
+
+			config := resources[0].(*core.ConfigMap)
+			Expect(config.Data[kTailscaleServeConfigKey]).To(ContainSubstring(`"Proxy": "http://app-http:8080"`))
+			Expect(config.Data[kTailscaleServeConfigKey]).To(ContainSubstring(`"AllowFunnel"`))
+
+			pod := resources[1].(*apps.Deployment).Spec.Template
+			Expect(pod.Spec.Containers[0].Env).To(ContainElement(core.EnvVar{Name: "TS_HOSTNAME", Value: "my-workspace"}))
+			Expect(pod.Spec.Volumes[0].ConfigMap.Name).To(Equal(config.Name))
+		})
+
+		It("is ready once the node is on the tailnet", func() {
+			resources, err := tailscale{spec: &tunneling.TailscaleTunnelSpec{Route: route}}.resources("my-workspace.tail1234.ts.net", service, secret)
+			Expect(err).NotTo(HaveOccurred())
+
+			container := resources[1].(*apps.Deployment).Spec.Template.Spec.Containers[0]
+			Expect(container.Env).To(ContainElements(
+				core.EnvVar{Name: "TS_ENABLE_HEALTH_CHECK", Value: "true"},
+				core.EnvVar{Name: "TS_LOCAL_ADDR_PORT", Value: "[::]:9002"},
+			))
+			Expect(container.ReadinessProbe.HTTPGet.Path).To(Equal("/healthz"))
+			Expect(container.ReadinessProbe.HTTPGet.Port.IntValue()).To(Equal(kTailscaleHealthPort))
+		})
+	})
+
+	Context("frp", func() {
+		It("points the host to the server", func() {
+			Expect(frp{spec: &tunneling.FrpTunnelSpec{ServerAddress: "frps.example.com"}}.records(host)).To(Equal([]workspaces.DNS{
+				{Name: host, RecordType: "CNAME", Target: "frps.example.com"},
+			}))
+			Expect(frp{spec: &tunneling.FrpTunnelSpec{ServerAddress: "frps.frp.svc", PublicAddress: "203.0.113.10"}}.records(host)).To(Equal([]workspaces.DNS{
+				{Name: host, RecordType: "A", Target: "203.0.113.10"},
+			}))
+		})
+
+		It("configures an HTTP proxy for the host", func() {
+			resources, err := frp{spec: &tunneling.FrpTunnelSpec{ServerAddress: "frps.frp.svc", Route: route}}.resources(host, service, secret)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources).To(HaveLen(2))
+
+			config := resources[0].(*core.ConfigMap).Data[kFrpConfigKey]
+			Expect(config).To(ContainSubstring(`serverAddr = "frps.frp.svc"`))
+			Expect(config).To(ContainSubstring("serverPort = 7000"))
+			Expect(config).To(ContainSubstring(`auth.token = "{{ .Envs.FRP_AUTH_TOKEN }}"`))
+			Expect(config).To(ContainSubstring(`name = "default.my-workspace"`))
+			Expect(config).To(ContainSubstring(`customDomains = ["` + host + `"]`))
+			Expect(config).To(ContainSubstring(`localIP = "app-http"`))
+			Expect(config).To(ContainSubstring("localPort = 8080"))
+		})
+
+		It("is ready once the server accepted the proxy", func() {
+			resources, err := frp{spec: &tunneling.FrpTunnelSpec{ServerAddress: "frps.frp.svc", Route: route}}.resources(host, service, secret)
+			Expect(err).NotTo(HaveOccurred())
+
+			config := resources[0].(*core.ConfigMap).Data[kFrpConfigKey]
+			Expect(config).To(ContainSubstring(`webServer.addr = "127.0.0.1"`))
+			Expect(config).To(ContainSubstring("webServer.port = 7400"))
+
+			probe := resources[1].(*apps.Deployment).Spec.Template.Spec.Containers[0].ReadinessProbe
+			Expect(probe.Exec.Command[2]).To(ContainSubstring("http://127.0.0.1:7400/api/status"))
+			Expect(probe.Exec.Command[2]).To(ContainSubstring(`"status":"running"`))
+		})
+
+		It("doesn't authenticate without a secret", func() {
+			resources, err := frp{spec: &tunneling.FrpTunnelSpec{ServerAddress: "frps.frp.svc", Route: route}}.resources(host, service, &core.Secret{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources[0].(*core.ConfigMap).Data[kFrpConfigKey]).NotTo(ContainSubstring("auth.token"))
+			Expect(resources[1].(*apps.Deployment).Spec.Template.Spec.Containers[0].Env).To(BeEmpty())
+		})
+	})
+})
+
+var _ = Describe("Agent provider", func() {
+	ctx := context.Background()
+
+	var c client.Client
+	var workspace *sequencer.Workspace
+
+	BeforeEach(func() {
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Spec.Networking.DNS.Zone = "previews.example.com"
+		workspace.Spec.Networking.Tunnel = &workspaces.TunnelSpec{Ngrok: &tunneling.NgrokTunnelSpec{
+			SecretKeyRef: utils.SecretKeyRef{Key: "authtoken", SecretRef: utils.SecretRef{Name: "ngrok"}},
+			Route:        tunneling.RouteSpec{ComponentName: "app", NetworkName: "http"},
+		}}
+		workspace.Status.Conditions = []conditions.Condition{{
+			Type:   workspaces.TunnelingCondition,
+			Status: conditions.ConditionInitialized,
+		}}
+
+		service := &core.Service{}
+		service.Name = "app-http"
+		service.Namespace = "default"
+		service.Labels = map[string]string{
+			workspaces.InstanceLabel: workspace.Name,
+			components.NameLabel:     "app",
+			components.NetworkLabel:  "http",
+		}
+		service.Spec.Ports = []core.ServicePort{{Port: 8080}}
+
+		secret := &core.Secret{}
+		secret.Name = "ngrok"
+		secret.Namespace = "default"
+		secret.Data = map[string][]byte{"authtoken": []byte("token")}
+
+		c = newClient(workspace, service, secret)
+	})
+
+	// Reconciles the tunnel like the workspace controller does, with the condition as it's stored.
+	reconcile := func() conditions.Condition {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(workspace), workspace)).To(Succeed())
+		condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.TunnelingCondition)
+
+		provider, err := NewProvider(ctx, integrations.NewController(workspace, *condition, reconciler{c, record.NewFakeRecorder(10)}))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = provider.Reconcile(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		return *conditions.FindCondition(workspace.Status.Conditions, workspaces.TunnelingCondition)
+	}
+
+	deployment := func() *apps.Deployment {
+		var deployment apps.Deployment
+		Expect(c.Get(ctx, types.NamespacedName{Name: "my-workspace-ngrok-agent", Namespace: "default"}, &deployment)).To(Succeed())
+		return &deployment
+	}
+
+	It("runs the agent as a Deployment and reports its health", func() {
+		Expect(reconcile().Status).To(Equal(conditions.ConditionCreated))
+		Expect(reconcile().Status).To(Equal(conditions.ConditionInProgress))
+
+		agent := deployment()
+		Expect(agent.Spec.Selector.MatchLabels).To(Equal(map[string]string{
+			workspaces.InstanceLabel: "my-workspace",
+			ConnectorLabel:           "ngrok",
+		}))
+		Expect(agent.Spec.Template.Labels).To(Equal(agent.Spec.Selector.MatchLabels))
+		Expect(agent.OwnerReferences[0].Name).To(Equal("my-workspace"))
+
+		Expect(reconcile().Status).To(Equal(conditions.ConditionInProgress))
+
+		agent.Status.ReadyReplicas = 1
+		Expect(c.Status().Update(ctx, agent)).To(Succeed())
+		Expect(reconcile().Status).To(Equal(conditions.ConditionCompleted))
+
+		agent = deployment()
+		agent.Status.ReadyReplicas = 0
+		Expect(c.Status().Update(ctx, agent)).To(Succeed())
+		Expect(reconcile().Status).To(Equal(conditions.ConditionNotHealthy))
+	})
+
+	It("doesn't duplicate the agent when the deployment is retried", func() {
+		reconcile()
+		reconcile()
+
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{Type: workspaces.TunnelingCondition, Status: conditions.ConditionCreated})
+		Expect(c.Status().Update(ctx, workspace)).To(Succeed())
+		Expect(reconcile().Status).To(Equal(conditions.ConditionInProgress))
+
+		var deployments apps.DeploymentList
+		Expect(c.List(ctx, &deployments)).To(Succeed())
+		Expect(deployments.Items).To(HaveLen(1))
+	})
+
+	It("replaces the pod of an agent deployed before it ran as a Deployment", func() {
+		reconcile()
+
+		pod := &core.Pod{}
+		pod.Name = "tunnel-ngrok-abcd"
+		pod.Namespace = "default"
+		pod.Labels = map[string]string{workspaces.InstanceLabel: "my-workspace", kTunnelAgentLabel: "ngrok"}
+		Expect(c.Create(ctx, pod)).To(Succeed())
+
+		conditions.SetCondition(&workspace.Status.Conditions, conditions.Condition{Type: workspaces.TunnelingCondition, Status: conditions.ConditionCompleted, Reason: "Tunnel ready to use"})
+		Expect(c.Status().Update(ctx, workspace)).To(Succeed())
+
+		Expect(reconcile().Status).To(Equal(conditions.ConditionCreated))
+		var pods core.PodList
+		Expect(c.List(ctx, &pods)).To(Succeed())
+		Expect(pods.Items).To(BeEmpty())
+
+		Expect(reconcile().Status).To(Equal(conditions.ConditionInProgress))
+		deployment()
+	})
+})
@@ -2,19 +2,23 @@ package tunneling
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
 	"time"
 
 	"github.com/cloudflare/cloudflare-go"
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
-	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
 	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
 	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
 	"github.com/pier-oliviert/sequencer/internal/integrations"
+	apps "k8s.io/api/apps/v1"
 	core "k8s.io/api/core/v1"
-	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -23,10 +27,18 @@ import (
 )
 
 const kCloudflareTunnelFinalizer = "tunnel.se.quencer.io/cloudflare"
-const kCloudflareTunnelTokenEnvName = "CLOUDFLARE_TUNNEL_TOKEN"
 const kCloudflareTunnelIDKey = "TunnelID"
+
+// Fingerprint of the routes the tunnel was last configured with, used to detect when they change.
+const kCloudflareRoutesKey = "Routes"
+
+// Name of the secret that holds the token of the tunnel.
+const kCloudflareTunnelSecretKey = "TunnelSecret"
+
+// Workspaces created before the token was stored in a secret have it in their status.
 const kCloudflareTunnelTokenKey = "TunnelToken"
-const kCloudflareConnectorLabel = "tunneling.se.quencer.io/connector"
+
+const kCloudflareConnectorLabel = ConnectorLabel
 const kConnectorGracefulTermination = time.Second * 5
 
 var cfTunnelDNSContentFmt = "%s.cfargotunnel.com"
@@ -92,21 +104,19 @@ func (c cf) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 			}
 
 			status.Ingress = fmt.Sprintf(cfTunnelDNSContentFmt, tunnel.ID)
-			status.DNS = append(status.DNS, workspaces.DNS{
-				Name:       status.Host,
-				RecordType: "CNAME",
-				Target:     status.Ingress,
-				Properties: map[string]string{
-					"proxied": "true",
-				},
-			})
+			status.DNS = append(status.DNS, tunnelRecords(status.Host, status.Ingress, tunnelSpec.AllRoutes())...)
+
+			secret, err := c.createTokenSecret(ctx, token)
+			if err != nil {
+				return "", "", err
+			}
 
 			status.Tunnel = &workspaces.Tunnel{
 				RemoteID: tunnel.ID,
 				ProviderMeta: map[string]string{
-					kCloudflareConnectorLabel: "cloudflared",
-					kCloudflareTunnelIDKey:    tunnel.ID,
-					kCloudflareTunnelTokenKey: token,
+					kCloudflareConnectorLabel:  "cloudflared",
+					kCloudflareTunnelIDKey:     tunnel.ID,
+					kCloudflareTunnelSecretKey: secret.Name,
 				},
 			}
 			return conditions.ConditionCreated, "Tunnel created on Cloudflare", c.Update(ctx, *status)
@@ -114,34 +124,56 @@ func (c cf) Reconcile(ctx context.Context) (_ *ctrl.Result, err error) {
 	}
 
 	if condition.Status == conditions.ConditionCreated {
-		var service *core.Service
-		service, err = c.getServiceFor(ctx, c.Workspace(), tunnelSpec.Route)
+		routes := tunnelSpec.AllRoutes()
+		services, missing, err := c.routeServices(ctx, routes)
 		if err != nil {
 			return nil, err
 		}
 
-		if service == nil {
-			c.Eventf(core.EventTypeNormal, "Tunneling", "Waiting for (%s:%s) service", tunnelSpec.Route.ComponentName, tunnelSpec.Route.NetworkName)
+		if missing != nil {
+			c.Eventf(core.EventTypeNormal, "Tunneling", "Waiting for (%s:%s) service", missing.ComponentName, missing.NetworkName)
 			return nil, nil
 		}
 
 		return &ctrl.Result{}, c.Guard(ctx, "Configuring DNS with the tunnel", func() (conditions.ConditionStatus, string, error) {
-			status := &c.Workspace().Status
-			if err := c.attachTunnelToDNSRecord(ctx, c.Workspace(), service); err != nil {
+			if err := c.attachTunnelToDNSRecord(ctx, c.Workspace(), routes, services); err != nil {
 				return "", "", fmt.Errorf("E#3008: Could not attach tunnel to the DNS record -- %w", err)
 			}
+			c.Workspace().Status.Tunnel.ProviderMeta[kCloudflareRoutesKey] = routesFingerprint(routes)
 
-			c.Eventf(core.EventTypeNormal, "Tunneling", "Tunnel now pointing to service (%s)", service.Name)
-			pod, err := c.deployConnector(ctx, status.Tunnel.ProviderMeta[kCloudflareTunnelTokenKey])
+			c.Eventf(core.EventTypeNormal, "Tunneling", "Tunnel now pointing to %d route(s)", len(routes))
+			if err := c.protectWithAccess(ctx); err != nil {
+				return "", "", err
+			}
+
+			deployment, err := c.deployConnector(ctx)
 			if err != nil {
 				return "", "", err
 			}
 
-			c.Eventf(core.EventTypeNormal, "Tunneling", "Connector deployed (%s)", pod.Name)
-			return conditions.ConditionCompleted, "Tunnel ready to use", nil
+			c.Eventf(core.EventTypeNormal, "Tunneling", "Connector deployed (%s)", deployment.Name)
+			return conditions.ConditionInProgress, "Waiting for the connector to be ready", nil
 		})
 	}
 
+	switch condition.Status {
+	case conditions.ConditionInProgress, conditions.ConditionCompleted, conditions.ConditionNotHealthy:
+		// Access can be added, changed or removed after the tunnel was created.
+		if c.accessChanged() {
+			return &ctrl.Result{}, c.Guard(ctx, "Syncing Cloudflare Access", func() (conditions.ConditionStatus, string, error) {
+				return condition.Status, condition.Reason, c.syncAccess(ctx)
+			})
+		}
+
+		if c.Workspace().Status.Tunnel.ProviderMeta[kCloudflareRoutesKey] != routesFingerprint(tunnelSpec.AllRoutes()) {
+			if result, err := c.updateRoutes(ctx, condition); result != nil || err != nil {
+				return result, err
+			}
+		}
+
+		return c.monitorConnector(ctx)
+	}
+
 	return nil, nil
 }
 
@@ -157,6 +189,16 @@ func (c cf) Terminate(ctx context.Context) (_ *ctrl.Result, err error) {
 	}
 
 	// Need to tear down the connector first as the Tunnel cannot be deleted with an active connection.
+	deployment := &apps.Deployment{}
+	err = c.Get(ctx, types.NamespacedName{Name: connectorName(workspace), Namespace: workspace.Namespace}, deployment)
+	if err == nil && deployment.DeletionTimestamp.IsZero() {
+		if err := c.Delete(ctx, deployment); err != nil {
+			return nil, err
+		}
+	} else if err != nil && !k8sErrors.IsNotFound(err) {
+		return nil, err
+	}
+
 	var pods core.PodList
 	selector, err := labels.Parse(fmt.Sprintf("%s=%s,%s=%s", workspaces.InstanceLabel, workspace.Name, kCloudflareConnectorLabel, workspace.Status.Tunnel.ProviderMeta[kCloudflareConnectorLabel]))
 	if err != nil {
@@ -190,6 +232,12 @@ func (c cf) Terminate(ctx context.Context) (_ *ctrl.Result, err error) {
 	}
 
 	return nil, c.Guard(ctx, "Deleting tunnel on Cloudflare", func() (status conditions.ConditionStatus, reason string, err error) {
+		if err := c.removeAccess(ctx); err != nil {
+			logger.Error(err, "E#3031: Could not delete the Access application")
+			c.Event(core.EventTypeWarning, string(c.Condition().Type), err.Error())
+			return "", "", err
+		}
+
 		tunnelID := workspace.Status.Tunnel.RemoteID
 		err = c.api.DeleteTunnel(ctx, cloudflare.AccountIdentifier(c.accountID), tunnelID)
 		if err != nil {
@@ -202,43 +250,84 @@ func (c cf) Terminate(ctx context.Context) (_ *ctrl.Result, err error) {
 	})
 }
 
-func (c cf) serviceEndpoint(routeSpec *tunneling.CloudflareRouteSpec, service *core.Service) string {
-	protocol := routeSpec.Protocol
-	if protocol == "" {
-		protocol = "http"
+// Apply the routes to a tunnel that was already configured: the ingress of the tunnel, the DNS records of the
+// hosts and the Access application are updated. Returns nil when the services of the routes don't exist yet.
+func (c cf) updateRoutes(ctx context.Context, condition conditions.Condition) (*ctrl.Result, error) {
+	routes := c.Workspace().Spec.Networking.Tunnel.Cloudflare.AllRoutes()
+	services, missing, err := c.routeServices(ctx, routes)
+	if err != nil || missing != nil {
+		return nil, err
 	}
 
-	return fmt.Sprintf("%s://%s:%d", protocol, service.Name, service.Spec.Ports[0].Port)
+	return &ctrl.Result{}, c.Guard(ctx, "Updating the routes of the tunnel", func() (conditions.ConditionStatus, string, error) {
+		status := &c.Workspace().Status
+		if err := c.attachTunnelToDNSRecord(ctx, c.Workspace(), routes, services); err != nil {
+			return "", "", fmt.Errorf("E#3008: Could not attach tunnel to the DNS record -- %w", err)
+		}
+
+		if err := c.updateAccessHosts(ctx); err != nil {
+			return "", "", err
+		}
+
+		// The DNS records created for the previous hosts are replaced by the workspace's DNS reconciler.
+		status.DNS = tunnelRecords(status.Host, status.Ingress, routes)
+		status.Tunnel.ProviderMeta[kCloudflareRoutesKey] = routesFingerprint(routes)
+
+		c.Eventf(core.EventTypeNormal, "Tunneling", "Tunnel now pointing to %d route(s)", len(routes))
+		return condition.Status, condition.Reason, c.Update(ctx, *status)
+	})
 }
 
-func (cf *cf) getServiceFor(ctx context.Context, workspace *sequencer.Workspace, routeSpec tunneling.CloudflareRouteSpec) (*core.Service, error) {
-	var services core.ServiceList
-	var service *core.Service
+// Returns the service of each route. If the service of a route doesn't exist yet, that route is returned instead.
+func (c cf) routeServices(ctx context.Context, routes []tunneling.CloudflareRouteSpec) ([]*core.Service, *tunneling.CloudflareRouteSpec, error) {
+	services := make([]*core.Service, len(routes))
+	for i := range routes {
+		service, err := serviceFor(ctx, c, routes[i].ComponentName, routes[i].NetworkName)
+		if err != nil {
+			return nil, nil, err
+		}
 
-	selector, err := labels.Parse(fmt.Sprintf("%s=%s", workspaces.InstanceLabel, workspace.Name))
-	if err != nil {
-		return nil, err
+		if service == nil {
+			return nil, &routes[i], nil
+		}
+		services[i] = service
 	}
 
-	err = cf.List(ctx, &services, &client.ListOptions{
-		Namespace:     workspace.Namespace,
-		LabelSelector: selector,
-	})
+	return services, nil, nil
+}
 
-	if err != nil {
-		cf.Event(core.EventTypeWarning, "Fetching Services", err.Error())
-		return nil, err
+// Proxied CNAME records pointing each host of the routes to the tunnel.
+func tunnelRecords(host, ingress string, routes []tunneling.CloudflareRouteSpec) []workspaces.DNS {
+	var records []workspaces.DNS
+	for _, h := range routeHosts(host, routes) {
+		records = append(records, workspaces.DNS{
+			Name:       h,
+			RecordType: "CNAME",
+			Target:     ingress,
+			Properties: map[string]string{
+				"proxied": "true",
+			},
+		})
 	}
 
-	for i := range services.Items {
-		s := &services.Items[i]
-		if s.Labels[components.NameLabel] == routeSpec.ComponentName && s.Labels[components.NetworkLabel] == routeSpec.NetworkName {
-			service = s
-			break
-		}
+	return records
+}
+
+// Routes are compared through their fingerprint as the workspace's status only holds strings for the provider.
+func routesFingerprint(routes []tunneling.CloudflareRouteSpec) string {
+	data, _ := json.Marshal(routes)
+	hash := fnv.New64a()
+	hash.Write(data)
+	return fmt.Sprintf("%x", hash.Sum64())
+}
+
+func serviceEndpoint(routeSpec *tunneling.CloudflareRouteSpec, service *core.Service) string {
+	protocol := routeSpec.Protocol
+	if protocol == "" {
+		protocol = "http"
 	}
 
-	return service, err
+	return fmt.Sprintf("%s://%s:%d", protocol, service.Name, service.Spec.Ports[0].Port)
 }
 
 func (c cf) createTunnel(ctx context.Context, workspace *sequencer.Workspace) (token string, _ *cloudflare.Tunnel, err error) {
@@ -259,54 +348,77 @@ func (c cf) createTunnel(ctx context.Context, workspace *sequencer.Workspace) (t
 	return token, &tunnel, nil
 }
 
-func (c cf) attachTunnelToDNSRecord(ctx context.Context, workspace *sequencer.Workspace, service *core.Service) error {
-	spec := workspace.Spec.Networking.Tunnel.Cloudflare
+func (c cf) attachTunnelToDNSRecord(ctx context.Context, workspace *sequencer.Workspace, routes []tunneling.CloudflareRouteSpec, services []*core.Service) error {
 	tunnel := workspace.Status.Tunnel
 	_, err := c.api.UpdateTunnelConfiguration(ctx, cloudflare.AccountIdentifier(c.accountID), cloudflare.TunnelConfigurationParams{
 		TunnelID: tunnel.ProviderMeta[kCloudflareTunnelIDKey],
 		Config: cloudflare.TunnelConfiguration{
-			Ingress: []cloudflare.UnvalidatedIngressRule{
-				{
-					Path:     spec.Route.Path,
-					Hostname: workspace.Status.Host,
-					Service:  c.serviceEndpoint(&spec.Route, service),
-				},
-				{
-					Service: "http_status:404",
-				},
-			},
+			Ingress: ingressRules(workspace.Status.Host, routes, services),
 		},
 	})
 
 	return err
 }
 
-func (c cf) deployConnector(ctx context.Context, token string) (*core.Pod, error) {
-	pod := &core.Pod{
-		ObjectMeta: meta.ObjectMeta{
-			Namespace:    c.Namespace(),
-			GenerateName: "tunnel-cloudflare-",
-			Labels: map[string]string{
-				kCloudflareConnectorLabel: "cloudflared",
-			},
-		},
-		Spec: core.PodSpec{
-			Containers: []core.Container{{
-				Name:  "cloudflared",
-				Image: "cloudflare/cloudflared:latest",
-				Args: []string{
-					"tunnel",
-					"--no-autoupdate",
-					"run",
-					"--token",
-					fmt.Sprintf("$(%s)", kCloudflareTunnelTokenEnvName),
-				},
-				Env: []core.EnvVar{{
-					Name:  kCloudflareTunnelTokenEnvName,
-					Value: token,
-				}},
-			}},
-		}}
-
-	return pod, c.Create(ctx, pod)
+// Returns the ingress rules of the tunnel, one for each route, followed by a catch-all. Cloudflare uses the
+// first rule that matches a request, the routes with a path go first so they aren't shadowed by a route for the same host.
+func ingressRules(host string, routes []tunneling.CloudflareRouteSpec, services []*core.Service) []cloudflare.UnvalidatedIngressRule {
+	rules := make([]cloudflare.UnvalidatedIngressRule, len(routes))
+	for i, route := range routes {
+		rules[i] = cloudflare.UnvalidatedIngressRule{
+			Path:          route.Path,
+			Hostname:      routeHost(host, route),
+			Service:       serviceEndpoint(&route, services[i]),
+			OriginRequest: originRequest(route.OriginRequest),
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		return rules[i].Path != "" && rules[j].Path == ""
+	})
+
+	return append(rules, cloudflare.UnvalidatedIngressRule{
+		Service: "http_status:404",
+	})
+}
+
+func originRequest(spec *tunneling.CloudflareOriginRequestSpec) *cloudflare.OriginRequestConfig {
+	if spec == nil {
+		return nil
+	}
+
+	config := &cloudflare.OriginRequestConfig{
+		NoTLSVerify: spec.NoTLSVerify,
+	}
+
+	if spec.HTTPHostHeader != "" {
+		config.HTTPHostHeader = &spec.HTTPHostHeader
+	}
+
+	if spec.ConnectTimeout != nil {
+		config.ConnectTimeout = &cloudflare.TunnelDuration{Duration: spec.ConnectTimeout.Duration}
+	}
+
+	return config
+}
+
+// Host the route answers to.
+func routeHost(host string, route tunneling.CloudflareRouteSpec) string {
+	if route.Subdomain == "" {
+		return host
+	}
+
+	return fmt.Sprintf("%s.%s", route.Subdomain, host)
+}
+
+// Returns each host the routes answer to, once.
+func routeHosts(host string, routes []tunneling.CloudflareRouteSpec) []string {
+	var hosts []string
+	for _, route := range routes {
+		if h := routeHost(host, route); !slices.Contains(hosts, h) {
+			hosts = append(hosts, h)
+		}
+	}
+
+	return hosts
 }
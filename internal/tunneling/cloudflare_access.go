@@ -0,0 +1,257 @@
+package tunneling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/cloudflare/cloudflare-go"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	core "k8s.io/api/core/v1"
+)
+
+// ID of the Access application protecting the hosts of the tunnel. The policies
+// belong to the application and are deleted with it.
+const kCloudflareAccessApplicationIDKey = "AccessApplicationID"
+
+// Fingerprint of the Access spec the application was last synced with. It's only stored once every
+// policy exists so a sync that failed halfway is retried.
+const kCloudflareAccessKey = "Access"
+
+// Returns true when the Access application doesn't match the spec anymore, including when the
+// workspace stopped being protected by Access.
+func (c cf) accessChanged() bool {
+	access := c.Workspace().Spec.Networking.Tunnel.Cloudflare.Access
+	meta := c.Workspace().Status.Tunnel.ProviderMeta
+	if access == nil {
+		return meta[kCloudflareAccessApplicationIDKey] != ""
+	}
+
+	return meta[kCloudflareAccessKey] != accessFingerprint(access)
+}
+
+// Brings the Access application in line with the spec: it's created, updated along with its policies, or
+// deleted when the workspace isn't protected by Access anymore.
+func (c cf) syncAccess(ctx context.Context) error {
+	if c.Workspace().Spec.Networking.Tunnel.Cloudflare.Access == nil {
+		return c.unprotect(ctx)
+	}
+
+	return c.protectWithAccess(ctx)
+}
+
+// Creates the Access application for every host of the tunnel, or updates the one that exists, and syncs its
+// policies. It does nothing if the workspace isn't protected by Access or if the application is up to date.
+func (c cf) protectWithAccess(ctx context.Context) error {
+	spec := c.Workspace().Spec.Networking.Tunnel.Cloudflare
+	status := &c.Workspace().Status
+	if spec.Access == nil || !c.accessChanged() {
+		return nil
+	}
+
+	applicationID := status.Tunnel.ProviderMeta[kCloudflareAccessApplicationIDKey]
+	if applicationID == "" {
+		rc := cloudflare.AccountIdentifier(c.accountID)
+		hosts := routeHosts(status.Host, spec.AllRoutes())
+		params := cloudflare.CreateAccessApplicationParams{
+			Name:              c.Workspace().Name,
+			Domain:            hosts[0],
+			SelfHostedDomains: hosts,
+			Type:              cloudflare.SelfHosted,
+		}
+		if spec.Access.SessionDuration != nil {
+			params.SessionDuration = spec.Access.SessionDuration.Duration.String()
+		}
+
+		application, err := c.api.CreateAccessApplication(ctx, rc, params)
+		if err != nil {
+			return fmt.Errorf("E#3030: Could not create the Access application -- %w", err)
+		}
+
+		// Store the application right away so it's deleted with the workspace even if a policy can't be created.
+		applicationID = application.ID
+		status.Tunnel.ProviderMeta[kCloudflareAccessApplicationIDKey] = applicationID
+		if err := c.Update(ctx, *status); err != nil {
+			return err
+		}
+
+		c.Eventf(core.EventTypeNormal, "Tunneling", "Access application created for %d host(s)", len(hosts))
+	} else if err := c.updateAccessHosts(ctx); err != nil {
+		return err
+	}
+
+	if err := c.syncAccessPolicies(ctx, applicationID, spec.Access); err != nil {
+		return err
+	}
+
+	status.Tunnel.ProviderMeta[kCloudflareAccessKey] = accessFingerprint(spec.Access)
+	return c.Update(ctx, *status)
+}
+
+// Policies are matched by name with the ones of the application: the ones that exist are updated, the missing ones
+// are created and the ones the spec doesn't need anymore are deleted.
+func (c cf) syncAccessPolicies(ctx context.Context, applicationID string, spec *tunneling.CloudflareAccessSpec) error {
+	rc := cloudflare.AccountIdentifier(c.accountID)
+	existing, _, err := c.api.ListAccessPolicies(ctx, rc, cloudflare.ListAccessPoliciesParams{ApplicationID: applicationID})
+	if err != nil {
+		return fmt.Errorf("E#3030: Could not retrieve the Access policies -- %w", err)
+	}
+
+	ids := map[string]string{}
+	for _, policy := range existing {
+		ids[policy.Name] = policy.ID
+	}
+
+	for _, policy := range accessPolicies(applicationID, spec) {
+		id, ok := ids[policy.Name]
+		delete(ids, policy.Name)
+
+		if !ok {
+			if _, err := c.api.CreateAccessPolicy(ctx, rc, policy); err != nil {
+				return fmt.Errorf("E#3030: Could not create the Access policy (%s) -- %w", policy.Name, err)
+			}
+			continue
+		}
+
+		_, err := c.api.UpdateAccessPolicy(ctx, rc, cloudflare.UpdateAccessPolicyParams{
+			ApplicationID: applicationID,
+			PolicyID:      id,
+			Precedence:    policy.Precedence,
+			Decision:      policy.Decision,
+			Name:          policy.Name,
+			Include:       policy.Include,
+		})
+		if err != nil {
+			return fmt.Errorf("E#3030: Could not update the Access policy (%s) -- %w", policy.Name, err)
+		}
+	}
+
+	for name, id := range ids {
+		if err := c.api.DeleteAccessPolicy(ctx, rc, cloudflare.DeleteAccessPolicyParams{ApplicationID: applicationID, PolicyID: id}); err != nil {
+			return fmt.Errorf("E#3030: Could not delete the Access policy (%s) -- %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// Points the Access application to the hosts of the tunnel after its routes changed. The application is
+// replaced as a whole by Cloudflare, its settings are sent along with the hosts.
+func (c cf) updateAccessHosts(ctx context.Context) error {
+	spec := c.Workspace().Spec.Networking.Tunnel.Cloudflare
+	status := &c.Workspace().Status
+	applicationID := status.Tunnel.ProviderMeta[kCloudflareAccessApplicationIDKey]
+	if spec.Access == nil || applicationID == "" {
+		return nil
+	}
+
+	hosts := routeHosts(status.Host, spec.AllRoutes())
+	params := cloudflare.UpdateAccessApplicationParams{
+		ID:                applicationID,
+		Name:              c.Workspace().Name,
+		Domain:            hosts[0],
+		SelfHostedDomains: hosts,
+		Type:              cloudflare.SelfHosted,
+	}
+	if spec.Access.SessionDuration != nil {
+		params.SessionDuration = spec.Access.SessionDuration.Duration.String()
+	}
+
+	if _, err := c.api.UpdateAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), params); err != nil {
+		return fmt.Errorf("E#3030: Could not update the Access application -- %w", err)
+	}
+
+	return nil
+}
+
+// Deletes the Access application of a workspace that isn't protected by Access anymore.
+func (c cf) unprotect(ctx context.Context) error {
+	if err := c.removeAccess(ctx); err != nil {
+		return err
+	}
+
+	status := &c.Workspace().Status
+	delete(status.Tunnel.ProviderMeta, kCloudflareAccessApplicationIDKey)
+	delete(status.Tunnel.ProviderMeta, kCloudflareAccessKey)
+	if err := c.Update(ctx, *status); err != nil {
+		return err
+	}
+
+	c.Event(core.EventTypeNormal, "Tunneling", "Access application deleted")
+	return nil
+}
+
+// Deletes the Access application of the tunnel, if it has one. An application that was already deleted is ignored.
+func (c cf) removeAccess(ctx context.Context) error {
+	applicationID := c.Workspace().Status.Tunnel.ProviderMeta[kCloudflareAccessApplicationIDKey]
+	if applicationID == "" {
+		return nil
+	}
+
+	err := c.api.DeleteAccessApplication(ctx, cloudflare.AccountIdentifier(c.accountID), applicationID)
+	var notFound *cloudflare.NotFoundError
+	if err != nil && !errors.As(err, &notFound) {
+		return fmt.Errorf("E#3031: Could not delete the Access application (ID: %s) -- %w", applicationID, err)
+	}
+
+	return nil
+}
+
+// Users are allowed through by their email domain or their groups. Service tokens need a policy of their own
+// as Cloudflare only lets them authenticate through the `non_identity` decision.
+func accessPolicies(applicationID string, spec *tunneling.CloudflareAccessSpec) []cloudflare.CreateAccessPolicyParams {
+	var users []interface{}
+	for _, domain := range spec.EmailDomains {
+		rule := cloudflare.AccessGroupEmailDomain{}
+		rule.EmailDomain.Domain = domain
+		users = append(users, rule)
+	}
+
+	for _, group := range spec.Groups {
+		rule := cloudflare.AccessGroupAccessGroup{}
+		rule.Group.ID = group
+		users = append(users, rule)
+	}
+
+	var tokens []interface{}
+	for _, token := range spec.ServiceTokens {
+		rule := cloudflare.AccessGroupServiceToken{}
+		rule.ServiceToken.ID = token
+		tokens = append(tokens, rule)
+	}
+
+	var policies []cloudflare.CreateAccessPolicyParams
+	if len(users) > 0 {
+		policies = append(policies, cloudflare.CreateAccessPolicyParams{
+			ApplicationID: applicationID,
+			Name:          "Sequencer users",
+			Decision:      "allow",
+			Include:       users,
+		})
+	}
+
+	if len(tokens) > 0 {
+		policies = append(policies, cloudflare.CreateAccessPolicyParams{
+			ApplicationID: applicationID,
+			Name:          "Sequencer service tokens",
+			Decision:      "non_identity",
+			Include:       tokens,
+		})
+	}
+
+	for i := range policies {
+		policies[i].Precedence = i + 1
+	}
+
+	return policies
+}
+
+// The spec is compared through its fingerprint, like the routes, as the workspace's status only holds strings for the provider.
+func accessFingerprint(spec *tunneling.CloudflareAccessSpec) string {
+	data, _ := json.Marshal(spec)
+	hash := fnv.New64a()
+	hash.Write(data)
+	return fmt.Sprintf("%x", hash.Sum64())
+}
@@ -0,0 +1,280 @@
+package tunneling
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"github.com/pier-oliviert/sequencer/internal/integrations"
+	apps "k8s.io/api/apps/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Cloudflare Access", func() {
+	It("allows users by email domain and group", func() {
+		policies := accessPolicies("app", &tunneling.CloudflareAccessSpec{
+			EmailDomains: []string{"example.com"},
+			Groups:       []string{"engineering"},
+		})
+		Expect(policies).To(HaveLen(1))
+		Expect(policies[0].ApplicationID).To(Equal("app"))
+		Expect(policies[0].Decision).To(Equal("allow"))
+		Expect(policies[0].Precedence).To(Equal(1))
+		Expect(policies[0].Include).To(HaveLen(2))
+
+		domain := policies[0].Include[0].(cloudflare.AccessGroupEmailDomain)
+		Expect(domain.EmailDomain.Domain).To(Equal("example.com"))
+		group := policies[0].Include[1].(cloudflare.AccessGroupAccessGroup)
+		Expect(group.Group.ID).To(Equal("engineering"))
+	})
+
+	It("uses a policy of their own for service tokens", func() {
+		policies := accessPolicies("app", &tunneling.CloudflareAccessSpec{
+			EmailDomains:  []string{"example.com"},
+			ServiceTokens: []string{"ci"},
+		})
+		Expect(policies).To(HaveLen(2))
+		Expect(policies[1].Decision).To(Equal("non_identity"))
+		Expect(policies[1].Precedence).To(Equal(2))
+
+		token := policies[1].Include[0].(cloudflare.AccessGroupServiceToken)
+		Expect(token.ServiceToken.ID).To(Equal("ci"))
+	})
+
+	It("only creates the policies it needs", func() {
+		policies := accessPolicies("app", &tunneling.CloudflareAccessSpec{ServiceTokens: []string{"ci"}})
+		Expect(policies).To(HaveLen(1))
+		Expect(policies[0].Decision).To(Equal("non_identity"))
+		Expect(policies[0].Precedence).To(Equal(1))
+	})
+})
+
+var _ = Describe("Cloudflare Access application", func() {
+	ctx := context.Background()
+	const applicationPath = "/accounts/account/access/apps/app"
+
+	var server *httptest.Server
+	var requests []string
+	var policies map[string]cloudflare.AccessPolicy
+	var failing string
+	var c client.Client
+	var workspace *sequencer.Workspace
+
+	users := &tunneling.CloudflareAccessSpec{EmailDomains: []string{"example.com"}}
+
+	// Keeps the policies of the application in memory. Creating the policy named by failing returns an error.
+	handle := func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, fmt.Sprintf("%s %s", r.Method, r.URL.Path))
+
+		var policy cloudflare.AccessPolicy
+		if r.Method == http.MethodPost || r.Method == http.MethodPut {
+			Expect(json.NewDecoder(r.Body).Decode(&policy)).To(Succeed())
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, applicationPath+"/policies/")
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == applicationPath+"/policies":
+			if policy.Name == failing {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"success": false, "errors": [{"code": 12130, "message": "invalid policy"}]}`))
+				return
+			}
+			policy.ID = fmt.Sprintf("policy-%d", len(requests))
+			policies[policy.ID] = policy
+		case r.Method == http.MethodPut && id != r.URL.Path:
+			policy.ID = id
+			policies[id] = policy
+		case r.Method == http.MethodDelete && id != r.URL.Path:
+			delete(policies, id)
+		case r.Method == http.MethodGet:
+			var list []cloudflare.AccessPolicy
+			for _, policy := range policies {
+				list = append(list, policy)
+			}
+			data, _ := json.Marshal(list)
+			_, _ = fmt.Fprintf(w, `{"success": true, "result": %s}`, data)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"success": true, "result": {"id": "app"}}`))
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		policies = map[string]cloudflare.AccessPolicy{}
+		failing = ""
+		server = httptest.NewServer(http.HandlerFunc(handle))
+
+		web := tunneling.CloudflareRouteSpec{ComponentName: "web", NetworkName: "http"}
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Spec.Networking.DNS.Zone = "example.com"
+		workspace.Spec.Networking.Tunnel = &workspaces.TunnelSpec{Cloudflare: &tunneling.CloudflareTunnelSpec{
+			AccountID: "account",
+			Routes:    []tunneling.CloudflareRouteSpec{web},
+			Access:    users.DeepCopy(),
+		}}
+		workspace.Status.Host = "my-workspace.example.com"
+		workspace.Status.Tunnel = &workspaces.Tunnel{
+			RemoteID: "abcd",
+			ProviderMeta: map[string]string{
+				kCloudflareTunnelIDKey: "abcd",
+				kCloudflareRoutesKey:   routesFingerprint([]tunneling.CloudflareRouteSpec{web}),
+			},
+		}
+		workspace.Status.Conditions = []conditions.Condition{{
+			Type:   workspaces.TunnelingCondition,
+			Status: conditions.ConditionCompleted,
+			Reason: "Tunnel ready to use, 1/1 connectors ready",
+		}}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	connector := func() *apps.Deployment {
+		deployment := connectorDeployment(workspace, "my-workspace-cloudflared")
+		deployment.Namespace = "default"
+		deployment.Status.ReadyReplicas = 1
+		return deployment
+	}
+
+	// The application already exists with the policies of the spec.
+	protected := func() {
+		policies["users"] = cloudflare.AccessPolicy{ID: "users", Name: "Sequencer users", Decision: "allow"}
+		workspace.Status.Tunnel.ProviderMeta[kCloudflareAccessApplicationIDKey] = "app"
+		workspace.Status.Tunnel.ProviderMeta[kCloudflareAccessKey] = accessFingerprint(users)
+	}
+
+	provider := func() cf {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(workspace), workspace)).To(Succeed())
+		condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.TunnelingCondition)
+
+		api, err := cloudflare.NewWithAPIToken("token", cloudflare.BaseURL(server.URL))
+		Expect(err).NotTo(HaveOccurred())
+
+		return cf{
+			api:                api,
+			accountID:          "account",
+			ProviderController: integrations.NewController(workspace, *condition, reconciler{c, record.NewFakeRecorder(10)}),
+		}
+	}
+
+	reconcile := func() {
+		_, err := provider().Reconcile(ctx)
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	// Changes to the spec need to be stored as updating the status reloads the workspace.
+	update := func(access *tunneling.CloudflareAccessSpec) {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(workspace), workspace)).To(Succeed())
+		workspace.Spec.Networking.Tunnel.Cloudflare.Access = access
+		Expect(c.Update(ctx, workspace)).To(Succeed())
+	}
+
+	policyNames := func() []string {
+		var names []string
+		for _, policy := range policies {
+			names = append(names, policy.Name)
+		}
+		return names
+	}
+
+	It("leaves an application that is up to date alone", func() {
+		protected()
+		c = newClient(workspace, connector())
+
+		reconcile()
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("creates the policies that couldn't be created by a previous attempt", func() {
+		workspace.Spec.Networking.Tunnel.Cloudflare.Access.ServiceTokens = []string{"ci"}
+		c = newClient(workspace, connector())
+
+		failing = "Sequencer service tokens"
+		Expect(provider().protectWithAccess(ctx)).To(MatchError(ContainSubstring("E#3030")))
+		Expect(workspace.Status.Tunnel.ProviderMeta[kCloudflareAccessApplicationIDKey]).To(Equal("app"))
+		Expect(workspace.Status.Tunnel.ProviderMeta).NotTo(HaveKey(kCloudflareAccessKey))
+		Expect(policyNames()).To(ConsistOf("Sequencer users"))
+
+		failing = ""
+		Expect(provider().protectWithAccess(ctx)).To(Succeed())
+		Expect(policyNames()).To(ConsistOf("Sequencer users", "Sequencer service tokens"))
+		Expect(requests).To(ContainElement("PUT " + applicationPath))
+
+		var created int
+		for _, request := range requests {
+			if request == "POST /accounts/account/access/apps" {
+				created++
+			}
+		}
+		Expect(created).To(Equal(1))
+
+		requests = nil
+		reconcile()
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("syncs the policies when the spec changes", func() {
+		protected()
+		c = newClient(workspace, connector())
+
+		update(&tunneling.CloudflareAccessSpec{
+			EmailDomains:    []string{"example.org"},
+			ServiceTokens:   []string{"ci"},
+			SessionDuration: &meta.Duration{Duration: time.Hour},
+		})
+		reconcile()
+		Expect(requests).To(ContainElements("PUT "+applicationPath, "PUT "+applicationPath+"/policies/users"))
+		Expect(policyNames()).To(ConsistOf("Sequencer users", "Sequencer service tokens"))
+
+		rule := policies["users"].Include[0].(map[string]interface{})
+		Expect(rule["email_domain"]).To(Equal(map[string]interface{}{"domain": "example.org"}))
+
+		requests = nil
+		reconcile()
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("deletes the policies the spec doesn't need anymore", func() {
+		protected()
+		c = newClient(workspace, connector())
+
+		update(&tunneling.CloudflareAccessSpec{ServiceTokens: []string{"ci"}})
+		reconcile()
+		Expect(requests).To(ContainElement("DELETE " + applicationPath + "/policies/users"))
+		Expect(policyNames()).To(ConsistOf("Sequencer service tokens"))
+	})
+
+	It("deletes the application when Access is removed", func() {
+		protected()
+		c = newClient(workspace, connector())
+
+		update(nil)
+		reconcile()
+		Expect(requests).To(Equal([]string{"DELETE " + applicationPath}))
+		Expect(workspace.Status.Tunnel.ProviderMeta).NotTo(HaveKey(kCloudflareAccessApplicationIDKey))
+		Expect(workspace.Status.Tunnel.ProviderMeta).NotTo(HaveKey(kCloudflareAccessKey))
+		Expect(conditions.FindCondition(workspace.Status.Conditions, workspaces.TunnelingCondition).Status).To(Equal(conditions.ConditionCompleted))
+
+		requests = nil
+		reconcile()
+		Expect(requests).To(BeEmpty())
+	})
+})
@@ -0,0 +1,201 @@
+package tunneling
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cloudflare/cloudflare-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/components"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	"github.com/pier-oliviert/sequencer/internal/integrations"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var _ = Describe("Cloudflare", func() {
+	const host = "my-workspace.example.com"
+
+	service := func(name string, port int32) *core.Service {
+		service := &core.Service{}
+		service.Name = name
+		service.Spec.Ports = []core.ServicePort{{Port: port}}
+		return service
+	}
+
+	routes := []tunneling.CloudflareRouteSpec{
+		{ComponentName: "web", NetworkName: "http"},
+		{Subdomain: "api", ComponentName: "api", NetworkName: "http", Protocol: "https", OriginRequest: &tunneling.CloudflareOriginRequestSpec{
+			NoTLSVerify:    new(bool),
+			HTTPHostHeader: "api.internal",
+			ConnectTimeout: &meta.Duration{Duration: 10 * time.Second},
+		}},
+		{Path: "/cable", ComponentName: "cable", NetworkName: "ws"},
+	}
+	services := []*core.Service{service("web-http", 3000), service("api-http", 8443), service("cable-ws", 28080)}
+
+	It("creates an ingress rule for each route", func() {
+		rules := ingressRules(host, routes, services)
+		Expect(rules).To(HaveLen(4))
+
+		Expect(rules[0].Hostname).To(Equal(host))
+		Expect(rules[0].Path).To(Equal("/cable"))
+		Expect(rules[0].Service).To(Equal("http://cable-ws:28080"))
+
+		Expect(rules[1].Hostname).To(Equal(host))
+		Expect(rules[1].Service).To(Equal("http://web-http:3000"))
+		Expect(rules[1].OriginRequest).To(BeNil())
+
+		Expect(rules[2].Hostname).To(Equal("api." + host))
+		Expect(rules[2].Service).To(Equal("https://api-http:8443"))
+		Expect(*rules[2].OriginRequest.HTTPHostHeader).To(Equal("api.internal"))
+		Expect(rules[2].OriginRequest.ConnectTimeout).To(Equal(&cloudflare.TunnelDuration{Duration: 10 * time.Second}))
+		Expect(*rules[2].OriginRequest.NoTLSVerify).To(BeFalse())
+
+		Expect(rules[3]).To(Equal(cloudflare.UnvalidatedIngressRule{Service: "http_status:404"}))
+	})
+
+	It("returns each host once", func() {
+		Expect(routeHosts(host, routes)).To(Equal([]string{host, "api." + host}))
+	})
+
+	It("supports the single route", func() {
+		spec := tunneling.CloudflareTunnelSpec{
+			Route:  tunneling.CloudflareRouteSpec{ComponentName: "web", NetworkName: "http"},
+			Routes: routes[1:],
+		}
+		Expect(spec.AllRoutes()).To(HaveLen(3))
+		Expect(spec.AllRoutes()[0].ComponentName).To(Equal("web"))
+	})
+})
+
+var _ = Describe("Cloudflare tunnel", func() {
+	ctx := context.Background()
+
+	var server *httptest.Server
+	var configurations []cloudflare.TunnelConfigurationParams
+	var c client.Client
+	var workspace *sequencer.Workspace
+
+	service := func(component string) *core.Service {
+		service := &core.Service{}
+		service.Name = component + "-http"
+		service.Namespace = "default"
+		service.Labels = map[string]string{
+			workspaces.InstanceLabel: "my-workspace",
+			components.NameLabel:     component,
+			components.NetworkLabel:  "http",
+		}
+		service.Spec.Ports = []core.ServicePort{{Port: 8080}}
+		return service
+	}
+
+	web := tunneling.CloudflareRouteSpec{ComponentName: "web", NetworkName: "http"}
+	api := tunneling.CloudflareRouteSpec{Subdomain: "api", ComponentName: "api", NetworkName: "http"}
+
+	BeforeEach(func() {
+		configurations = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var params cloudflare.TunnelConfigurationParams
+			Expect(json.NewDecoder(r.Body).Decode(&params)).To(Succeed())
+			configurations = append(configurations, params)
+			_, _ = w.Write([]byte(`{"success": true, "result": {"tunnel_id": "abcd", "version": 2}}`))
+		}))
+
+		workspace = &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Namespace = "default"
+		workspace.Spec.Networking.DNS.Zone = "example.com"
+		workspace.Spec.Networking.Tunnel = &workspaces.TunnelSpec{Cloudflare: &tunneling.CloudflareTunnelSpec{
+			AccountID: "account",
+			Routes:    []tunneling.CloudflareRouteSpec{web},
+		}}
+		workspace.Status.Host = "my-workspace.example.com"
+		workspace.Status.Ingress = "abcd.cfargotunnel.com"
+		workspace.Status.DNS = tunnelRecords(workspace.Status.Host, workspace.Status.Ingress, []tunneling.CloudflareRouteSpec{web})
+		workspace.Status.Tunnel = &workspaces.Tunnel{
+			RemoteID: "abcd",
+			ProviderMeta: map[string]string{
+				kCloudflareTunnelIDKey:     "abcd",
+				kCloudflareTunnelSecretKey: "my-workspace-cloudflared",
+				kCloudflareRoutesKey:       routesFingerprint([]tunneling.CloudflareRouteSpec{web}),
+			},
+		}
+		workspace.Status.Conditions = []conditions.Condition{{
+			Type:   workspaces.TunnelingCondition,
+			Status: conditions.ConditionCompleted,
+			Reason: "Tunnel ready to use, 1/1 connectors ready",
+		}}
+
+		connector := connectorDeployment(workspace, "my-workspace-cloudflared")
+		connector.Namespace = "default"
+		connector.Status.ReadyReplicas = 1
+
+		c = newClient(workspace, connector, service("web"))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	reconcile := func() conditions.Condition {
+		Expect(c.Get(ctx, client.ObjectKeyFromObject(workspace), workspace)).To(Succeed())
+		condition := conditions.FindCondition(workspace.Status.Conditions, workspaces.TunnelingCondition)
+
+		api, err := cloudflare.NewWithAPIToken("token", cloudflare.BaseURL(server.URL))
+		Expect(err).NotTo(HaveOccurred())
+
+		provider := cf{
+			api:                api,
+			accountID:          "account",
+			ProviderController: integrations.NewController(workspace, *condition, reconciler{c, record.NewFakeRecorder(10)}),
+		}
+		_, err = provider.Reconcile(ctx)
+		Expect(err).NotTo(HaveOccurred())
+
+		return *conditions.FindCondition(workspace.Status.Conditions, workspaces.TunnelingCondition)
+	}
+
+	It("leaves a tunnel with the same routes alone", func() {
+		Expect(reconcile().Status).To(Equal(conditions.ConditionCompleted))
+		Expect(configurations).To(BeEmpty())
+	})
+
+	It("applies the routes that changed after the tunnel was configured", func() {
+		Expect(c.Create(ctx, service("api"))).To(Succeed())
+		workspace.Spec.Networking.Tunnel.Cloudflare.Routes = []tunneling.CloudflareRouteSpec{web, api}
+		Expect(c.Update(ctx, workspace)).To(Succeed())
+
+		Expect(reconcile().Status).To(Equal(conditions.ConditionCompleted))
+		Expect(configurations).To(HaveLen(1))
+		Expect(configurations[0].Config.Ingress).To(HaveLen(3))
+		Expect(configurations[0].Config.Ingress[1].Hostname).To(Equal("api.my-workspace.example.com"))
+
+		var names []string
+		for _, record := range workspace.Status.DNS {
+			names = append(names, record.Name)
+		}
+		Expect(names).To(Equal([]string{"my-workspace.example.com", "api.my-workspace.example.com"}))
+
+		reconcile()
+		Expect(configurations).To(HaveLen(1))
+	})
+
+	It("waits for the service of a new route", func() {
+		workspace.Spec.Networking.Tunnel.Cloudflare.Routes = []tunneling.CloudflareRouteSpec{web, api}
+		Expect(c.Update(ctx, workspace)).To(Succeed())
+
+		Expect(reconcile().Status).To(Equal(conditions.ConditionCompleted))
+		Expect(configurations).To(BeEmpty())
+		Expect(workspace.Status.DNS).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,224 @@
+package tunneling
+
+import (
+	"context"
+	"fmt"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const kCloudflaredDefaultImage = "cloudflare/cloudflared:latest"
+const kCloudflaredTokenEnvName = "TUNNEL_TOKEN"
+const kCloudflaredTokenKey = "token"
+const kCloudflaredMetricsPort = 2000
+
+// Name of the Deployment, and of the secret holding the token, of the workspace's connector.
+func connectorName(workspace *sequencer.Workspace) string {
+	return fmt.Sprintf("%s-cloudflared", workspace.Name)
+}
+
+// Store the token of the tunnel in a secret owned by the workspace, the token gives access to the tunnel
+// and can't be kept in the workspace's status.
+func (c cf) createTokenSecret(ctx context.Context, token string) (*core.Secret, error) {
+	secret := &core.Secret{
+		ObjectMeta: meta.ObjectMeta{
+			Name: connectorName(c.Workspace()),
+		},
+		Data: map[string][]byte{
+			kCloudflaredTokenKey: []byte(token),
+		},
+	}
+
+	if err := c.Create(ctx, secret); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("E#3029: Could not store the token of the tunnel -- %w", err)
+	}
+
+	return secret, nil
+}
+
+// Returns the name of the secret holding the token. Workspaces created before the token was
+// stored in a secret get it moved out of their status.
+func (c cf) tokenSecretName(ctx context.Context) (string, error) {
+	tunnel := c.Workspace().Status.Tunnel
+	token, ok := tunnel.ProviderMeta[kCloudflareTunnelTokenKey]
+	if !ok {
+		return tunnel.ProviderMeta[kCloudflareTunnelSecretKey], nil
+	}
+
+	secret, err := c.createTokenSecret(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	delete(tunnel.ProviderMeta, kCloudflareTunnelTokenKey)
+	tunnel.ProviderMeta[kCloudflareTunnelSecretKey] = secret.Name
+
+	return secret.Name, c.Update(ctx, c.Workspace().Status)
+}
+
+func (c cf) deployConnector(ctx context.Context) (*apps.Deployment, error) {
+	secretName, err := c.tokenSecretName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deployment := connectorDeployment(c.Workspace(), secretName)
+	if err := c.Create(ctx, deployment); err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("E#3029: Could not deploy the connector -- %w", err)
+	}
+
+	return deployment, nil
+}
+
+// Keep the Deployment in sync with the spec and report the health of the connector through the condition. The
+// tunnel works as long as one connector is ready.
+func (c cf) monitorConnector(ctx context.Context) (*ctrl.Result, error) {
+	workspace := c.Workspace()
+
+	var deployment apps.Deployment
+	err := c.Get(ctx, types.NamespacedName{Name: connectorName(workspace), Namespace: workspace.Namespace}, &deployment)
+	if k8sErrors.IsNotFound(err) {
+		// Workspaces created before the connector was a Deployment ran it as a pod.
+		if _, err := c.deployConnector(ctx); err != nil {
+			return nil, err
+		}
+		return &ctrl.Result{}, c.UpdateCondition(ctx, conditions.ConditionInProgress, "Waiting for the connector to be ready")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	desired := connectorDeployment(workspace, workspace.Status.Tunnel.ProviderMeta[kCloudflareTunnelSecretKey])
+	if !connectorInSync(&deployment, desired) {
+		patch := client.MergeFrom(deployment.DeepCopy())
+		deployment.Spec.Replicas = desired.Spec.Replicas
+		deployment.Spec.Template.Spec.Containers[0].Image = desired.Spec.Template.Spec.Containers[0].Image
+		deployment.Spec.Template.Spec.Containers[0].Resources = desired.Spec.Template.Spec.Containers[0].Resources
+		if err := c.Patch(ctx, &deployment, patch); err != nil {
+			return nil, fmt.Errorf("E#3029: Could not update the connector -- %w", err)
+		}
+	}
+
+	status, reason := connectorHealth(&deployment, c.Condition().Status)
+	if status == c.Condition().Status && reason == c.Condition().Reason {
+		return nil, nil
+	}
+
+	if status == conditions.ConditionNotHealthy {
+		c.Event(core.EventTypeWarning, "Tunneling", reason)
+	}
+
+	return &ctrl.Result{}, c.UpdateCondition(ctx, status, reason)
+}
+
+// Returns the status of the tunnel based on the connectors that are ready. A connector that isn't
+// ready yet only makes the tunnel unhealthy once it was ready before.
+func connectorHealth(deployment *apps.Deployment, current conditions.ConditionStatus) (conditions.ConditionStatus, string) {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+
+	if deployment.Status.ReadyReplicas > 0 {
+		return conditions.ConditionCompleted, fmt.Sprintf("Tunnel ready to use, %d/%d connectors ready", deployment.Status.ReadyReplicas, replicas)
+	}
+
+	if current == conditions.ConditionInProgress {
+		return current, "Waiting for the connector to be ready"
+	}
+
+	return conditions.ConditionNotHealthy, fmt.Sprintf("No connector is ready, 0/%d connectors ready", replicas)
+}
+
+func connectorInSync(current, desired *apps.Deployment) bool {
+	container := current.Spec.Template.Spec.Containers[0]
+	desiredContainer := desired.Spec.Template.Spec.Containers[0]
+
+	return equality.Semantic.DeepEqual(current.Spec.Replicas, desired.Spec.Replicas) &&
+		container.Image == desiredContainer.Image &&
+		equality.Semantic.DeepEqual(container.Resources, desiredContainer.Resources)
+}
+
+func connectorDeployment(workspace *sequencer.Workspace, secretName string) *apps.Deployment {
+	spec := workspace.Spec.Networking.Tunnel.Cloudflare.Cloudflared
+	labels := map[string]string{
+		workspaces.InstanceLabel:  workspace.Name,
+		kCloudflareConnectorLabel: string(tunneling.TunnelingConnectorCloudflared),
+	}
+
+	replicas := int32(1)
+	if spec.Replicas != nil {
+		replicas = *spec.Replicas
+	}
+
+	image := spec.Image
+	if image == "" {
+		image = kCloudflaredDefaultImage
+	}
+
+	// cloudflared reports itself as ready once it's connected to Cloudflare.
+	probe := &core.Probe{
+		ProbeHandler: core.ProbeHandler{
+			HTTPGet: &core.HTTPGetAction{
+				Path: "/ready",
+				Port: intstr.FromInt32(kCloudflaredMetricsPort),
+			},
+		},
+		PeriodSeconds: 10,
+	}
+
+	return &apps.Deployment{
+		ObjectMeta: meta.ObjectMeta{
+			Name:   connectorName(workspace),
+			Labels: labels,
+		},
+		Spec: apps.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &meta.LabelSelector{MatchLabels: labels},
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{Labels: labels},
+				Spec: core.PodSpec{
+					Containers: []core.Container{{
+						Name:  "cloudflared",
+						Image: image,
+						Args: []string{
+							"tunnel",
+							"--no-autoupdate",
+							"--metrics",
+							fmt.Sprintf("0.0.0.0:%d", kCloudflaredMetricsPort),
+							"run",
+						},
+						Env: []core.EnvVar{{
+							Name: kCloudflaredTokenEnvName,
+							ValueFrom: &core.EnvVarSource{
+								SecretKeyRef: &core.SecretKeySelector{
+									LocalObjectReference: core.LocalObjectReference{Name: secretName},
+									Key:                  kCloudflaredTokenKey,
+								},
+							},
+						}},
+						Resources:      spec.Resources,
+						ReadinessProbe: probe,
+						LivenessProbe: &core.Probe{
+							ProbeHandler:     probe.ProbeHandler,
+							PeriodSeconds:    10,
+							FailureThreshold: 6,
+						},
+					}},
+				},
+			},
+		},
+	}
+}
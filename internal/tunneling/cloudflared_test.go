@@ -0,0 +1,89 @@
+package tunneling
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ = Describe("Cloudflared", func() {
+	workspace := func(spec tunneling.CloudflaredSpec) *sequencer.Workspace {
+		workspace := &sequencer.Workspace{}
+		workspace.Name = "my-workspace"
+		workspace.Spec.Networking.Tunnel = &workspaces.TunnelSpec{
+			Cloudflare: &tunneling.CloudflareTunnelSpec{Cloudflared: spec},
+		}
+		return workspace
+	}
+
+	It("runs a single connector by default", func() {
+		deployment := connectorDeployment(workspace(tunneling.CloudflaredSpec{}), "my-workspace-cloudflared")
+		Expect(deployment.Name).To(Equal("my-workspace-cloudflared"))
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+		Expect(deployment.Labels).To(HaveKeyWithValue(ConnectorLabel, "cloudflared"))
+		Expect(deployment.Spec.Template.Labels).To(Equal(deployment.Labels))
+
+		container := deployment.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).To(Equal(kCloudflaredDefaultImage))
+		Expect(container.Env[0].ValueFrom.SecretKeyRef.Name).To(Equal("my-workspace-cloudflared"))
+		Expect(container.ReadinessProbe.HTTPGet.Path).To(Equal("/ready"))
+	})
+
+	It("configures the connector from the spec", func() {
+		replicas := int32(3)
+		spec := tunneling.CloudflaredSpec{
+			Replicas: &replicas,
+			Image:    "cloudflare/cloudflared:2024.8.2",
+			Resources: core.ResourceRequirements{
+				Limits: core.ResourceList{core.ResourceMemory: resource.MustParse("128Mi")},
+			},
+		}
+
+		deployment := connectorDeployment(workspace(spec), "secret")
+		Expect(*deployment.Spec.Replicas).To(Equal(replicas))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).To(Equal(spec.Image))
+		Expect(deployment.Spec.Template.Spec.Containers[0].Resources).To(Equal(spec.Resources))
+	})
+
+	It("detects when the connector drifted from the spec", func() {
+		current := connectorDeployment(workspace(tunneling.CloudflaredSpec{}), "secret")
+		Expect(connectorInSync(current, connectorDeployment(workspace(tunneling.CloudflaredSpec{}), "secret"))).To(BeTrue())
+
+		replicas := int32(2)
+		Expect(connectorInSync(current, connectorDeployment(workspace(tunneling.CloudflaredSpec{Replicas: &replicas}), "secret"))).To(BeFalse())
+		Expect(connectorInSync(current, connectorDeployment(workspace(tunneling.CloudflaredSpec{Image: "cloudflared:dev"}), "secret"))).To(BeFalse())
+	})
+
+	Context("health", func() {
+		deployment := func(ready int32) *apps.Deployment {
+			replicas := int32(2)
+			deployment := &apps.Deployment{}
+			deployment.Spec.Replicas = &replicas
+			deployment.Status.ReadyReplicas = ready
+			return deployment
+		}
+
+		It("is completed when a connector is ready", func() {
+			status, reason := connectorHealth(deployment(1), conditions.ConditionNotHealthy)
+			Expect(status).To(Equal(conditions.ConditionCompleted))
+			Expect(reason).To(ContainSubstring("1/2"))
+		})
+
+		It("waits for the first connector to be ready", func() {
+			status, _ := connectorHealth(deployment(0), conditions.ConditionInProgress)
+			Expect(status).To(Equal(conditions.ConditionInProgress))
+		})
+
+		It("is not healthy when every connector went down", func() {
+			status, reason := connectorHealth(deployment(0), conditions.ConditionCompleted)
+			Expect(status).To(Equal(conditions.ConditionNotHealthy))
+			Expect(reason).To(ContainSubstring("0/2"))
+		})
+	})
+})
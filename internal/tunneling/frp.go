@@ -0,0 +1,127 @@
+package tunneling
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const kFrpDefaultImage = "fatedier/frpc:v0.61.0"
+const kFrpDefaultServerPort = 7000
+const kFrpConfigPath = "/etc/frp"
+const kFrpConfigKey = "frpc.toml"
+const kFrpAuthTokenEnvName = "FRP_AUTH_TOKEN"
+
+// The admin API of the agent reports the status of its proxy. It only listens on the loopback
+// interface as it exposes the configuration, token included.
+const kFrpAdminPort = 7400
+
+type frp struct {
+	spec *tunneling.FrpTunnelSpec
+}
+
+func (f frp) name() string {
+	return "frp"
+}
+
+func (f frp) route() tunneling.RouteSpec {
+	return f.spec.Route
+}
+
+func (f frp) secretKeyRef() *utils.SecretKeyRef {
+	return f.spec.SecretKeyRef
+}
+
+// The host points to the server, which routes the requests to the agent based on the host header.
+func (f frp) records(host string) []workspaces.DNS {
+	address := f.spec.PublicAddress
+	if address == "" {
+		address = f.spec.ServerAddress
+	}
+
+	record := workspaces.DNS{Name: host, RecordType: "CNAME", Target: address}
+	if ip := net.ParseIP(address); ip != nil {
+		record.RecordType = "A"
+		if ip.To4() == nil {
+			record.RecordType = "AAAA"
+		}
+	}
+
+	return []workspaces.DNS{record}
+}
+
+func (f frp) resources(host string, service *core.Service, secret *core.Secret) ([]client.Object, error) {
+	proxyName, _, _ := strings.Cut(host, ".")
+	config := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Name: fmt.Sprintf("%s-frp-agent", proxyName),
+		},
+		Data: map[string]string{
+			kFrpConfigKey: f.config(host, fmt.Sprintf("%s.%s", service.Namespace, proxyName), service, secret.Name != ""),
+		},
+	}
+
+	image := f.spec.Image
+	if image == "" {
+		image = kFrpDefaultImage
+	}
+
+	var env []core.EnvVar
+	if secret.Name != "" {
+		env = append(env, secretEnv(kFrpAuthTokenEnvName, secret))
+	}
+
+	// The proxy is running once the server accepted it.
+	deployment := agentDeployment(f.name(), image, []string{"-c", fmt.Sprintf("%s/%s", kFrpConfigPath, kFrpConfigKey)}, env, &core.Probe{
+		ProbeHandler: core.ProbeHandler{
+			Exec: &core.ExecAction{
+				Command: []string{"sh", "-c", fmt.Sprintf(`wget -q -O - http://127.0.0.1:%d/api/status | grep -q '"status":"running"'`, kFrpAdminPort)},
+			},
+		},
+		PeriodSeconds: 10,
+	})
+	mountConfig(deployment, config, kFrpConfigPath)
+
+	return []client.Object{config, deployment}, nil
+}
+
+// Configuration of the agent. The proxy name needs to be unique on the server, the token is read
+// from the environment by the agent.
+func (f frp) config(host, proxyName string, service *core.Service, authenticated bool) string {
+	serverPort := f.spec.ServerPort
+	if serverPort == 0 {
+		serverPort = kFrpDefaultServerPort
+	}
+
+	var config strings.Builder
+	fmt.Fprintf(&config, "serverAddr = %q\n", f.spec.ServerAddress)
+	fmt.Fprintf(&config, "serverPort = %d\n", serverPort)
+	fmt.Fprintf(&config, "webServer.addr = \"127.0.0.1\"\n")
+	fmt.Fprintf(&config, "webServer.port = %d\n", kFrpAdminPort)
+	if authenticated {
+		fmt.Fprintf(&config, "auth.token = \"{{ .Envs.%s }}\"\n", kFrpAuthTokenEnvName)
+	}
+
+	fmt.Fprintf(&config, "\n[[proxies]]\n")
+	fmt.Fprintf(&config, "name = %q\n", proxyName)
+	fmt.Fprintf(&config, "type = \"http\"\n")
+	fmt.Fprintf(&config, "customDomains = [%q]\n", host)
+
+	if f.spec.Route.Protocol == "https" {
+		fmt.Fprintf(&config, "\n[proxies.plugin]\n")
+		fmt.Fprintf(&config, "type = \"http2https\"\n")
+		fmt.Fprintf(&config, "localAddr = \"%s:%d\"\n", service.Name, service.Spec.Ports[0].Port)
+	} else {
+		fmt.Fprintf(&config, "localIP = %q\n", service.Name)
+		fmt.Fprintf(&config, "localPort = %d\n", service.Spec.Ports[0].Port)
+	}
+
+	return config.String()
+}
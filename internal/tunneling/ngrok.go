@@ -0,0 +1,74 @@
+package tunneling
+
+import (
+	"fmt"
+
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const kNgrokDefaultImage = "ngrok/ngrok:3"
+const kNgrokAuthTokenEnvName = "NGROK_AUTHTOKEN"
+
+// The agent's API, which the ngrok image serves on every interface.
+const kNgrokAPIPort = 4040
+
+// Name the agent gives to the tunnel started from the command line.
+const kNgrokTunnelName = "command_line"
+
+type ngrok struct {
+	spec *tunneling.NgrokTunnelSpec
+}
+
+func (n ngrok) name() string {
+	return "ngrok"
+}
+
+func (n ngrok) route() tunneling.RouteSpec {
+	return n.spec.Route
+}
+
+func (n ngrok) secretKeyRef() *utils.SecretKeyRef {
+	return &n.spec.SecretKeyRef
+}
+
+func (n ngrok) records(host string) []workspaces.DNS {
+	if n.spec.CNAMETarget == "" {
+		return nil
+	}
+
+	return []workspaces.DNS{{
+		Name:       host,
+		RecordType: "CNAME",
+		Target:     n.spec.CNAMETarget,
+	}}
+}
+
+func (n ngrok) resources(host string, service *core.Service, secret *core.Secret) ([]client.Object, error) {
+	image := n.spec.Image
+	if image == "" {
+		image = kNgrokDefaultImage
+	}
+
+	deployment := agentDeployment(n.name(), image, []string{
+		"http",
+		upstream(n.spec.Route, service),
+		fmt.Sprintf("--url=https://%s", host),
+		"--log=stdout",
+	}, []core.EnvVar{secretEnv(kNgrokAuthTokenEnvName, secret)}, &core.Probe{
+		// The tunnel only shows up in the API once it's online.
+		ProbeHandler: core.ProbeHandler{
+			HTTPGet: &core.HTTPGetAction{
+				Path: fmt.Sprintf("/api/tunnels/%s", kNgrokTunnelName),
+				Port: intstr.FromInt32(kNgrokAPIPort),
+			},
+		},
+		PeriodSeconds: 10,
+	})
+
+	return []client.Object{deployment}, nil
+}
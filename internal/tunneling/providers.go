@@ -10,8 +10,15 @@ import (
 
 func NewProvider(ctx context.Context, controller integrations.ProviderController) (integrations.Provider, error) {
 	spec := controller.Workspace().Spec.Networking.Tunnel
-	if spec.Cloudflare != nil {
+	switch {
+	case spec.Cloudflare != nil:
 		return newCloudflareProvider(ctx, controller)
+	case spec.Ngrok != nil:
+		return agentProvider{agent: ngrok{spec: spec.Ngrok}, ProviderController: controller}, nil
+	case spec.Tailscale != nil:
+		return agentProvider{agent: tailscale{spec: spec.Tailscale}, ProviderController: controller}, nil
+	case spec.Frp != nil:
+		return agentProvider{agent: frp{spec: spec.Frp}, ProviderController: controller}, nil
 	}
 
 	return nil, errors.New("E#3012: The tunnel spec doesn't include a valid provider")
@@ -22,9 +29,5 @@ func IncludesTunnelSpec(spec workspaces.NetworkingSpec) bool {
 		return false
 	}
 
-	if spec.Tunnel.Cloudflare != nil {
-		return true
-	}
-
-	return false
+	return len(spec.Tunnel.Providers()) > 0
 }
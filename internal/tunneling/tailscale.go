@@ -0,0 +1,113 @@
+package tunneling
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tunneling "github.com/pier-oliviert/sequencer/api/v1alpha1/tunneling"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/utils"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const kTailscaleDefaultImage = "tailscale/tailscale:stable"
+const kTailscaleConfigPath = "/etc/tailscale"
+const kTailscaleServeConfigKey = "serve.json"
+
+// Port of the health check served by the container. It succeeds once the node has an address on the tailnet.
+const kTailscaleHealthPort = 9002
+
+// The agent replaces this placeholder in the serve config with the node's domain.
+const kTailscaleCertDomain = "${TS_CERT_DOMAIN}:443"
+
+type tailscale struct {
+	spec *tunneling.TailscaleTunnelSpec
+}
+
+func (t tailscale) name() string {
+	return "tailscale"
+}
+
+func (t tailscale) route() tunneling.RouteSpec {
+	return t.spec.Route
+}
+
+func (t tailscale) secretKeyRef() *utils.SecretKeyRef {
+	return &t.spec.SecretKeyRef
+}
+
+// Tailscale serves the DNS of the tailnet's domain.
+func (t tailscale) records(host string) []workspaces.DNS {
+	return nil
+}
+
+func (t tailscale) resources(host string, service *core.Service, secret *core.Secret) ([]client.Object, error) {
+	serveConfig, err := tailscaleServeConfig(upstream(t.spec.Route, service))
+	if err != nil {
+		return nil, err
+	}
+
+	nodeName, _, _ := strings.Cut(host, ".")
+	config := &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Name: fmt.Sprintf("%s-tailscale-agent", nodeName),
+		},
+		Data: map[string]string{
+			kTailscaleServeConfigKey: serveConfig,
+		},
+	}
+
+	image := t.spec.Image
+	if image == "" {
+		image = kTailscaleDefaultImage
+	}
+
+	deployment := agentDeployment(t.name(), image, nil, []core.EnvVar{
+		secretEnv("TS_AUTHKEY", secret),
+		{Name: "TS_HOSTNAME", Value: nodeName},
+		{Name: "TS_SERVE_CONFIG", Value: fmt.Sprintf("%s/%s", kTailscaleConfigPath, kTailscaleServeConfigKey)},
+		// The state is kept in the container, the node is expected to be ephemeral.
+		{Name: "TS_KUBE_SECRET", Value: ""},
+		{Name: "TS_STATE_DIR", Value: "/tmp/tailscale"},
+		{Name: "TS_USERSPACE", Value: "true"},
+		{Name: "TS_ENABLE_HEALTH_CHECK", Value: "true"},
+		{Name: "TS_LOCAL_ADDR_PORT", Value: fmt.Sprintf("[::]:%d", kTailscaleHealthPort)},
+	}, &core.Probe{
+		ProbeHandler: core.ProbeHandler{
+			HTTPGet: &core.HTTPGetAction{
+				Path: "/healthz",
+				Port: intstr.FromInt32(kTailscaleHealthPort),
+			},
+		},
+		PeriodSeconds: 10,
+	})
+	mountConfig(deployment, config, kTailscaleConfigPath)
+
+	return []client.Object{config, deployment}, nil
+}
+
+// Serve the upstream on port 443 of the node and allow it through Funnel.
+func tailscaleServeConfig(upstream string) (string, error) {
+	config := map[string]any{
+		"TCP": map[string]any{
+			"443": map[string]any{"HTTPS": true},
+		},
+		"Web": map[string]any{
+			kTailscaleCertDomain: map[string]any{
+				"Handlers": map[string]any{
+					"/": map[string]any{"Proxy": upstream},
+				},
+			},
+		},
+		"AllowFunnel": map[string]any{
+			kTailscaleCertDomain: true,
+		},
+	}
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	return string(data), err
+}
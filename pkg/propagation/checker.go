@@ -0,0 +1,118 @@
+package propagation
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Checker verifies that a record answers with its target. By default, the authoritative nameservers
+// of the zone are queried, which is where a record is available first. Configured nameservers
+// are queried as recursive resolvers instead, eg. to verify what clients in a private network see.
+type Checker struct {
+	// Addresses (host:port) of the resolvers to query. When empty, the authoritative nameservers
+	// of the record's zone are queried.
+	Nameservers []string
+
+	// Timeout of each query. Defaults to 5 seconds.
+	Timeout time.Duration
+
+	// Used to find the authoritative nameservers.
+	Resolver *net.Resolver
+}
+
+// Returns true when every nameserver answers the record with its target.
+func (c *Checker) Propagated(ctx context.Context, recordType, name, target string) (bool, error) {
+	qtype, ok := dns.StringToType[strings.ToUpper(recordType)]
+	if !ok {
+		return false, fmt.Errorf("E#4009: Record type %s can't be verified", recordType)
+	}
+
+	nameservers := c.Nameservers
+	recursive := len(nameservers) > 0
+	if !recursive {
+		var err error
+		nameservers, err = c.authoritativeNameservers(ctx, name)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	client := &dns.Client{Timeout: timeout}
+
+	for _, nameserver := range nameservers {
+		msg := new(dns.Msg)
+		msg.SetQuestion(dns.Fqdn(name), qtype)
+		msg.RecursionDesired = recursive
+
+		response, _, err := client.ExchangeContext(ctx, msg, nameserver)
+		if err != nil {
+			return false, fmt.Errorf("E#4009: Couldn't query %s for %s -- %w", nameserver, name, err)
+		}
+
+		if !Matches(response.Answer, recordType, target) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// Walks up the name until a zone with nameservers is found.
+func (c *Checker) authoritativeNameservers(ctx context.Context, name string) ([]string, error) {
+	resolver := c.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	candidate := strings.TrimSuffix(strings.TrimPrefix(name, "*."), ".")
+	for strings.Contains(candidate, ".") {
+		records, err := resolver.LookupNS(ctx, candidate)
+		if err == nil && len(records) > 0 {
+			nameservers := make([]string, len(records))
+			for i, record := range records {
+				nameservers[i] = net.JoinHostPort(strings.TrimSuffix(record.Host, "."), "53")
+			}
+			return nameservers, nil
+		}
+
+		_, candidate, _ = strings.Cut(candidate, ".")
+	}
+
+	return nil, fmt.Errorf("E#4009: Couldn't find the nameservers of the zone for %s", name)
+}
+
+// Returns true if the answers include the target. Providers can flatten a CNAME, like Route53's aliases
+// or Cloudflare's proxied records, in which case any address answers for it.
+func Matches(answers []dns.RR, recordType, target string) bool {
+	for _, answer := range answers {
+		switch rr := answer.(type) {
+		case *dns.A:
+			if recordType == "CNAME" || rr.A.Equal(net.ParseIP(target)) {
+				return true
+			}
+		case *dns.AAAA:
+			if recordType == "CNAME" || rr.AAAA.Equal(net.ParseIP(target)) {
+				return true
+			}
+		case *dns.CNAME:
+			if recordType == "CNAME" && strings.EqualFold(rr.Target, dns.Fqdn(target)) {
+				return true
+			}
+		case *dns.TXT:
+			if recordType == "TXT" && strings.Join(rr.Txt, "") == strings.Trim(target, "\"") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
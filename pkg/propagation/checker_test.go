@@ -0,0 +1,89 @@
+package propagation
+
+import (
+	"context"
+	"net"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Checker", func() {
+	var server *dns.Server
+	var answers map[string][]dns.RR
+	var checker *Checker
+
+	BeforeEach(func() {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		answers = map[string][]dns.RR{}
+		started := make(chan struct{})
+		server = &dns.Server{
+			PacketConn:        conn,
+			NotifyStartedFunc: func() { close(started) },
+			Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+				response := new(dns.Msg)
+				response.SetReply(r)
+				response.Answer = answers[r.Question[0].Name]
+				_ = w.WriteMsg(response)
+			}),
+		}
+		go func() { _ = server.ActivateAndServe() }()
+		<-started
+
+		checker = &Checker{Nameservers: []string{conn.LocalAddr().String()}}
+	})
+
+	AfterEach(func() {
+		Expect(server.Shutdown()).To(Succeed())
+	})
+
+	rr := func(s string) dns.RR {
+		record, err := dns.NewRR(s)
+		Expect(err).NotTo(HaveOccurred())
+		return record
+	}
+
+	It("waits until the record answers", func() {
+		propagated, err := checker.Propagated(context.Background(), "A", "my-workspace.example.com", "203.0.113.10")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(propagated).To(BeFalse())
+
+		answers["my-workspace.example.com."] = []dns.RR{rr("my-workspace.example.com. 60 IN A 203.0.113.10")}
+		propagated, err = checker.Propagated(context.Background(), "A", "my-workspace.example.com", "203.0.113.10")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(propagated).To(BeTrue())
+	})
+
+	It("doesn't match a stale target", func() {
+		answers["my-workspace.example.com."] = []dns.RR{rr("my-workspace.example.com. 60 IN A 203.0.113.11")}
+		propagated, err := checker.Propagated(context.Background(), "A", "my-workspace.example.com", "203.0.113.10")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(propagated).To(BeFalse())
+	})
+
+	It("rejects record types it can't verify", func() {
+		_, err := checker.Propagated(context.Background(), "BOGUS", "my-workspace.example.com", "")
+		Expect(err).To(MatchError(ContainSubstring("E#4009")))
+	})
+
+	Context("Matches", func() {
+		It("compares CNAME targets as FQDN", func() {
+			Expect(Matches([]dns.RR{rr("a.example.com. 60 IN CNAME LB.example.net.")}, "CNAME", "lb.example.net")).To(BeTrue())
+		})
+
+		It("accepts flattened CNAME records", func() {
+			Expect(Matches([]dns.RR{rr("a.example.com. 60 IN A 203.0.113.10")}, "CNAME", "lb.example.net")).To(BeTrue())
+		})
+
+		It("compares TXT values without quotes", func() {
+			Expect(Matches([]dns.RR{rr(`a.example.com. 60 IN TXT "heritage=sequencer"`)}, "TXT", `"heritage=sequencer"`)).To(BeTrue())
+		})
+
+		It("compares IPv6 addresses", func() {
+			Expect(Matches([]dns.RR{rr("a.example.com. 60 IN AAAA 2001:db8::10")}, "AAAA", "2001:db8:0::10")).To(BeTrue())
+		})
+	})
+})
@@ -3,8 +3,12 @@ package providers
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/route53"
 	"github.com/aws/aws-sdk-go-v2/service/route53/types"
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
@@ -13,93 +17,379 @@ import (
 const kAWSZoneID = "AWS_ZONE_ID"
 const kAWSHostedZoneID = "AWS_HOSTED_ZONE_ID"
 const kAWSLoadBalancerHost = "AWS_LOAD_BALANCER_HOST"
+const kAWSAccessKeyID = "AWS_ACCESS_KEY_ID"
+const kAWSSecretAccessKey = "AWS_SECRET_ACCESS_KEY"
+const kAWSRegion = "AWS_REGION"
+
+const kAWSPropertiesTTL = "ttl"
+const kAWSPropertiesAlias = "alias"
+const kAWSPropertiesAliasHostedZoneID = "aliasHostedZoneId"
+const kAWSPropertiesEvaluateTargetHealth = "evaluateTargetHealth"
 
 type r53 struct {
 	hostedZoneID     string
 	zoneID           string
 	loadBalancerHost string
+	ownerID          string
 	*route53.Client
 }
 
-func NewAWSProvider() (*r53, error) {
+func NewAWSProvider(values Values) (*r53, error) {
 	ctx := context.Background()
-	cfg, err := config.LoadDefaultConfig(ctx)
+
+	// Credentials are usually loaded by the SDK (environment, IRSA, etc.). They can also be part
+	// of the values, which is the case when the values come from the secret of a DNSProvider.
+	var opts []func(*config.LoadOptions) error
+	if accessKeyID := optionalValue(values, kAWSAccessKeyID, ""); accessKeyID != "" {
+		secretAccessKey := optionalValue(values, kAWSSecretAccessKey, "")
+		opts = append(opts, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, "")))
+	}
+
+	if region := optionalValue(values, kAWSRegion, ""); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, err
 	}
-	zoneID, err := retrieveValueFromEnvOrFile(kAWSZoneID)
+	zoneID, err := values(kAWSZoneID)
 	if err != nil {
 		return nil, fmt.Errorf("E#4102: Zone ID not found -- %w", err)
 	}
 
-	hostedZoneID, err := retrieveValueFromEnvOrFile(kAWSHostedZoneID)
-	if err != nil {
-		return nil, fmt.Errorf("E#4102: Hosted Zone ID not found -- %w", err)
-	}
+	// The hosted zone ID is only needed for aliases that don't set their own.
+	hostedZoneID := optionalValue(values, kAWSHostedZoneID, "")
 
-	loadBalancerHost, err := retrieveValueFromEnvOrFile(kAWSLoadBalancerHost)
+	loadBalancerHost, err := values(kAWSLoadBalancerHost)
 	if err != nil {
 		return nil, fmt.Errorf("E#4102: Load balancer host not found -- %w", err)
 	}
 
 	return &r53{
-		zoneID:           zoneID,
+		zoneID:           strings.TrimSpace(zoneID),
 		hostedZoneID:     hostedZoneID,
 		loadBalancerHost: loadBalancerHost,
+		ownerID:          optionalValue(values, kOwnerID, kDefaultOwnerID),
 		Client:           route53.NewFromConfig(cfg),
 	}, nil
 }
 
+// Records are created with UPSERT so that a record that already exists with the same name and type
+// is replaced instead of failing the creation.
 func (c *r53) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	return c.Update(ctx, record)
+}
+
+// Route53 replaces the record set in place with UPSERT, which also creates it when it's missing. Nothing is
+// written when the record set, and its ownership record, already match the spec. The change is applied asynchronously,
+// its ID is stored in the record's PendingChange until Route53 reports it as INSYNC.
+func (c *r53) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	set, err := c.resourceRecordSet(record)
+	if err != nil {
+		return err
+	}
+
+	ownership := c.ownershipRecordSet(set)
+
+	current, err := c.currentRecordSet(ctx, *set.Name, set.Type)
+	if err != nil {
+		return err
+	}
+
+	currentOwnership, err := c.currentRecordSet(ctx, *ownership.Name, types.RRTypeTxt)
+	if err != nil {
+		return err
+	}
+
+	if sameRecordSet(current, set) && sameRecordSet(currentOwnership, ownership) {
+		return nil
+	}
+
 	inputs := route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: &c.zoneID,
 		ChangeBatch: &types.ChangeBatch{
 			Changes: []types.Change{{
-				Action:            types.ChangeActionCreate,
-				ResourceRecordSet: c.resourceRecordSet(record),
+				Action:            types.ChangeActionUpsert,
+				ResourceRecordSet: set,
+			}, {
+				Action:            types.ChangeActionUpsert,
+				ResourceRecordSet: ownership,
 			}},
 		},
 	}
 
-	_, err := c.ChangeResourceRecordSets(ctx, &inputs)
-	return err
+	output, err := c.ChangeResourceRecordSets(ctx, &inputs)
+	if err != nil {
+		return err
+	}
+
+	if output.ChangeInfo.Status != types.ChangeStatusInsync {
+		record.Status.PendingChange = output.ChangeInfo.Id
+	}
+
+	return nil
+}
+
+func (c *r53) ChangeApplied(ctx context.Context, changeID string) (bool, error) {
+	output, err := c.GetChange(ctx, &route53.GetChangeInput{Id: &changeID})
+	if err != nil {
+		return false, err
+	}
+
+	return output.ChangeInfo.Status == types.ChangeStatusInsync, nil
 }
 
 func (c *r53) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
-	inputs := route53.ChangeResourceRecordSetsInput{
+	set, err := c.resourceRecordSet(record)
+	if err != nil {
+		return err
+	}
+
+	changes := []types.Change{{
+		Action:            types.ChangeActionDelete,
+		ResourceRecordSet: set,
+	}}
+
+	// Records created before ownership records existed don't have one, and Route53 rejects the whole
+	// batch when a deletion doesn't match.
+	ownership, err := c.currentRecordSet(ctx, *c.ownershipRecordSet(set).Name, types.RRTypeTxt)
+	if err != nil {
+		return err
+	}
+
+	if ownership != nil {
+		changes = append(changes, types.Change{
+			Action:            types.ChangeActionDelete,
+			ResourceRecordSet: ownership,
+		})
+	}
+
+	_, err = c.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: &c.zoneID,
-		ChangeBatch: &types.ChangeBatch{
-			Changes: []types.Change{{
-				Action:            types.ChangeActionDelete,
-				ResourceRecordSet: c.resourceRecordSet(record),
-			}},
-		},
+		ChangeBatch:  &types.ChangeBatch{Changes: changes},
+	})
+	return err
+}
+
+// Returns the record set with the name and type, or nil if it doesn't exist.
+func (c *r53) currentRecordSet(ctx context.Context, name string, recordType types.RRType) (*types.ResourceRecordSet, error) {
+	output, err := c.ListResourceRecordSets(ctx, &route53.ListResourceRecordSetsInput{
+		HostedZoneId:    &c.zoneID,
+		StartRecordName: &name,
+		StartRecordType: recordType,
+		MaxItems:        aws.Int32(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(output.ResourceRecordSets) == 0 {
+		return nil, nil
+	}
+
+	set := output.ResourceRecordSets[0]
+	if !sameRecordName(*set.Name, name) || set.Type != recordType {
+		return nil, nil
+	}
+
+	return &set, nil
+}
+
+// TXT record that marks the record set as owned by this installation of Sequencer.
+func (c *r53) ownershipRecordSet(set *types.ResourceRecordSet) *types.ResourceRecordSet {
+	return &types.ResourceRecordSet{
+		Name: aws.String(ownershipName(*set.Name, string(set.Type))),
+		Type: types.RRTypeTxt,
+		TTL:  aws.Int64(300),
+		ResourceRecords: []types.ResourceRecord{{
+			Value: aws.String(ownershipValue(c.ownerID)),
+		}},
+	}
+}
+
+// Remote data of an owned record, the record set can be nil when only the ownership record is left.
+type r53OwnedRecord struct {
+	set       *types.ResourceRecordSet
+	ownership types.ResourceRecordSet
+}
+
+func (c *r53) OwnedRecords(ctx context.Context) ([]OwnedRecord, error) {
+	sets := map[string]types.ResourceRecordSet{}
+	var ownerships []types.ResourceRecordSet
+
+	paginator := route53.NewListResourceRecordSetsPaginator(c.Client, &route53.ListResourceRecordSetsInput{
+		HostedZoneId: &c.zoneID,
+	})
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, set := range page.ResourceRecordSets {
+			name := normalizeRecordName(*set.Name)
+			if _, _, ok := parseOwnershipName(name); ok && set.Type == types.RRTypeTxt {
+				ownerships = append(ownerships, set)
+				continue
+			}
+			sets[name+"/"+string(set.Type)] = set
+		}
+	}
+
+	var owned []OwnedRecord
+	for _, ownership := range ownerships {
+		if len(ownership.ResourceRecords) == 0 || *ownership.ResourceRecords[0].Value != ownershipValue(c.ownerID) {
+			continue
+		}
+
+		name, recordType, _ := parseOwnershipName(normalizeRecordName(*ownership.Name))
+		record := OwnedRecord{
+			RecordType: recordType,
+			Name:       name,
+		}
+
+		remote := r53OwnedRecord{ownership: ownership}
+		if set, ok := sets[name+"/"+recordType]; ok {
+			remote.set = &set
+			if set.AliasTarget != nil {
+				record.Target = *set.AliasTarget.DNSName
+			} else if len(set.ResourceRecords) > 0 {
+				record.Target = *set.ResourceRecords[0].Value
+			}
+		}
+		record.remote = remote
+
+		owned = append(owned, record)
 	}
 
-	_, err := c.ChangeResourceRecordSets(ctx, &inputs)
+	return owned, nil
+}
+
+func (c *r53) DeleteOwnedRecord(ctx context.Context, record OwnedRecord) error {
+	remote := record.remote.(r53OwnedRecord)
+
+	changes := []types.Change{{
+		Action:            types.ChangeActionDelete,
+		ResourceRecordSet: &remote.ownership,
+	}}
+
+	if remote.set != nil {
+		changes = append(changes, types.Change{
+			Action:            types.ChangeActionDelete,
+			ResourceRecordSet: remote.set,
+		})
+	}
+
+	_, err := c.ChangeResourceRecordSets(ctx, &route53.ChangeResourceRecordSetsInput{
+		HostedZoneId: &c.zoneID,
+		ChangeBatch:  &types.ChangeBatch{Changes: changes},
+	})
 	return err
 }
 
-// Convert a DNSRecord to a resourceRecordSet
-func (c *r53) resourceRecordSet(record *sequencer.DNSRecord) *types.ResourceRecordSet {
+// Returns true when the record set that exists upstream matches the one generated from the spec.
+func sameRecordSet(current, desired *types.ResourceRecordSet) bool {
+	if current == nil || current.Type != desired.Type || !sameRecordName(*current.Name, *desired.Name) {
+		return false
+	}
+
+	if (current.AliasTarget == nil) != (desired.AliasTarget == nil) {
+		return false
+	}
+
+	if desired.AliasTarget != nil {
+		return sameRecordName(*current.AliasTarget.DNSName, *desired.AliasTarget.DNSName) &&
+			*current.AliasTarget.HostedZoneId == *desired.AliasTarget.HostedZoneId &&
+			current.AliasTarget.EvaluateTargetHealth == desired.AliasTarget.EvaluateTargetHealth
+	}
+
+	if current.TTL == nil || *current.TTL != *desired.TTL || len(current.ResourceRecords) != len(desired.ResourceRecords) {
+		return false
+	}
+
+	for i := range desired.ResourceRecords {
+		if !sameRecordName(*current.ResourceRecords[i].Value, *desired.ResourceRecords[i].Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Route53 returns fully qualified names and escapes the wildcard.
+func normalizeRecordName(name string) string {
+	return strings.ReplaceAll(strings.TrimSuffix(name, "."), "\\052", "*")
+}
+
+func sameRecordName(a, b string) bool {
+	return strings.EqualFold(normalizeRecordName(a), normalizeRecordName(b))
+}
+
+// Convert a DNSRecord to a resourceRecordSet. The properties of the record configure the set:
+//
+//   - `ttl`: TTL in seconds of a regular record, defaults to 60.
+//   - `alias`: `true` to create an alias record, `false` for a regular record. Defaults to `true` for
+//     records pointing at an AWS load balancer.
+//   - `aliasHostedZoneId`: Hosted zone of the alias' target, defaults to AWS_HOSTED_ZONE_ID.
+//   - `evaluateTargetHealth`: `true` to have the alias evaluate the health of its target.
+func (c *r53) resourceRecordSet(record *sequencer.DNSRecord) (*types.ResourceRecordSet, error) {
 	set := types.ResourceRecordSet{
 		Name: &record.Spec.Name,
 		Type: types.RRType(record.Spec.RecordType),
 	}
 
-	if set.Type == types.RRTypeA || set.Type == types.RRTypeCname {
+	properties := record.Spec.Properties
+	alias := isAWSLoadBalancer(record.Spec.Target)
+	if value, ok := properties[kAWSPropertiesAlias]; ok {
+		alias = strings.EqualFold(value, "true")
+	}
+
+	if alias {
+		hostedZoneID := c.hostedZoneID
+		if value, ok := properties[kAWSPropertiesAliasHostedZoneID]; ok {
+			hostedZoneID = value
+		}
+
+		if hostedZoneID == "" {
+			return nil, fmt.Errorf("E#4103: The alias to %s needs a hosted zone, set %s or the %s property", record.Spec.Target, kAWSHostedZoneID, kAWSPropertiesAliasHostedZoneID)
+		}
+
+		// Aliases to a load balancer can't be CNAME, they resolve to the IPs of the load balancer.
+		if set.Type == types.RRTypeCname {
+			set.Type = types.RRTypeA
+		}
+
 		set.AliasTarget = &types.AliasTarget{
-			DNSName:      &record.Spec.Target,
-			HostedZoneId: &c.hostedZoneID,
+			DNSName:              &record.Spec.Target,
+			HostedZoneId:         &hostedZoneID,
+			EvaluateTargetHealth: strings.EqualFold(properties[kAWSPropertiesEvaluateTargetHealth], "true"),
 		}
-	} else {
-		set.ResourceRecords = append(set.ResourceRecords, types.ResourceRecord{
-			Value: &record.Spec.Target,
-		})
-		set.TTL = new(int64)
-		*set.TTL = 60
 
+		return &set, nil
+	}
+
+	ttl := int64(60)
+	if value, ok := properties[kAWSPropertiesTTL]; ok {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("E#4103: The %s property needs to be a positive number of seconds, got %s", kAWSPropertiesTTL, value)
+		}
+		ttl = parsed
 	}
 
-	return &set
+	set.TTL = &ttl
+	set.ResourceRecords = []types.ResourceRecord{{
+		Value: &record.Spec.Target,
+	}}
+
+	return &set, nil
+}
+
+// Records pointing at a load balancer are aliases unless the record says otherwise. Application and classic
+// load balancers are under `<region>.elb.amazonaws.com` while network load balancers are under `elb.<region>.amazonaws.com`.
+func isAWSLoadBalancer(host string) bool {
+	host = strings.TrimSuffix(host, ".")
+	return strings.HasSuffix(host, ".amazonaws.com") && strings.Contains(host, ".elb.")
 }
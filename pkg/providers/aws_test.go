@@ -0,0 +1,113 @@
+package providers
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/route53/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+)
+
+var _ = Describe("Route53", func() {
+	provider := &r53{hostedZoneID: "Z35SXDOTRQ7X7K"}
+
+	record := func(recordType, target string, properties map[string]string) *sequencer.DNSRecord {
+		r := &sequencer.DNSRecord{}
+		r.Spec.RecordType = recordType
+		r.Spec.Name = "my-workspace.example.com"
+		r.Spec.Target = target
+		r.Spec.Properties = properties
+		return r
+	}
+
+	It("creates a regular CNAME for hostnames outside of AWS", func() {
+		set, err := provider.resourceRecordSet(record("CNAME", "tunnel.cfargotunnel.com", nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(set.Type).To(Equal(types.RRTypeCname))
+		Expect(set.AliasTarget).To(BeNil())
+		Expect(*set.TTL).To(Equal(int64(60)))
+		Expect(*set.ResourceRecords[0].Value).To(Equal("tunnel.cfargotunnel.com"))
+	})
+
+	It("creates a regular A record for an IP", func() {
+		set, err := provider.resourceRecordSet(record("A", "203.0.113.10", map[string]string{"ttl": "300"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(set.Type).To(Equal(types.RRTypeA))
+		Expect(set.AliasTarget).To(BeNil())
+		Expect(*set.TTL).To(Equal(int64(300)))
+	})
+
+	It("aliases load balancers by default", func() {
+		for _, host := range []string{"my-lb-123.us-east-1.elb.amazonaws.com", "my-nlb-abc.elb.us-east-1.amazonaws.com"} {
+			set, err := provider.resourceRecordSet(record("CNAME", host, nil))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(set.Type).To(Equal(types.RRTypeA))
+			Expect(*set.AliasTarget.DNSName).To(Equal(host))
+			Expect(*set.AliasTarget.HostedZoneId).To(Equal("Z35SXDOTRQ7X7K"))
+			Expect(set.TTL).To(BeNil())
+		}
+	})
+
+	It("uses the alias properties", func() {
+		set, err := provider.resourceRecordSet(record("A", "d111111abcdef8.cloudfront.net", map[string]string{
+			"alias":                "true",
+			"aliasHostedZoneId":    "Z2FDTNDATAQYW2",
+			"evaluateTargetHealth": "true",
+		}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*set.AliasTarget.HostedZoneId).To(Equal("Z2FDTNDATAQYW2"))
+		Expect(set.AliasTarget.EvaluateTargetHealth).To(BeTrue())
+	})
+
+	It("doesn't alias a load balancer when alias is false", func() {
+		set, err := provider.resourceRecordSet(record("CNAME", "my-lb-123.us-east-1.elb.amazonaws.com", map[string]string{"alias": "false"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(set.Type).To(Equal(types.RRTypeCname))
+		Expect(set.AliasTarget).To(BeNil())
+	})
+
+	It("returns an error for invalid properties", func() {
+		_, err := provider.resourceRecordSet(record("A", "203.0.113.10", map[string]string{"ttl": "soon"}))
+		Expect(err).To(MatchError(ContainSubstring("E#4103")))
+
+		_, err = (&r53{}).resourceRecordSet(record("A", "my-lb-123.us-east-1.elb.amazonaws.com", nil))
+		Expect(err).To(MatchError(ContainSubstring("E#4103")))
+	})
+
+	Context("drift", func() {
+		It("matches a record set that is already up to date", func() {
+			desired, err := provider.resourceRecordSet(record("CNAME", "tunnel.cfargotunnel.com", nil))
+			Expect(err).NotTo(HaveOccurred())
+
+			current := &types.ResourceRecordSet{
+				Name:            aws.String("my-workspace.example.com."),
+				Type:            types.RRTypeCname,
+				TTL:             aws.Int64(60),
+				ResourceRecords: []types.ResourceRecord{{Value: aws.String("tunnel.cfargotunnel.com.")}},
+			}
+			Expect(sameRecordSet(current, desired)).To(BeTrue())
+
+			current.TTL = aws.Int64(300)
+			Expect(sameRecordSet(current, desired)).To(BeFalse())
+			Expect(sameRecordSet(nil, desired)).To(BeFalse())
+		})
+
+		It("compares the target of aliases", func() {
+			desired, err := provider.resourceRecordSet(record("CNAME", "my-lb-123.us-east-1.elb.amazonaws.com", nil))
+			Expect(err).NotTo(HaveOccurred())
+
+			current := &types.ResourceRecordSet{
+				Name: aws.String("my-workspace.example.com."),
+				Type: types.RRTypeA,
+				AliasTarget: &types.AliasTarget{
+					DNSName:      aws.String("my-lb-123.us-east-1.elb.amazonaws.com."),
+					HostedZoneId: aws.String("Z35SXDOTRQ7X7K"),
+				},
+			}
+			Expect(sameRecordSet(current, desired)).To(BeTrue())
+
+			current.AliasTarget.DNSName = aws.String("other-lb-456.us-east-1.elb.amazonaws.com.")
+			Expect(sameRecordSet(current, desired)).To(BeFalse())
+		})
+	})
+})
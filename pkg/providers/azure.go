@@ -0,0 +1,120 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/dns/armdns"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+)
+
+const kAzureSubscriptionID = "AZURE_SUBSCRIPTION_ID"
+const kAzureResourceGroup = "AZURE_RESOURCE_GROUP"
+const kAzureZoneName = "AZURE_ZONE_NAME"
+const kAzureTenantID = "AZURE_TENANT_ID"
+const kAzureClientID = "AZURE_CLIENT_ID"
+const kAzureClientSecret = "AZURE_CLIENT_SECRET"
+
+type azure struct {
+	resourceGroup string
+	zoneName      string
+	*armdns.RecordSetsClient
+}
+
+// Generate a new Azure DNS Provider. The subscription, the resource group and the zone name are required.
+// The service principal set with AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET is used when the values
+// include a client secret, otherwise credentials are loaded by the Azure SDK through DefaultAzureCredential, which supports Workload Identity.
+func NewAzureProvider(values Values) (*azure, error) {
+	subscriptionID, err := values(kAzureSubscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Subscription ID not found -- %w", err)
+	}
+
+	resourceGroup, err := values(kAzureResourceGroup)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Resource Group not found -- %w", err)
+	}
+
+	zoneName, err := values(kAzureZoneName)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Zone name not found -- %w", err)
+	}
+
+	var credential azcore.TokenCredential
+	if clientSecret := optionalValue(values, kAzureClientSecret, ""); clientSecret != "" {
+		credential, err = azidentity.NewClientSecretCredential(optionalValue(values, kAzureTenantID, ""), optionalValue(values, kAzureClientID, ""), clientSecret, nil)
+	} else {
+		credential, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("E#4003: Could not create new Azure credentials -- %w", err)
+	}
+
+	client, err := armdns.NewRecordSetsClient(strings.TrimSpace(subscriptionID), credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("E#4003: Could not create new Azure DNS Client -- %w", err)
+	}
+
+	return &azure{
+		resourceGroup:    strings.TrimSpace(resourceGroup),
+		zoneName:         strings.TrimSuffix(strings.TrimSpace(zoneName), "."),
+		RecordSetsClient: client,
+	}, nil
+}
+
+func (a *azure) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	set, err := a.recordSet(record)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.CreateOrUpdate(ctx, a.resourceGroup, a.zoneName, a.relativeName(record), armdns.RecordType(record.Spec.RecordType), *set, nil)
+	return err
+}
+
+// CreateOrUpdate replaces the record set, which is what an update needs.
+func (a *azure) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	return a.Create(ctx, record)
+}
+
+func (a *azure) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
+	_, err := a.RecordSetsClient.Delete(ctx, a.resourceGroup, a.zoneName, a.relativeName(record), armdns.RecordType(record.Spec.RecordType), nil)
+	return err
+}
+
+// Azure identifies record sets by their name relative to the zone, the apex
+// of the zone is `@`.
+func (a *azure) relativeName(record *sequencer.DNSRecord) string {
+	name := strings.TrimSuffix(record.Spec.Name, ".")
+	if name == a.zoneName {
+		return "@"
+	}
+
+	return strings.TrimSuffix(name, "."+a.zoneName)
+}
+
+func (a *azure) recordSet(record *sequencer.DNSRecord) (*armdns.RecordSet, error) {
+	properties := &armdns.RecordSetProperties{
+		TTL: to.Ptr[int64](60),
+	}
+
+	target := record.Spec.Target
+	switch armdns.RecordType(record.Spec.RecordType) {
+	case armdns.RecordTypeA:
+		properties.ARecords = []*armdns.ARecord{{IPv4Address: &target}}
+	case armdns.RecordTypeAAAA:
+		properties.AaaaRecords = []*armdns.AaaaRecord{{IPv6Address: &target}}
+	case armdns.RecordTypeCNAME:
+		properties.CnameRecord = &armdns.CnameRecord{Cname: &target}
+	case armdns.RecordTypeTXT:
+		properties.TxtRecords = []*armdns.TxtRecord{{Value: []*string{&target}}}
+	default:
+		return nil, fmt.Errorf("E#4004: Record type %s is not supported by the provider", record.Spec.RecordType)
+	}
+
+	return &armdns.RecordSet{Properties: properties}, nil
+}
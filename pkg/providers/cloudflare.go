@@ -2,6 +2,7 @@ package providers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -15,8 +16,13 @@ const kCloudflareZoneID = "CF_ZONE_ID"
 
 const kCloudflarePropertiesProxied = "proxied"
 
+// Records created by Sequencer have a comment that starts with this prefix, followed
+// by the owner ID.
+const kCloudflareCommentPrefix = "Record managed by sequencer"
+
 type cf struct {
-	zoneID string
+	zoneID  string
+	ownerID string
 	cloudflare.API
 }
 
@@ -26,13 +32,13 @@ type cf struct {
 // The CF_API_TOKEN value can either be sourced from an environment variable, or from a file.
 // The file needs to be located at `${kProviderConfigPath}/CF_API_TOKEN`
 // The file path is preferred as that's easier to work with different providers and Kubernetes secret system.
-func NewCloudflareProvider() (*cf, error) {
-	token, err := retrieveValueFromEnvOrFile(kCloudflareAPIKeyName)
+func NewCloudflareProvider(values Values) (*cf, error) {
+	token, err := values(kCloudflareAPIKeyName)
 	if err != nil {
 		return nil, fmt.Errorf("E#4002: API Key not found -- %w", err)
 	}
 
-	zoneID, err := retrieveValueFromEnvOrFile(kCloudflareZoneID)
+	zoneID, err := values(kCloudflareZoneID)
 	if err != nil {
 		return nil, fmt.Errorf("E#4002: Zone ID not found -- %w", err)
 	}
@@ -45,21 +51,31 @@ func NewCloudflareProvider() (*cf, error) {
 	}
 
 	return &cf{
-		zoneID: zoneID,
-		API:    *api,
+		zoneID:  zoneID,
+		ownerID: optionalValue(values, kOwnerID, kDefaultOwnerID),
+		API:     *api,
 	}, nil
 }
 
 func (c *cf) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	// The record might have been created by a previous attempt whose ID couldn't be stored in the status. The
+	// record is adopted instead of being created a second time.
+	existing, err := c.ownedRecord(ctx, record)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil {
+		record.Status.RemoteID = &existing.ID
+		return c.update(ctx, record, *existing)
+	}
+
 	dnsParams := cloudflare.CreateDNSRecordParams{
 		ZoneID:  c.zoneID,
 		Type:    record.Spec.RecordType,
 		Name:    record.Spec.Name,
 		Content: record.Spec.Target,
-	}
-
-	if workspaceName, ok := record.Labels[workspaces.InstanceLabel]; ok {
-		dnsParams.Comment = fmt.Sprintf("Record managed by sequencer for workspace: %s", workspaceName)
+		Comment: c.comment(record),
 	}
 
 	if proxied, ok := record.Spec.Properties[kCloudflarePropertiesProxied]; ok {
@@ -77,6 +93,72 @@ func (c *cf) Create(ctx context.Context, record *sequencer.DNSRecord) error {
 	return nil
 }
 
+// Update the record upstream so it matches the spec. The record is created again if it was
+// deleted from Cloudflare.
+func (c *cf) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	if record.Status.RemoteID == nil {
+		return c.Create(ctx, record)
+	}
+
+	current, err := c.GetDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), *record.Status.RemoteID)
+	if err != nil {
+		var notFound *cloudflare.NotFoundError
+		if errors.As(err, &notFound) {
+			return c.Create(ctx, record)
+		}
+		return err
+	}
+
+	return c.update(ctx, record, current)
+}
+
+func (c *cf) update(ctx context.Context, record *sequencer.DNSRecord, current cloudflare.DNSRecord) error {
+	dnsParams := cloudflare.UpdateDNSRecordParams{
+		ID:      current.ID,
+		Type:    record.Spec.RecordType,
+		Name:    record.Spec.Name,
+		Content: record.Spec.Target,
+		Proxied: current.Proxied,
+		Comment: new(string),
+	}
+	*dnsParams.Comment = c.comment(record)
+
+	if proxied, ok := record.Spec.Properties[kCloudflarePropertiesProxied]; ok {
+		dnsParams.Proxied = new(bool)
+		*dnsParams.Proxied = strings.EqualFold(proxied, "true")
+	}
+
+	if current.Content == dnsParams.Content && current.Comment == *dnsParams.Comment && equalProxied(current.Proxied, dnsParams.Proxied) {
+		return nil
+	}
+
+	_, err := c.UpdateDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), dnsParams)
+	return err
+}
+
+// Returns the record upstream with the same name and type as this one, if it's owned by this installation.
+func (c *cf) ownedRecord(ctx context.Context, record *sequencer.DNSRecord) (*cloudflare.DNSRecord, error) {
+	records, _, err := c.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(c.zoneID), cloudflare.ListDNSRecordsParams{
+		Type: record.Spec.RecordType,
+		Name: record.Spec.Name,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		if c.owns(records[i].Comment) {
+			return &records[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func equalProxied(a, b *bool) bool {
+	return (a != nil && *a) == (b != nil && *b)
+}
+
 func (c *cf) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
 	if record.Status.RemoteID == nil {
 		// Nothing to delete if the RemoteID was never added to this resource. It could
@@ -87,3 +169,54 @@ func (c *cf) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
 
 	return c.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), *record.Status.RemoteID)
 }
+
+// The comment marks the record as owned by this installation of Sequencer.
+func (c *cf) comment(record *sequencer.DNSRecord) string {
+	comment := fmt.Sprintf("%s (owner: %s)", kCloudflareCommentPrefix, c.ownerID)
+	if workspaceName, ok := record.Labels[workspaces.InstanceLabel]; ok {
+		comment = fmt.Sprintf("%s for workspace: %s", comment, workspaceName)
+	}
+
+	return comment
+}
+
+// Records created before the owner was part of the comment belong to the default owner.
+func (c *cf) owns(comment string) bool {
+	rest, ok := strings.CutPrefix(comment, kCloudflareCommentPrefix)
+	if !ok {
+		return false
+	}
+
+	if owner, ok := strings.CutPrefix(rest, " (owner: "); ok {
+		return strings.HasPrefix(owner, c.ownerID+")")
+	}
+
+	return c.ownerID == kDefaultOwnerID
+}
+
+func (c *cf) OwnedRecords(ctx context.Context) ([]OwnedRecord, error) {
+	records, _, err := c.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(c.zoneID), cloudflare.ListDNSRecordsParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []OwnedRecord
+	for _, record := range records {
+		if !c.owns(record.Comment) {
+			continue
+		}
+
+		owned = append(owned, OwnedRecord{
+			RecordType: record.Type,
+			Name:       record.Name,
+			Target:     record.Content,
+			remote:     record.ID,
+		})
+	}
+
+	return owned, nil
+}
+
+func (c *cf) DeleteOwnedRecord(ctx context.Context, record OwnedRecord) error {
+	return c.DeleteDNSRecord(ctx, cloudflare.ZoneIdentifier(c.zoneID), record.remote.(string))
+}
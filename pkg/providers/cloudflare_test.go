@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	cloudflare "github.com/cloudflare/cloudflare-go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/workspaces"
+)
+
+var _ = Describe("Cloudflare", func() {
+	It("marks the records with the owner", func() {
+		record := &sequencer.DNSRecord{}
+		record.Labels = map[string]string{workspaces.InstanceLabel: "my-workspace"}
+
+		provider := &cf{ownerID: "staging"}
+		Expect(provider.comment(record)).To(Equal("Record managed by sequencer (owner: staging) for workspace: my-workspace"))
+		Expect(provider.owns(provider.comment(record))).To(BeTrue())
+	})
+
+	It("only owns the records of its owner", func() {
+		provider := &cf{ownerID: "staging"}
+		Expect(provider.owns("Record managed by sequencer (owner: production)")).To(BeFalse())
+		Expect(provider.owns("Record managed by sequencer (owner: staging-2)")).To(BeFalse())
+		Expect(provider.owns("Created by hand")).To(BeFalse())
+		Expect(provider.owns("")).To(BeFalse())
+	})
+
+	It("gives the records created before owners existed to the default owner", func() {
+		legacy := "Record managed by sequencer for workspace: my-workspace"
+		Expect((&cf{ownerID: kDefaultOwnerID}).owns(legacy)).To(BeTrue())
+		Expect((&cf{ownerID: "staging"}).owns(legacy)).To(BeFalse())
+	})
+
+	Context("with the API", func() {
+		ctx := context.Background()
+
+		var server *httptest.Server
+		var records map[string]cloudflare.DNSRecord
+		var provider *cf
+		var record *sequencer.DNSRecord
+
+		BeforeEach(func() {
+			records = map[string]cloudflare.DNSRecord{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id, single := strings.CutPrefix(r.URL.Path, "/zones/zone/dns_records/")
+				var result any
+
+				switch {
+				case !single && r.Method == http.MethodGet:
+					matches := []cloudflare.DNSRecord{}
+					for _, record := range records {
+						if record.Name == r.URL.Query().Get("name") && record.Type == r.URL.Query().Get("type") {
+							matches = append(matches, record)
+						}
+					}
+					result = matches
+				case !single && r.Method == http.MethodPost:
+					var record cloudflare.DNSRecord
+					Expect(json.NewDecoder(r.Body).Decode(&record)).To(Succeed())
+					record.ID = fmt.Sprintf("r%d", len(records)+1)
+					records[record.ID] = record
+					result = record
+				case records[id].ID == "":
+					w.WriteHeader(http.StatusNotFound)
+					_, _ = w.Write([]byte(`{"success": false, "errors": [{"code": 81044, "message": "Record does not exist."}]}`))
+					return
+				case r.Method == http.MethodGet:
+					result = records[id]
+				case r.Method == http.MethodPatch:
+					record := records[id]
+					Expect(json.NewDecoder(r.Body).Decode(&record)).To(Succeed())
+					records[id] = record
+					result = record
+				}
+
+				_ = json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+			}))
+
+			api, err := cloudflare.NewWithAPIToken("token", cloudflare.BaseURL(server.URL))
+			Expect(err).NotTo(HaveOccurred())
+			provider = &cf{zoneID: "zone", ownerID: kDefaultOwnerID, API: *api}
+
+			record = &sequencer.DNSRecord{}
+			record.Spec = sequencer.DNSRecordSpec{RecordType: "A", Name: "my-workspace.example.com", Target: "203.0.113.10"}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("adopts the record created by a previous attempt", func() {
+			Expect(provider.Create(ctx, record)).To(Succeed())
+			id := *record.Status.RemoteID
+
+			// The status couldn't be stored after the record was created.
+			record.Status.RemoteID = nil
+			record.Spec.Target = "203.0.113.11"
+			Expect(provider.Update(ctx, record)).To(Succeed())
+			Expect(*record.Status.RemoteID).To(Equal(id))
+			Expect(records).To(HaveLen(1))
+			Expect(records[id].Content).To(Equal("203.0.113.11"))
+		})
+
+		It("doesn't adopt a record it doesn't own", func() {
+			records["r1"] = cloudflare.DNSRecord{ID: "r1", Type: "A", Name: record.Spec.Name, Content: "198.51.100.1", Comment: "Created by hand"}
+
+			Expect(provider.Create(ctx, record)).To(Succeed())
+			Expect(*record.Status.RemoteID).NotTo(Equal("r1"))
+			Expect(records["r1"].Content).To(Equal("198.51.100.1"))
+		})
+
+		It("creates the record again when it was deleted upstream", func() {
+			Expect(provider.Create(ctx, record)).To(Succeed())
+			delete(records, *record.Status.RemoteID)
+
+			Expect(provider.Update(ctx, record)).To(Succeed())
+			Expect(records).To(HaveLen(1))
+			Expect(records).To(HaveKey(*record.Status.RemoteID))
+		})
+	})
+})
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
+	core "k8s.io/api/core/v1"
 	"k8s.io/utils/env"
 
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
@@ -16,15 +18,44 @@ const kProviderConfigPath = "/var/run/configs/provider"
 
 type Provider interface {
 	Create(context.Context, *sequencer.DNSRecord) error
+
+	// Update the record upstream so it matches the spec of the DNSRecord. It is called when
+	// the spec changes and periodically to correct drift, so it needs to be idempotent and to create the record
+	// again if it was deleted upstream.
+	Update(context.Context, *sequencer.DNSRecord) error
 	Delete(context.Context, *sequencer.DNSRecord) error
 }
 
+// ChangeTracker is implemented by providers that apply changes asynchronously. Their Create and Update
+// return as soon as the change is accepted and store its ID in the record's PendingChange.
+type ChangeTracker interface {
+	// Returns true once the change is applied by all of the provider's nameservers.
+	ChangeApplied(ctx context.Context, changeID string) (bool, error)
+}
+
+// Source of the configuration values of a provider, eg. CF_API_TOKEN. An error is returned
+// when the value isn't set.
+type Values func(name string) (string, error)
+
+// Create the provider with its values read from environment variables or files, see retrieveValueFromEnvOrFile.
 func NewProvider(name string) (Provider, error) {
+	return NewProviderWithValues(name, retrieveValueFromEnvOrFile)
+}
+
+func NewProviderWithValues(name string, values Values) (Provider, error) {
 	switch name {
 	case "cloudflare":
-		return NewCloudflareProvider()
+		return NewCloudflareProvider(values)
 	case "aws":
-		return NewAWSProvider()
+		return NewAWSProvider(values)
+	case "google":
+		return NewGoogleProvider(values)
+	case "azure":
+		return NewAzureProvider(values)
+	case "rfc2136":
+		return NewRFC2136Provider(values)
+	case "local":
+		return NewLocalProvider(values)
 	case "":
 		return nil, fmt.Errorf("E#4001: The environment variable %s need to be set with a valid provider name", kProviderName)
 	}
@@ -33,11 +64,13 @@ func NewProvider(name string) (Provider, error) {
 }
 
 // Same as NewProvider but throw a fatal exception if
-// the configuration settings can't initialize a provider.
+// the configuration settings can't initialize a provider. Returns nil when no provider
+// name is configured, in which case every zone needs to be served by a DNSProvider.
 func DefaultProvider() Provider {
 	value, err := retrieveValueFromEnvOrFile(kProviderName)
-	if err != nil {
-		log.Fatal(err)
+	if err != nil || strings.TrimSpace(value) == "" {
+		log.Printf("%s isn't set, only DNSProviders will be used", kProviderName)
+		return nil
 	}
 
 	p, err := NewProvider(value)
@@ -64,3 +97,48 @@ func retrieveValueFromEnvOrFile(envNameOrFileName string) (content string, err e
 
 	return content, nil
 }
+
+// Values stored in a Secret, each key of the secret is the name of a value.
+func SecretValues(secret *core.Secret) Values {
+	return func(name string) (string, error) {
+		data, ok := secret.Data[name]
+		if !ok {
+			return "", fmt.Errorf("E#4002: %s does not exist in the secret %s/%s", name, secret.Namespace, secret.Name)
+		}
+		return string(data), nil
+	}
+}
+
+// Returns the value, or the fallback when the value isn't set.
+func optionalValue(values Values, name, fallback string) string {
+	content, err := values(name)
+	if err != nil || strings.TrimSpace(content) == "" {
+		return fallback
+	}
+
+	return strings.TrimSpace(content)
+}
+
+// Returns the name as a fully qualified domain name, with the trailing dot.
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
+
+// Returns the target of the record in the presentation format of zone files. Hostnames are
+// fully qualified and TXT values are quoted.
+func recordData(record *sequencer.DNSRecord) string {
+	target := record.Spec.Target
+	switch strings.ToUpper(record.Spec.RecordType) {
+	case "CNAME", "NS", "PTR":
+		return fqdn(target)
+	case "TXT":
+		if !strings.HasPrefix(target, "\"") {
+			return fmt.Sprintf("%q", target)
+		}
+	}
+
+	return target
+}
@@ -0,0 +1,109 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	dns "google.golang.org/api/dns/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+)
+
+const kGoogleProjectID = "GCP_PROJECT_ID"
+const kGoogleManagedZone = "GCP_MANAGED_ZONE"
+const kGoogleServiceAccountKey = "GCP_SERVICE_ACCOUNT_KEY"
+
+type gcp struct {
+	projectID   string
+	managedZone string
+	*dns.Service
+}
+
+// Generate a new Google Cloud DNS Provider. The project ID and the name of the managed zone
+// are required. Credentials are loaded from the service account key (JSON) stored in GCP_SERVICE_ACCOUNT_KEY
+// when it's set, otherwise the Application Default Credentials are used, which includes Workload Identity.
+func NewGoogleProvider(values Values) (*gcp, error) {
+	projectID, err := values(kGoogleProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Project ID not found -- %w", err)
+	}
+
+	managedZone, err := values(kGoogleManagedZone)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Managed Zone not found -- %w", err)
+	}
+
+	var opts []option.ClientOption
+	if key := optionalValue(values, kGoogleServiceAccountKey, ""); key != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(key)))
+	}
+
+	service, err := dns.NewService(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("E#4003: Could not create new Google Cloud DNS Client -- %w", err)
+	}
+
+	return &gcp{
+		projectID:   strings.TrimSpace(projectID),
+		managedZone: strings.TrimSpace(managedZone),
+		Service:     service,
+	}, nil
+}
+
+func (g *gcp) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	change := &dns.Change{
+		Additions: []*dns.ResourceRecordSet{g.resourceRecordSet(record)},
+	}
+
+	_, err := g.Changes.Create(g.projectID, g.managedZone, change).Context(ctx).Do()
+	return err
+}
+
+// Cloud DNS doesn't have an upsert, the current record set is replaced within the same change.
+func (g *gcp) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	expected := g.resourceRecordSet(record)
+	change := &dns.Change{
+		Additions: []*dns.ResourceRecordSet{expected},
+	}
+
+	current, err := g.ResourceRecordSets.Get(g.projectID, g.managedZone, expected.Name, expected.Type).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if !errors.As(err, &apiErr) || apiErr.Code != http.StatusNotFound {
+			return err
+		}
+	} else {
+		if current.Ttl == expected.Ttl && slices.Equal(current.Rrdatas, expected.Rrdatas) {
+			return nil
+		}
+		change.Deletions = []*dns.ResourceRecordSet{current}
+	}
+
+	_, err = g.Changes.Create(g.projectID, g.managedZone, change).Context(ctx).Do()
+	return err
+}
+
+func (g *gcp) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
+	// Deletions need to match the record set exactly, which is the case as the
+	// record set is generated from the same record.
+	change := &dns.Change{
+		Deletions: []*dns.ResourceRecordSet{g.resourceRecordSet(record)},
+	}
+
+	_, err := g.Changes.Create(g.projectID, g.managedZone, change).Context(ctx).Do()
+	return err
+}
+
+func (g *gcp) resourceRecordSet(record *sequencer.DNSRecord) *dns.ResourceRecordSet {
+	return &dns.ResourceRecordSet{
+		Name:    fqdn(record.Spec.Name),
+		Type:    record.Spec.RecordType,
+		Ttl:     60,
+		Rrdatas: []string{recordData(record)},
+	}
+}
@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/miekg/dns"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+)
+
+// TTL of the records served by the local provider. It's kept short so changes are picked up
+// quickly by resolvers during development.
+const kLocalTTL = 5
+
+// The local provider doesn't have an upstream service. Records are served straight from the DNSRecords of the
+// cluster by the DNS server embedded in the DNS controller, which makes it possible to resolve workspaces
+// without credentials, eg. in a Kind cluster.
+type local struct{}
+
+func NewLocalProvider(values Values) (*local, error) {
+	return &local{}, nil
+}
+
+// The record is served as soon as the DNSRecord is marked as created.
+func (l *local) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	return nil
+}
+
+func (l *local) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	return nil
+}
+
+func (l *local) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
+	return nil
+}
+
+// Returns true if the records of the provider are served by the embedded DNS server.
+func IsLocal(provider Provider) bool {
+	_, ok := provider.(*local)
+	return ok
+}
+
+// Answers the question with the records, as an authoritative server for the zones would. Wildcard records
+// answer for the names that don't have a record of their own, and a CNAME answers for every type.
+func LocalAnswer(records []sequencer.DNSRecord, question dns.Question) (int, []dns.RR, error) {
+	name := strings.ToLower(question.Name)
+
+	matching := recordsNamed(records, name)
+	if len(matching) == 0 {
+		if _, parent, ok := strings.Cut(name, "."); ok {
+			matching = recordsNamed(records, "*."+parent)
+		}
+	}
+
+	if len(matching) == 0 {
+		return dns.RcodeNameError, nil, nil
+	}
+
+	var answers []dns.RR
+	for _, record := range matching {
+		recordType := strings.ToUpper(record.Spec.RecordType)
+		if recordType != dns.TypeToString[question.Qtype] && recordType != "CNAME" && question.Qtype != dns.TypeANY {
+			continue
+		}
+
+		rr, err := dns.NewRR(fmt.Sprintf("%s %d IN %s %s", question.Name, kLocalTTL, recordType, recordData(&record)))
+		if err != nil {
+			return dns.RcodeServerFailure, nil, fmt.Errorf("E#4010: Couldn't serve the DNS record %s -- %w", record.Name, err)
+		}
+		answers = append(answers, rr)
+	}
+
+	// The name exists, but without a record of that type.
+	return dns.RcodeSuccess, answers, nil
+}
+
+func recordsNamed(records []sequencer.DNSRecord, name string) []sequencer.DNSRecord {
+	var matching []sequencer.DNSRecord
+	for _, record := range records {
+		if strings.EqualFold(fqdn(record.Spec.Name), name) {
+			matching = append(matching, record)
+		}
+	}
+
+	return matching
+}
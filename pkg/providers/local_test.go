@@ -0,0 +1,72 @@
+package providers
+
+import (
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+)
+
+var _ = Describe("Local", func() {
+	record := func(recordType, name, target string) sequencer.DNSRecord {
+		record := sequencer.DNSRecord{}
+		record.Spec.RecordType = recordType
+		record.Spec.Name = name
+		record.Spec.Target = target
+		return record
+	}
+
+	records := []sequencer.DNSRecord{
+		record("A", "my-workspace.example.com", "203.0.113.10"),
+		record("A", "*.my-workspace.example.com", "203.0.113.11"),
+		record("CNAME", "docs.example.com", "lb.example.net"),
+		record("TXT", "_acme-challenge.example.com", `"challenge"`),
+	}
+
+	question := func(name string, qtype uint16) dns.Question {
+		return dns.Question{Name: name, Qtype: qtype, Qclass: dns.ClassINET}
+	}
+
+	It("answers with the matching records", func() {
+		rcode, answers, err := LocalAnswer(records, question("My-Workspace.example.com.", dns.TypeA))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcode).To(Equal(dns.RcodeSuccess))
+		Expect(answers).To(HaveLen(1))
+		Expect(answers[0].(*dns.A).A.String()).To(Equal("203.0.113.10"))
+	})
+
+	It("answers with the wildcard for subdomains", func() {
+		_, answers, err := LocalAnswer(records, question("api.my-workspace.example.com.", dns.TypeA))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answers).To(HaveLen(1))
+		Expect(answers[0].Header().Name).To(Equal("api.my-workspace.example.com."))
+		Expect(answers[0].(*dns.A).A.String()).To(Equal("203.0.113.11"))
+	})
+
+	It("answers with the CNAME for any type", func() {
+		_, answers, err := LocalAnswer(records, question("docs.example.com.", dns.TypeAAAA))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answers).To(HaveLen(1))
+		Expect(answers[0].(*dns.CNAME).Target).To(Equal("lb.example.net."))
+	})
+
+	It("answers TXT records", func() {
+		_, answers, err := LocalAnswer(records, question("_acme-challenge.example.com.", dns.TypeTXT))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(answers).To(HaveLen(1))
+		Expect(answers[0].(*dns.TXT).Txt).To(Equal([]string{"challenge"}))
+	})
+
+	It("answers without records when the type doesn't exist", func() {
+		rcode, answers, err := LocalAnswer(records, question("my-workspace.example.com.", dns.TypeAAAA))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcode).To(Equal(dns.RcodeSuccess))
+		Expect(answers).To(BeEmpty())
+	})
+
+	It("answers that unknown names don't exist", func() {
+		rcode, _, err := LocalAnswer(records, question("unknown.example.com.", dns.TypeA))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rcode).To(Equal(dns.RcodeNameError))
+	})
+})
@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Identifies the Sequencer installation that owns the records. Installations that share
+// a zone need to use a different owner ID so they don't remove each other's records.
+const kOwnerID = "SEQUENCER_OWNER_ID"
+const kDefaultOwnerID = "default"
+
+// Prefix of the TXT records that mark a record as owned by Sequencer for the providers
+// that can't store metadata on the record itself.
+const kOwnershipPrefix = "_sequencer-"
+
+// A record that Sequencer created upstream.
+type OwnedRecord struct {
+	RecordType string
+	Name       string
+	Target     string
+
+	// Provider specific data needed to delete the record.
+	remote any
+}
+
+// Implemented by the providers that can find the records they created, so the
+// records that don't have a DNSRecord anymore can be removed.
+type Sweeper interface {
+	// Returns the records owned by this installation of Sequencer.
+	OwnedRecords(context.Context) ([]OwnedRecord, error)
+
+	// Delete the record upstream, as well as what marks it as owned.
+	DeleteOwnedRecord(context.Context, OwnedRecord) error
+}
+
+// Value of the TXT record that marks a record as owned.
+func ownershipValue(ownerID string) string {
+	return fmt.Sprintf("\"heritage=sequencer,owner=%s\"", ownerID)
+}
+
+// Name of the TXT record that marks the record as owned. The record type is part of the name as
+// different types can share the same name. Wildcards need to be the leftmost label so they are spelled out.
+func ownershipName(name, recordType string) string {
+	prefix := kOwnershipPrefix + strings.ToLower(recordType)
+	if rest, ok := strings.CutPrefix(name, "*."); ok {
+		return fmt.Sprintf("%s-wildcard.%s", prefix, rest)
+	}
+
+	return fmt.Sprintf("%s.%s", prefix, name)
+}
+
+// Inverse of ownershipName, returns false if the name isn't one of an ownership record.
+func parseOwnershipName(name string) (recordName, recordType string, ok bool) {
+	label, rest, found := strings.Cut(name, ".")
+	if !found || !strings.HasPrefix(label, kOwnershipPrefix) {
+		return "", "", false
+	}
+
+	recordType = strings.TrimPrefix(label, kOwnershipPrefix)
+	if t, wildcard := strings.CutSuffix(recordType, "-wildcard"); wildcard {
+		return "*." + rest, strings.ToUpper(t), true
+	}
+
+	return rest, strings.ToUpper(recordType), true
+}
@@ -0,0 +1,25 @@
+package providers
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ownership", func() {
+	It("names the ownership record after the record", func() {
+		Expect(ownershipName("my-workspace.example.com", "CNAME")).To(Equal("_sequencer-cname.my-workspace.example.com"))
+		Expect(ownershipName("*.my-workspace.example.com", "A")).To(Equal("_sequencer-a-wildcard.my-workspace.example.com"))
+	})
+
+	It("parses the name of ownership records", func() {
+		for _, name := range []string{"my-workspace.example.com", "*.my-workspace.example.com"} {
+			recordName, recordType, ok := parseOwnershipName(ownershipName(name, "AAAA"))
+			Expect(ok).To(BeTrue())
+			Expect(recordName).To(Equal(name))
+			Expect(recordType).To(Equal("AAAA"))
+		}
+
+		_, _, ok := parseOwnershipName("my-workspace.example.com")
+		Expect(ok).To(BeFalse())
+	})
+})
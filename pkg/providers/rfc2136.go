@@ -0,0 +1,130 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+)
+
+const kRFC2136Nameserver = "RFC2136_NAMESERVER"
+const kRFC2136Zone = "RFC2136_ZONE"
+const kRFC2136TSIGKeyName = "RFC2136_TSIG_KEY_NAME"
+const kRFC2136TSIGSecret = "RFC2136_TSIG_SECRET"
+const kRFC2136TSIGAlgorithm = "RFC2136_TSIG_ALGORITHM"
+
+type rfc2136 struct {
+	nameserver string
+	zone       string
+
+	// TSIG is only used when the key name is set.
+	keyName   string
+	algorithm string
+	client    *dns.Client
+}
+
+// Generate a new RFC2136 Provider that sends dynamic updates to a nameserver, like BIND or
+// PowerDNS. The nameserver (host:port) and the zone are required. Updates are signed with TSIG
+// when RFC2136_TSIG_KEY_NAME is set, in which case RFC2136_TSIG_SECRET is required too. The algorithm
+// defaults to hmac-sha256.
+func NewRFC2136Provider(values Values) (*rfc2136, error) {
+	nameserver, err := values(kRFC2136Nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Nameserver not found -- %w", err)
+	}
+
+	zone, err := values(kRFC2136Zone)
+	if err != nil {
+		return nil, fmt.Errorf("E#4002: Zone not found -- %w", err)
+	}
+
+	provider := &rfc2136{
+		nameserver: strings.TrimSpace(nameserver),
+		zone:       fqdn(strings.TrimSpace(zone)),
+		client:     &dns.Client{Net: "tcp"},
+	}
+
+	if keyName := optionalValue(values, kRFC2136TSIGKeyName, ""); keyName != "" {
+		secret, err := values(kRFC2136TSIGSecret)
+		if err != nil {
+			return nil, fmt.Errorf("E#4002: TSIG secret not found -- %w", err)
+		}
+
+		algorithm := optionalValue(values, kRFC2136TSIGAlgorithm, "hmac-sha256")
+		provider.keyName = fqdn(keyName)
+		provider.algorithm = fqdn(algorithm)
+		provider.client.TsigSecret = map[string]string{provider.keyName: strings.TrimSpace(secret)}
+	}
+
+	return provider, nil
+}
+
+func (r *rfc2136) Create(ctx context.Context, record *sequencer.DNSRecord) error {
+	rr, err := r.resourceRecord(record)
+	if err != nil {
+		return err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(r.zone)
+	msg.Insert([]dns.RR{rr})
+
+	return r.exchange(ctx, msg)
+}
+
+// Replace the records of the same name and type with the record in a single update.
+func (r *rfc2136) Update(ctx context.Context, record *sequencer.DNSRecord) error {
+	rr, err := r.resourceRecord(record)
+	if err != nil {
+		return err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(r.zone)
+	msg.RemoveRRset([]dns.RR{rr})
+	msg.Insert([]dns.RR{rr})
+
+	return r.exchange(ctx, msg)
+}
+
+func (r *rfc2136) Delete(ctx context.Context, record *sequencer.DNSRecord) error {
+	rr, err := r.resourceRecord(record)
+	if err != nil {
+		return err
+	}
+
+	msg := new(dns.Msg)
+	msg.SetUpdate(r.zone)
+	msg.Remove([]dns.RR{rr})
+
+	return r.exchange(ctx, msg)
+}
+
+func (r *rfc2136) exchange(ctx context.Context, msg *dns.Msg) error {
+	if r.keyName != "" {
+		msg.SetTsig(r.keyName, r.algorithm, 300, time.Now().Unix())
+	}
+
+	response, _, err := r.client.ExchangeContext(ctx, msg, r.nameserver)
+	if err != nil {
+		return err
+	}
+
+	if response.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("E#4005: The nameserver %s refused the update -- %s", r.nameserver, dns.RcodeToString[response.Rcode])
+	}
+
+	return nil
+}
+
+func (r *rfc2136) resourceRecord(record *sequencer.DNSRecord) (dns.RR, error) {
+	rr, err := dns.NewRR(fmt.Sprintf("%s 60 IN %s %s", fqdn(record.Spec.Name), record.Spec.RecordType, recordData(record)))
+	if err != nil {
+		return nil, fmt.Errorf("E#4004: Record could not be converted for the nameserver -- %w", err)
+	}
+
+	return rr, nil
+}
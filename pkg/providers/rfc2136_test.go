@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"github.com/miekg/dns"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	core "k8s.io/api/core/v1"
+)
+
+var _ = Describe("RFC2136", func() {
+	const keyName = "sequencer."
+	const secret = "c2VxdWVuY2VyLXRzaWctc2VjcmV0"
+
+	var server *dns.Server
+	var mu sync.Mutex
+	var updates []*dns.Msg
+	var values *core.Secret
+
+	record := &sequencer.DNSRecord{}
+	record.Spec.RecordType = "CNAME"
+	record.Spec.Name = "my-workspace.example.com"
+	record.Spec.Target = "lb.example.net"
+
+	BeforeEach(func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		updates = nil
+		server = &dns.Server{
+			Listener:   listener,
+			TsigSecret: map[string]string{keyName: secret},
+			// The default accept func refuses dynamic updates.
+			MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+			Handler: dns.HandlerFunc(func(w dns.ResponseWriter, r *dns.Msg) {
+				response := new(dns.Msg)
+				response.SetReply(r)
+				if r.IsTsig() == nil || w.TsigStatus() != nil {
+					response.Rcode = dns.RcodeRefused
+				} else {
+					mu.Lock()
+					updates = append(updates, r)
+					mu.Unlock()
+					response.SetTsig(keyName, dns.HmacSHA256, 300, int64(r.IsTsig().TimeSigned))
+				}
+				_ = w.WriteMsg(response)
+			}),
+		}
+		go func() { _ = server.ActivateAndServe() }()
+
+		values = &core.Secret{Data: map[string][]byte{
+			kRFC2136Nameserver: []byte(listener.Addr().String()),
+			kRFC2136Zone:       []byte("example.com"),
+		}}
+	})
+
+	AfterEach(func() {
+		Expect(server.Shutdown()).To(Succeed())
+	})
+
+	It("sends signed updates to the nameserver", func() {
+		values.Data[kRFC2136TSIGKeyName] = []byte("sequencer")
+		values.Data[kRFC2136TSIGSecret] = []byte(secret)
+
+		provider, err := NewRFC2136Provider(SecretValues(values))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(provider.Create(context.Background(), record)).To(Succeed())
+		Expect(provider.Delete(context.Background(), record)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(updates).To(HaveLen(2))
+		Expect(updates[0].Question[0].Name).To(Equal("example.com."))
+		Expect(updates[0].Ns).To(HaveLen(1))
+		Expect(updates[0].Ns[0].String()).To(Equal("my-workspace.example.com.\t60\tIN\tCNAME\tlb.example.net."))
+		Expect(updates[1].Ns[0].Header().Class).To(Equal(uint16(dns.ClassNONE)))
+	})
+
+	It("replaces the record set on update", func() {
+		values.Data[kRFC2136TSIGKeyName] = []byte("sequencer")
+		values.Data[kRFC2136TSIGSecret] = []byte(secret)
+
+		provider, err := NewRFC2136Provider(SecretValues(values))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(provider.Update(context.Background(), record)).To(Succeed())
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(updates).To(HaveLen(1))
+		Expect(updates[0].Ns).To(HaveLen(2))
+		Expect(updates[0].Ns[0].Header().Class).To(Equal(uint16(dns.ClassANY)))
+		Expect(updates[0].Ns[1].String()).To(Equal("my-workspace.example.com.\t60\tIN\tCNAME\tlb.example.net."))
+	})
+
+	It("returns an error when the nameserver refuses the update", func() {
+		GinkgoT().Setenv(kRFC2136Nameserver, string(values.Data[kRFC2136Nameserver]))
+		GinkgoT().Setenv(kRFC2136Zone, "example.com")
+
+		provider, err := NewProvider("rfc2136")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = provider.Create(context.Background(), record)
+		Expect(err).To(MatchError(ContainSubstring("E#4005")))
+	})
+})
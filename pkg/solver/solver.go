@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
@@ -11,9 +12,17 @@ import (
 	"github.com/cert-manager/cert-manager/pkg/acme/webhook"
 	whapi "github.com/cert-manager/cert-manager/pkg/acme/webhook/apis/acme/v1alpha1"
 	sequencer "github.com/pier-oliviert/sequencer/api/v1alpha1"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/conditions"
+	"github.com/pier-oliviert/sequencer/api/v1alpha1/dnsrecords"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
+// How long Present waits for the challenge record to propagate. cert-manager calls Present
+// again if it times out.
+const kPropagationTimeout = 2 * time.Minute
+const kPropagationInterval = 2 * time.Second
+
 func New(name, namespace string) webhook.Solver {
 	return &SolverProvider{name: name, namespace: namespace}
 }
@@ -60,25 +69,82 @@ func (sp *SolverProvider) Present(ch *whapi.ChallengeRequest) error {
 	// The Targets includes escaped double quotes per https://datatracker.ietf.org/doc/html/rfc1464
 	// as those TXT fields are expected to be quoted. AWS Route53 requires them while others(cloudflare)
 	// do not.
-	ep := &sequencer.DNSRecord{
-		ObjectMeta: meta.ObjectMeta{
-			Namespace:    sp.namespace,
-			GenerateName: "dns01-challenge-",
-			Labels: map[string]string{
-				"solver.se.quencer.io/dns-name": strings.TrimPrefix(strings.TrimSuffix(ch.DNSName, "."), "_"),
-				"solver.se.quencer.io/fqdn":     strings.TrimPrefix(strings.TrimSuffix(ch.ResolvedFQDN, "."), "_"),
-				"solver.se.quencer.io/zone":     strings.TrimPrefix(strings.TrimSuffix(ch.ResolvedZone, "."), "_"),
+	target := fmt.Sprintf("\"%s\"", ch.Key)
+
+	// cert-manager calls Present again until it succeeds, the record created by a previous call is reused.
+	ep, err := sp.challengeRecord(ctx, ch.ResolvedFQDN, target)
+	if err != nil {
+		return err
+	}
+
+	if ep == nil {
+		ep = &sequencer.DNSRecord{
+			ObjectMeta: meta.ObjectMeta{
+				Namespace:    sp.namespace,
+				GenerateName: "dns01-challenge-",
+				Labels: map[string]string{
+					"solver.se.quencer.io/dns-name": strings.TrimPrefix(strings.TrimSuffix(ch.DNSName, "."), "_"),
+					"solver.se.quencer.io/fqdn":     strings.TrimPrefix(strings.TrimSuffix(ch.ResolvedFQDN, "."), "_"),
+					"solver.se.quencer.io/zone":     strings.TrimPrefix(strings.TrimSuffix(ch.ResolvedZone, "."), "_"),
+				},
 			},
-		},
-		Spec: sequencer.DNSRecordSpec{
-			RecordType: "TXT",
-			Name:       ch.ResolvedFQDN,
-			Target:     fmt.Sprintf("\"%s\"", ch.Key),
-		},
+			Spec: sequencer.DNSRecordSpec{
+				RecordType: "TXT",
+				Name:       ch.ResolvedFQDN,
+				Target:     target,
+			},
+		}
+
+		created := &sequencer.DNSRecord{}
+		if err := sp.client.Post().Namespace(sp.namespace).Resource("dnsrecords").Body(ep).Do(ctx).Into(created); err != nil {
+			return err
+		}
+		ep = created
+	}
+
+	return sp.waitForPropagation(ctx, ep.Name)
+}
+
+// Returns the record that was created for this challenge, if any.
+func (sp *SolverProvider) challengeRecord(ctx context.Context, fqdn, target string) (*sequencer.DNSRecord, error) {
+	var challenges sequencer.DNSRecordList
+	opts := meta.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", "solver.se.quencer.io/fqdn", strings.TrimPrefix(strings.TrimSuffix(fqdn, "."), "_")),
 	}
-	result := sp.client.Post().Namespace(sp.namespace).Resource("dnsrecords").Body(ep).Do(ctx)
 
-	return result.Error()
+	err := sp.client.Get().Namespace(sp.namespace).Resource("dnsrecords").VersionedParams(&opts, scheme.ParameterCodec).Do(ctx).Into(&challenges)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range challenges.Items {
+		if record.Spec.Target == target && !record.IsTerminating() {
+			return &record, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// Wait for the DNS controller to create the record, and to verify its propagation when it's configured to,
+// so the ACME server doesn't look for the challenge before it resolves.
+func (sp *SolverProvider) waitForPropagation(ctx context.Context, name string) error {
+	return wait.PollUntilContextTimeout(ctx, kPropagationInterval, kPropagationTimeout, true, func(ctx context.Context) (bool, error) {
+		var record sequencer.DNSRecord
+		if err := sp.client.Get().Namespace(sp.namespace).Resource("dnsrecords").Name(name).Do(ctx).Into(&record); err != nil {
+			return false, err
+		}
+
+		if err := record.ConditionError(); err != nil {
+			return false, err
+		}
+
+		if !conditions.IsStatusConditionPresentAndEqual(record.Status.Conditions, dnsrecords.ProviderCondition, conditions.ConditionCreated) {
+			return false, nil
+		}
+
+		return !conditions.IsStatusConditionPresentAndEqual(record.Status.Conditions, dnsrecords.PropagatedCondition, conditions.ConditionInProgress), nil
+	})
 }
 
 func (sp *SolverProvider) CleanUp(ch *whapi.ChallengeRequest) error {